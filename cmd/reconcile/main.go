@@ -0,0 +1,28 @@
+// Command reconcile recomputes every wallet balance from the ledger and writes
+// a discrepancy report, run it nightly from cron or a Kubernetes CronJob.
+// It exits with status 2 when the books do not balance
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"user-service/db"
+	"user-service/reconcile"
+)
+
+func main() {
+	dir := flag.String("dir", reconcile.ReportDir(), "directory the JSON and CSV reports are written to")
+	freeze := flag.Bool("freeze", reconcile.FreezeFromEnv(), "freeze wallets that disagree with their ledger")
+	flag.Parse()
+
+	db.Init()
+	report, err := reconcile.Run(context.Background(), *dir, *freeze)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !report.Balanced() {
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,245 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+	"strconv"
+	"user-service/forms"
+	"user-service/middlewares"
+	"user-service/models"
+)
+
+func respondAddressError(c *gin.Context, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = errAddressNotFound
+	}
+	_ = c.Error(err)
+}
+
+func addressID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		_ = c.Error(errInvalidID)
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// PingExample godoc
+// @Summary Add a buyer address
+// @Schemes
+// @Description Add a shipping or billing address, the first address of each type becomes the default
+// @Tags example
+// @Accept json
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer YourJWTToken"
+// @Param data body forms.AddressInput true "Address"
+// @Success 201 {object} forms.AddressResponse
+// @Failure 409 {object} forms.ProblemResponse "Address book is full"
+// @Failure 422 {object} forms.ProblemResponse "Postal code does not match the country"
+// @Router /customer/addresses [post]
+func CreateBuyerAddress(c *gin.Context) {
+	var input forms.AddressInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	authHeader := c.GetHeader("Authorization")
+	tokenString := authHeader[len("Bearer "):]
+	userID, err := middlewares.GetCustomerJwtMiddleware().GetUserIDFromToken(tokenString)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	user := models.Buyer{ID: userID}
+
+	address := addressFromInput(input)
+	if err := user.CreateAddress(c.Request.Context(), &address); err != nil {
+		respondAddressError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, generateAddressData(address))
+}
+
+// PingExample godoc
+// @Summary List buyer addresses
+// @Schemes
+// @Description List the address book, default addresses first
+// @Tags example
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer YourJWTToken"
+// @Param type query string false "Address type" Enums(shipping, billing)
+// @Success 200 {array} forms.AddressResponse
+// @Router /customer/addresses [get]
+func ListBuyerAddresses(c *gin.Context) {
+	var query forms.AddressQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	authHeader := c.GetHeader("Authorization")
+	tokenString := authHeader[len("Bearer "):]
+	userID, err := middlewares.GetCustomerJwtMiddleware().GetUserIDFromToken(tokenString)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	user := models.Buyer{ID: userID}
+
+	addresses, err := user.ListAddresses(c.Request.Context(), query.Type)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	response := make([]forms.AddressResponse, 0, len(addresses))
+	for _, address := range addresses {
+		response = append(response, generateAddressData(address))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// PingExample godoc
+// @Summary Get a buyer address
+// @Schemes
+// @Tags example
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer YourJWTToken"
+// @Param id path int true "Address ID"
+// @Success 200 {object} forms.AddressResponse
+// @Failure 404 {object} forms.ProblemResponse "No such address"
+// @Router /customer/addresses/{id} [get]
+func GetBuyerAddress(c *gin.Context) {
+	id, ok := addressID(c)
+	if !ok {
+		return
+	}
+	authHeader := c.GetHeader("Authorization")
+	tokenString := authHeader[len("Bearer "):]
+	userID, err := middlewares.GetCustomerJwtMiddleware().GetUserIDFromToken(tokenString)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	user := models.Buyer{ID: userID}
+
+	address, err := user.RetrieveAddress(c.Request.Context(), id)
+	if err != nil {
+		respondAddressError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, generateAddressData(*address))
+}
+
+// PingExample godoc
+// @Summary Replace a buyer address
+// @Schemes
+// @Description Replace every field of the address, setting is_default moves the default of its type to it
+// @Tags example
+// @Accept json
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer YourJWTToken"
+// @Param id path int true "Address ID"
+// @Param data body forms.AddressInput true "Address"
+// @Success 200 {object} forms.AddressResponse
+// @Failure 404 {object} forms.ProblemResponse "No such address"
+// @Failure 422 {object} forms.ProblemResponse "Postal code does not match the country"
+// @Router /customer/addresses/{id} [put]
+func UpdateBuyerAddress(c *gin.Context) {
+	id, ok := addressID(c)
+	if !ok {
+		return
+	}
+	var input forms.AddressInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	authHeader := c.GetHeader("Authorization")
+	tokenString := authHeader[len("Bearer "):]
+	userID, err := middlewares.GetCustomerJwtMiddleware().GetUserIDFromToken(tokenString)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	user := models.Buyer{ID: userID}
+
+	address, err := user.UpdateAddress(c.Request.Context(), id, addressFromInput(input))
+	if err != nil {
+		respondAddressError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, generateAddressData(*address))
+}
+
+// PingExample godoc
+// @Summary Delete a buyer address
+// @Schemes
+// @Description Remove the address, when it was the default the most recent address of the same type takes over
+// @Tags example
+// @Security JWT Key
+// @param Authorization header string true "Bearer YourJWTToken"
+// @Param id path int true "Address ID"
+// @Success 204
+// @Failure 404 {object} forms.ProblemResponse "No such address"
+// @Router /customer/addresses/{id} [delete]
+func DeleteBuyerAddress(c *gin.Context) {
+	id, ok := addressID(c)
+	if !ok {
+		return
+	}
+	authHeader := c.GetHeader("Authorization")
+	tokenString := authHeader[len("Bearer "):]
+	userID, err := middlewares.GetCustomerJwtMiddleware().GetUserIDFromToken(tokenString)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	user := models.Buyer{ID: userID}
+
+	if err := user.DeleteAddress(c.Request.Context(), id); err != nil {
+		respondAddressError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func addressFromInput(input forms.AddressInput) models.BuyerAddress {
+	return models.BuyerAddress{
+		Type:          input.Type,
+		Label:         input.Label,
+		RecipientName: input.RecipientName,
+		Phone:         input.Phone,
+		Line1:         input.Line1,
+		Line2:         input.Line2,
+		City:          input.City,
+		State:         input.State,
+		PostalCode:    input.PostalCode,
+		Country:       input.Country,
+		IsDefault:     input.IsDefault,
+	}
+}
+
+func generateAddressData(address models.BuyerAddress) forms.AddressResponse {
+	return forms.AddressResponse{
+		ID:            address.ID,
+		Type:          address.Type,
+		Label:         address.Label,
+		RecipientName: address.RecipientName,
+		Phone:         address.Phone,
+		Line1:         address.Line1,
+		Line2:         address.Line2,
+		City:          address.City,
+		State:         address.State,
+		PostalCode:    address.PostalCode,
+		Country:       address.Country,
+		IsDefault:     address.IsDefault,
+		CreatedAt:     address.CreatedAt,
+		UpdatedAt:     address.UpdatedAt,
+	}
+}
@@ -1,13 +1,16 @@
 package controllers
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 	"net/http"
 	"user-service/enums"
 	"user-service/forms"
 	"user-service/middlewares"
 	"user-service/models"
+	"user-service/payment"
 	"user-service/service"
 )
 
@@ -24,18 +27,22 @@ import (
 func BuyerLogin(c *gin.Context) {
 	var loginData forms.UserSignIn
 	if err := c.ShouldBind(&loginData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
 	var userModel = models.Buyer{}
 	isSuccess, err := userModel.Login(c.Request.Context(), loginData)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// Same answer as a wrong password so usernames cannot be probed
+		isSuccess, err = false, nil
+	}
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 	if !isSuccess {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "authentication failed"})
+		_ = c.Error(errAuthenticationFailed)
 		return
 	}
 
@@ -45,16 +52,17 @@ func BuyerLogin(c *gin.Context) {
 		RoleGroupName: enums.Buyer,
 		Firstname:     userModel.BuyerProfile.FirstName,
 		Lastname:      userModel.BuyerProfile.LastName,
+		Locale:        models.PreferredLocale(c.Request.Context(), enums.Buyer, userModel.ID),
 	}
 	tokenString, err := middlewares.GetCustomerJwtMiddleware().GenerateAccessToken(&tokenUserInput)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
 	refreshTokenString, err := middlewares.GetCustomerJwtMiddleware().GenerateRefreshToken(&tokenUserInput)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
@@ -74,19 +82,21 @@ func BuyerLogin(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param data body forms.UserSignUp true "Signup input"
+// @Param X-Device-ID header string false "Device ID the service issued, a new one is returned when missing"
 // @Success 200 {object} forms.LoginResponse
+// @Header 200 {string} X-Device-ID "Newly issued device ID, send it on later requests"
 // @Router /customer/register [post]
 func RegisterCustomer(c *gin.Context) {
 	var input forms.UserSignUp
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 	var userModel = new(models.Buyer)
-	newUser, err := userModel.CreateAccount(c.Request.Context(), input)
+	newUser, err := userModel.CreateAccount(c.Request.Context(), input, signupSignal(c))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 	tokenUserInput := service.TokenUserInput{
@@ -95,16 +105,17 @@ func RegisterCustomer(c *gin.Context) {
 		RoleGroupName: enums.Buyer,
 		Firstname:     newUser.BuyerProfile.FirstName,
 		Lastname:      newUser.BuyerProfile.LastName,
+		Locale:        models.PreferredLocale(c.Request.Context(), enums.Buyer, newUser.ID),
 	}
 	tokenString, err := middlewares.GetCustomerJwtMiddleware().GenerateAccessToken(&tokenUserInput)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
 	refreshTokenString, err := middlewares.GetCustomerJwtMiddleware().GenerateRefreshToken(&tokenUserInput)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
@@ -130,23 +141,23 @@ func BuyerRefreshTokenHandler(c *gin.Context) {
 	var input forms.RefreshTokenRequest
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
 	claims, err := middlewares.GetCustomerJwtMiddleware().ValidateRefreshAccessToken(input.RefreshToken)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 	userID, err := uuid.Parse(claims["userid"].(string))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(service.ErrInvalidToken)
 		return
 	}
 	var user models.Buyer
 	if err := user.RetrieveByUserIDWithProfile(c.Request.Context(), userID); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 	tokenUserInput := service.TokenUserInput{
@@ -155,10 +166,11 @@ func BuyerRefreshTokenHandler(c *gin.Context) {
 		RoleGroupName: enums.Buyer,
 		Firstname:     user.BuyerProfile.FirstName,
 		Lastname:      user.BuyerProfile.LastName,
+		Locale:        models.PreferredLocale(c.Request.Context(), enums.Buyer, user.ID),
 	}
 	accessToken, err := middlewares.GetCustomerJwtMiddleware().GenerateAccessToken(&tokenUserInput)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"access_token": accessToken})
@@ -174,59 +186,90 @@ func BuyerRefreshTokenHandler(c *gin.Context) {
 // @Security JWT Key
 // @param Authorization header string true "Bearer YourJWTToken"
 // @Success 200 {object} forms.UserResponse
+// @Header 200 {string} ETag "Version of the profile, send it in If-Match to update"
 // @Router /customer/profile [get]
 func GetBuyerProfileHandler(c *gin.Context) {
 	authHeader := c.GetHeader("Authorization")
 	tokenString := authHeader[len("Bearer "):]
 	userID, err := middlewares.GetCustomerJwtMiddleware().GetUserIDFromToken(tokenString)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 	user := models.Buyer{}
 
 	err = user.RetrieveByUserIDWithProfile(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
 	loginResponse := generateBuyerData(user)
+	c.Header("ETag", profileETag(user.BuyerProfile.UpdatedAt))
 	c.JSON(http.StatusOK, loginResponse)
 }
 
 func generateBuyerData(userModel models.Buyer) forms.UserResponse {
 	return forms.UserResponse{
-		ID:       userModel.ID,
-		Username: userModel.Username,
+		ID:           userModel.ID,
+		Username:     userModel.Username,
+		ReferralCode: userModel.ReferralCode,
+		Phone:        userModel.Phone,
 		Profile: forms.UserProfileResponse{
 			FirstName: userModel.BuyerProfile.FirstName,
 			LastName:  userModel.BuyerProfile.LastName,
+			Avatar:    models.ImageURLs(enums.ImageAvatar, userModel.BuyerProfile.Avatar),
 		},
 		Group: forms.UserGroupResponse{
 			Name: enums.Buyer,
 		},
 		WalletBalance: userModel.BuyerWallet.Balance,
+		HeldBalance:   userModel.BuyerWallet.HeldBalance,
+		PromoBalance:  userModel.BuyerWallet.PromoBalance,
+		Wallets:       generateBuyerWalletData(userModel),
+	}
+}
+
+func generateBuyerWalletData(userModel models.Buyer) []forms.WalletResponse {
+	wallets := userModel.Wallets
+	if len(wallets) == 0 {
+		// Freshly created accounts only carry the default wallet
+		wallets = []models.BuyerWallet{userModel.BuyerWallet}
+	}
+	response := make([]forms.WalletResponse, 0, len(wallets))
+	for _, wallet := range wallets {
+		response = append(response, forms.WalletResponse{
+			Currency:     wallet.Currency,
+			Balance:      wallet.Balance,
+			HeldBalance:  wallet.HeldBalance,
+			PromoBalance: wallet.PromoBalance,
+		})
 	}
+	return response
 }
 
 // PingExample godoc
 // @Summary Topup customer wallet balance to purchase stuff
 // @Schemes
-// @Description Increase custom wallet balance
+// @Description Start a top-up payment, the wallet is credited once the payment provider confirms it
 // @Tags example
 // @Accept json
 // @Produce json
 // @Security JWT Key
 // @param Authorization header string true "Bearer YourJWTToken"
 // @Param data body forms.AddWalletBalanceInput true "Increment balance by certain amount"
-// @Success 200 {object} forms.AddWalletBalanceResponse
+// @Param Idempotency-Key header string false "Unique key to safely retry the request"
+// @Param X-Device-ID header string false "Device ID the service issued, large top-ups from new devices are challenged"
+// @Success 200 {object} forms.TopupIntentResponse
+// @Failure 422 {object} forms.PolicyViolationResponse
+// @Failure 403 {object} forms.RiskRejectionResponse "Blocked or challenged by the risk rules. A challenge passes with a token from /customer/mfa/verify"
+// @Failure 503 {object} forms.ProblemResponse "No payment provider is configured"
 // @Router /customer/increase_balance [post]
 func AddBuyerWalletBalance(c *gin.Context) {
 	var input forms.AddWalletBalanceInput
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 	authHeader := c.GetHeader("Authorization")
@@ -234,22 +277,190 @@ func AddBuyerWalletBalance(c *gin.Context) {
 
 	userID, err := middlewares.GetCustomerJwtMiddleware().GetUserIDFromToken(tokenString)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 	user := models.Buyer{}
 
 	err = user.RetrieveByUserIDWithProfile(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err)
+		return
+	}
+
+	currency, ok := walletCurrency(c, input.Currency)
+	if !ok {
+		return
+	}
+	if !assessRisk(c, models.RiskRequest{
+		Group:       enums.Buyer,
+		UserID:      user.ID,
+		Operation:   enums.RiskOperationTopup,
+		Currency:    currency,
+		Amount:      input.AddBalance,
+		DeviceID:    middlewares.GetDeviceID(c),
+		MFAVerified: middlewares.GetCustomerJwtMiddleware().IsMFAVerified(tokenString),
+	}) {
+		return
+	}
+	intent, err := user.StartTopup(c.Request.Context(), currency, input.AddBalance, payment.GetProvider())
+	if respondPolicyViolation(c, err) {
+		return
+	}
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	middlewares.MarkCommitted(c)
+
+	c.JSON(http.StatusOK, forms.TopupIntentResponse{
+		ID:          intent.ID,
+		Currency:    intent.Currency,
+		Amount:      intent.Amount,
+		Status:      intent.Status,
+		CheckoutURL: intent.CheckoutURL,
+		ExpiresAt:   intent.ExpiresAt,
+	})
+}
+
+// PingExample godoc
+// @Summary Get customer wallet transaction history
+// @Schemes
+// @Description List wallet transactions newest first, use next_cursor to fetch the following page
+// @Tags example
+// @Accept json
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer YourJWTToken"
+// @Param cursor query string false "Cursor returned by the previous page"
+// @Param limit query int false "Page size, at most 100"
+// @Param type query string false "Transaction type" Enums(topup, purchase, refund, promo_credit, promo_expiry, opening_balance)
+// @Param currency query string false "ISO 4217 currency code of the wallet"
+// @Param from query string false "Start date (YYYY-MM-DD)"
+// @Param to query string false "End date inclusive (YYYY-MM-DD)"
+// @Success 200 {object} forms.WalletTransactionListResponse
+// @Router /customer/wallet/transactions [get]
+func GetBuyerWalletTransactions(c *gin.Context) {
+	var query forms.WalletTransactionQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	if query.Type != "" && !enums.IsValidTransactionType(query.Type) {
+		_ = c.Error(errInvalidTransaction)
+		return
+	}
+	if query.Currency != "" && !enums.IsValidCurrency(query.Currency) {
+		_ = c.Error(models.ErrInvalidCurrency)
+		return
+	}
+	authHeader := c.GetHeader("Authorization")
+	tokenString := authHeader[len("Bearer "):]
+	userID, err := middlewares.GetCustomerJwtMiddleware().GetUserIDFromToken(tokenString)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	user := models.Buyer{ID: userID}
+
+	transactions, nextCursor, err := user.ListWalletTransactions(c.Request.Context(), query)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	response := forms.WalletTransactionListResponse{
+		Transactions: make([]forms.WalletTransactionResponse, 0, len(transactions)),
+		NextCursor:   nextCursor,
+	}
+	for _, transaction := range transactions {
+		response.Transactions = append(response.Transactions, forms.WalletTransactionResponse{
+			ID:                    transaction.ID,
+			Type:                  transaction.Type,
+			Currency:              transaction.Currency,
+			Amount:                transaction.Amount,
+			PromoAmount:           transaction.PromoAmount,
+			BalanceAfter:          transaction.BalanceAfter,
+			Reference:             transaction.Reference,
+			CreatedAt:             transaction.CreatedAt,
+			OriginalTransactionID: transaction.OriginalTransactionID,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// PingExample godoc
+// @Summary Open a buyer wallet in another currency
+// @Schemes
+// @Description Create an empty wallet in the given ISO 4217 currency, one wallet per currency
+// @Tags example
+// @Accept json
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer YourJWTToken"
+// @Param data body forms.OpenWalletInput true "Currency of the new wallet"
+// @Success 200 {object} forms.WalletResponse
+// @Failure 409 {object} forms.ProblemResponse "Wallet already exists"
+// @Router /customer/wallets [post]
+func OpenBuyerWallet(c *gin.Context) {
+	var input forms.OpenWalletInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	authHeader := c.GetHeader("Authorization")
+	tokenString := authHeader[len("Bearer "):]
+	userID, err := middlewares.GetCustomerJwtMiddleware().GetUserIDFromToken(tokenString)
+	if err != nil {
+		_ = c.Error(err)
 		return
 	}
+	user := models.Buyer{ID: userID}
 
-	updatedBalance, err := user.AddBalance(c.Request.Context(), input)
+	wallet, err := user.OpenWallet(c.Request.Context(), input.Currency)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
+	c.JSON(http.StatusOK, forms.WalletResponse{
+		Currency:    wallet.Currency,
+		Balance:     wallet.Balance,
+		HeldBalance: wallet.HeldBalance,
+	})
+}
 
-	c.JSON(http.StatusOK, forms.AddWalletBalanceResponse{NewBalance: updatedBalance})
+// PingExample godoc
+// @Summary Get buyer referral code
+// @Schemes
+// @Description Return the referral code to share and how many referrals are pending, rewarded or rejected
+// @Tags example
+// @Accept json
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer YourJWTToken"
+// @Success 200 {object} forms.ReferralResponse
+// @Router /customer/referral [get]
+func GetBuyerReferral(c *gin.Context) {
+	authHeader := c.GetHeader("Authorization")
+	tokenString := authHeader[len("Bearer "):]
+	userID, err := middlewares.GetCustomerJwtMiddleware().GetUserIDFromToken(tokenString)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	user := models.Buyer{}
+	if err := user.RetrieveByUserID(c.Request.Context(), userID); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	if err := user.EnsureReferralCode(c.Request.Context()); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	stats, err := models.ReferralStats(c.Request.Context(), user.ID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, generateReferralData(user.ReferralCode, stats))
 }
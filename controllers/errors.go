@@ -0,0 +1,31 @@
+package controllers
+
+import (
+	"errors"
+	"user-service/errs"
+	"user-service/i18n"
+)
+
+var (
+	errAuthenticationFailed = errs.New(errs.ErrUnauthorized, i18n.CodeAuthenticationFailed, "authentication failed")
+	errInvalidID            = errs.New(errs.ErrBadRequest, i18n.CodeInvalidID, "invalid id")
+	errInvalidTransaction   = errs.New(errs.ErrValidation, i18n.CodeInvalidTransaction, "invalid transaction type")
+	errInvalidMonth         = errs.New(errs.ErrValidation, i18n.CodeInvalidMonth, "month must be in YYYY-MM format")
+	errIfMatchRequired      = errs.New(errs.ErrPreconditionRequired, i18n.CodeIfMatchRequired, "If-Match header is required")
+	errAddressNotFound      = errs.New(errs.ErrNotFound, i18n.CodeAddressNotFound, "address not found")
+	errWalletNotFound       = errs.New(errs.ErrNotFound, i18n.CodeWalletNotFound, "wallet not found")
+	errSellerNotFound       = errs.New(errs.ErrNotFound, i18n.CodeSellerNotFound, "seller not found")
+	errVerificationNotFound = errs.New(errs.ErrNotFound, i18n.CodeVerificationNotFound, "verification not found")
+	errFakePaymentDisabled  = errs.New(errs.ErrNotFound, i18n.CodeFakePaymentDisabled, "fake payment provider is not enabled")
+)
+
+// badRequest marks an error caused by a malformed request, like an unreadable
+// upload, so it is not answered as an internal error. Domain errors keep their
+// own kind
+func badRequest(err error) error {
+	var domainError *errs.Error
+	if errors.As(err, &domainError) {
+		return err
+	}
+	return errs.Wrap(errs.ErrBadRequest, i18n.CodeBadRequest, err)
+}
@@ -0,0 +1,173 @@
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"user-service/enums"
+	"user-service/forms"
+	"user-service/middlewares"
+	"user-service/models"
+	"user-service/service"
+)
+
+// PingExample godoc
+// @Summary Send buyer second factor code
+// @Schemes
+// @Description Text a 6-digit code to the verified buyer phone, confirming it gives an access token that passes risk challenges
+// @Tags example
+// @Accept json
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer YourJWTToken"
+// @Success 202 {object} forms.OTPSentResponse
+// @Failure 412 {object} forms.ProblemResponse "No verified phone"
+// @Failure 429 {object} forms.ProblemResponse "Code requested too recently, or too many codes today"
+// @Router /customer/mfa [post]
+func RequestBuyerMFACode(c *gin.Context) {
+	authHeader := c.GetHeader("Authorization")
+	tokenString := authHeader[len("Bearer "):]
+	userID, err := middlewares.GetCustomerJwtMiddleware().GetUserIDFromToken(tokenString)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	user := models.Buyer{ID: userID}
+
+	phone, err := user.RequestMFACode(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusAccepted, generateOTPSentData(phone))
+}
+
+// PingExample godoc
+// @Summary Confirm buyer second factor
+// @Schemes
+// @Description Confirm the code sent to the buyer phone and return an access token with the mfa claim
+// @Tags example
+// @Accept json
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer YourJWTToken"
+// @Param data body forms.MFAInput true "Code"
+// @Success 200 {object} forms.MFATokenResponse
+// @Failure 401 {object} forms.ProblemResponse "Wrong or expired code"
+// @Failure 429 {object} forms.ProblemResponse "Too many wrong codes, from this phone or this client"
+// @Router /customer/mfa/verify [post]
+func VerifyBuyerMFACode(c *gin.Context) {
+	var input forms.MFAInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	authHeader := c.GetHeader("Authorization")
+	tokenString := authHeader[len("Bearer "):]
+	userID, err := middlewares.GetCustomerJwtMiddleware().GetUserIDFromToken(tokenString)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	user := models.Buyer{ID: userID}
+
+	if err := user.VerifyMFACode(c.Request.Context(), input.Code, c.ClientIP()); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	tokenUserInput := service.TokenUserInput{
+		Username:      user.Username,
+		UserID:        user.ID,
+		RoleGroupName: enums.Buyer,
+		Firstname:     user.BuyerProfile.FirstName,
+		Lastname:      user.BuyerProfile.LastName,
+		MFAVerified:   true,
+		Locale:        models.PreferredLocale(c.Request.Context(), enums.Buyer, user.ID),
+	}
+	accessToken, err := middlewares.GetCustomerJwtMiddleware().GenerateAccessToken(&tokenUserInput)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, forms.MFATokenResponse{Token: accessToken})
+}
+
+// PingExample godoc
+// @Summary Send seller second factor code
+// @Schemes
+// @Description Text a 6-digit code to the verified seller phone, confirming it gives an access token that passes risk challenges
+// @Tags example
+// @Accept json
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer YourJWTToken"
+// @Success 202 {object} forms.OTPSentResponse
+// @Failure 412 {object} forms.ProblemResponse "No verified phone"
+// @Failure 429 {object} forms.ProblemResponse "Code requested too recently, or too many codes today"
+// @Router /seller/mfa [post]
+func RequestSellerMFACode(c *gin.Context) {
+	authHeader := c.GetHeader("Authorization")
+	tokenString := authHeader[len("Bearer "):]
+	userID, err := middlewares.GetSellerJwtMiddleware().GetUserIDFromToken(tokenString)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	user := models.Seller{ID: userID}
+
+	phone, err := user.RequestMFACode(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusAccepted, generateOTPSentData(phone))
+}
+
+// PingExample godoc
+// @Summary Confirm seller second factor
+// @Schemes
+// @Description Confirm the code sent to the seller phone and return an access token with the mfa claim
+// @Tags example
+// @Accept json
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer YourJWTToken"
+// @Param data body forms.MFAInput true "Code"
+// @Success 200 {object} forms.MFATokenResponse
+// @Failure 401 {object} forms.ProblemResponse "Wrong or expired code"
+// @Failure 429 {object} forms.ProblemResponse "Too many wrong codes, from this phone or this client"
+// @Router /seller/mfa/verify [post]
+func VerifySellerMFACode(c *gin.Context) {
+	var input forms.MFAInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	authHeader := c.GetHeader("Authorization")
+	tokenString := authHeader[len("Bearer "):]
+	userID, err := middlewares.GetSellerJwtMiddleware().GetUserIDFromToken(tokenString)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	user := models.Seller{ID: userID}
+
+	if err := user.VerifyMFACode(c.Request.Context(), input.Code, c.ClientIP()); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	tokenUserInput := service.TokenUserInput{
+		Username:      user.Username,
+		UserID:        user.ID,
+		RoleGroupName: enums.Seller,
+		Firstname:     user.SellerProfile.FirstName,
+		Lastname:      user.SellerProfile.LastName,
+		MFAVerified:   true,
+		Locale:        models.PreferredLocale(c.Request.Context(), enums.Seller, user.ID),
+	}
+	accessToken, err := middlewares.GetSellerJwtMiddleware().GenerateAccessToken(&tokenUserInput)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, forms.MFATokenResponse{Token: accessToken})
+}
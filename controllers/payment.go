@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"user-service/errs"
+	"user-service/forms"
+	"user-service/i18n"
+	"user-service/models"
+	"user-service/payment"
+)
+
+// PingExample godoc
+// @Summary Payment provider webhook
+// @Schemes
+// @Description Receive signed payment notifications and credit confirmed top-ups
+// @Tags payment
+// @Accept json
+// @Produce json
+// @Success 204
+// @Router /payments/webhook [post]
+func PaymentWebhook(c *gin.Context) {
+	payload, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		_ = c.Error(badRequest(err))
+		return
+	}
+	event, err := payment.GetProvider().VerifyWebhook(c.Request.Header, payload)
+	if err != nil {
+		_ = c.Error(badRequest(err))
+		return
+	}
+
+	intent := models.TopupIntent{}
+	if err := intent.RetrieveByProviderReference(c.Request.Context(), event.Reference); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	if err := intent.ApplyPaymentEvent(c.Request.Context(), event); err != nil {
+		// Anything but a 2xx makes the provider retry the notification
+		_ = c.Error(err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// PingExample godoc
+// @Summary Simulate a payment with the fake provider
+// @Schemes
+// @Description Make the fake payment provider send a signed webhook for a pending top-up, only available when PAYMENT_PROVIDER is fake and PAYMENT_FAKE_ENABLED is true
+// @Tags payment
+// @Accept json
+// @Produce json
+// @Param reference path string true "Provider reference of the top-up"
+// @Param data body forms.FakePaymentInput true "Payment outcome"
+// @Success 204
+// @Router /payments/fake/{reference} [post]
+func SimulateFakePayment(c *gin.Context) {
+	var input forms.FakePaymentInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	provider, ok := payment.GetProvider().(*payment.FakeProvider)
+	if !ok {
+		_ = c.Error(errFakePaymentDisabled)
+		return
+	}
+	intent := models.TopupIntent{}
+	if err := intent.RetrieveByProviderReference(c.Request.Context(), c.Param("reference")); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	if err := provider.SendWebhook(c.Request.Context(), intent.ProviderReference, input.Status, intent.Amount, intent.Currency); err != nil {
+		_ = c.Error(errs.Wrap(errs.ErrUpstream, i18n.CodeUpstreamFailed, err))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
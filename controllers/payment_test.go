@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"user-service/forms"
+	"user-service/i18n"
+	"user-service/middlewares"
+	"user-service/payment"
+)
+
+const testWebhookSecret = "webhook-secret"
+
+// Webhooks without a valid signature are turned away before any top-up is
+// looked at. A signed payload gets past the check, one that is not JSON then
+// stops at parsing so the test needs no database
+func TestPaymentWebhookSignature(t *testing.T) {
+	t.Setenv("PAYMENT_PROVIDER", "fake")
+	t.Setenv("PAYMENT_FAKE_ENABLED", "true")
+	t.Setenv("PAYMENT_WEBHOOK_SECRET", testWebhookSecret)
+	payment.Init()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middlewares.ErrorHandler())
+	router.POST("/webhook", PaymentWebhook)
+
+	payload := `{"reference":"fake_1","status":"succeeded","amount":"100.00","currency":"THB"}`
+	tests := []struct {
+		name       string
+		signature  string
+		payload    string
+		wantStatus int
+		wantCode   string
+	}{
+		{"signed", signWebhook(`not json`), `not json`, http.StatusBadRequest, i18n.CodeBadRequest},
+		{"unsigned", "", payload, http.StatusUnauthorized, i18n.CodeInvalidSignature},
+		{"wrong signature", strings.Repeat("00", sha256.Size), payload, http.StatusUnauthorized, i18n.CodeInvalidSignature},
+		{"tampered payload", signWebhook(payload), strings.Replace(payload, "100.00", "900.00", 1), http.StatusUnauthorized, i18n.CodeInvalidSignature},
+	}
+	for _, test := range tests {
+		request := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(test.payload))
+		if test.signature != "" {
+			request.Header.Set("X-Fake-Signature", test.signature)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+
+		var problem forms.ProblemResponse
+		if err := json.Unmarshal(recorder.Body.Bytes(), &problem); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if recorder.Code != test.wantStatus || problem.Code != test.wantCode {
+			t.Errorf("%s: got %d %s, want %d %s", test.name, recorder.Code, problem.Code, test.wantStatus, test.wantCode)
+		}
+	}
+}
+
+// signWebhook signs payload the way the fake provider does
+func signWebhook(payload string) string {
+	mac := hmac.New(sha256.New, []byte(testWebhookSecret))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
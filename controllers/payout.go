@@ -0,0 +1,341 @@
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"user-service/enums"
+	"user-service/forms"
+	"user-service/middlewares"
+	"user-service/models"
+)
+
+// PingExample godoc
+// @Summary Register a payout destination
+// @Schemes
+// @Description Add a bank account the seller can withdraw to
+// @Tags payout
+// @Accept json
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer YourJWTToken"
+// @Param data body forms.PayoutDestinationInput true "Bank account"
+// @Success 200 {object} forms.PayoutDestinationResponse
+// @Router /seller/payout_destinations [post]
+func CreatePayoutDestination(c *gin.Context) {
+	var input forms.PayoutDestinationInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	authHeader := c.GetHeader("Authorization")
+	tokenString := authHeader[len("Bearer "):]
+	userID, err := middlewares.GetSellerJwtMiddleware().GetUserIDFromToken(tokenString)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	destination := models.PayoutDestination{
+		SellerID:      userID,
+		AccountName:   input.AccountName,
+		AccountNumber: input.AccountNumber,
+		BankCode:      input.BankCode,
+	}
+	if err := destination.Create(c.Request.Context()); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, generatePayoutDestinationData(destination))
+}
+
+// PingExample godoc
+// @Summary List payout destinations
+// @Schemes
+// @Description List the bank accounts the seller can withdraw to
+// @Tags payout
+// @Accept json
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer YourJWTToken"
+// @Success 200 {array} forms.PayoutDestinationResponse
+// @Router /seller/payout_destinations [get]
+func ListPayoutDestinations(c *gin.Context) {
+	authHeader := c.GetHeader("Authorization")
+	tokenString := authHeader[len("Bearer "):]
+	userID, err := middlewares.GetSellerJwtMiddleware().GetUserIDFromToken(tokenString)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	user := models.Seller{ID: userID}
+
+	destinations, err := user.ListPayoutDestinations(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	response := make([]forms.PayoutDestinationResponse, 0, len(destinations))
+	for _, destination := range destinations {
+		response = append(response, generatePayoutDestinationData(destination))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// PingExample godoc
+// @Summary Remove a payout destination
+// @Schemes
+// @Description Remove a bank account, past payouts keep their reference
+// @Tags payout
+// @Accept json
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer YourJWTToken"
+// @Param id path int true "Destination ID"
+// @Success 204
+// @Router /seller/payout_destinations/{id} [delete]
+func DeletePayoutDestination(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		_ = c.Error(errInvalidID)
+		return
+	}
+	authHeader := c.GetHeader("Authorization")
+	tokenString := authHeader[len("Bearer "):]
+	userID, err := middlewares.GetSellerJwtMiddleware().GetUserIDFromToken(tokenString)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	user := models.Seller{ID: userID}
+
+	if err := user.DeletePayoutDestination(c.Request.Context(), uint(id)); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// PingExample godoc
+// @Summary Request a payout
+// @Schemes
+// @Description Withdraw from the seller wallet, the amount is deducted immediately and returned if the payout fails. Only verified sellers can request payouts
+// @Tags payout
+// @Accept json
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer YourJWTToken"
+// @Param Idempotency-Key header string false "Unique key to safely retry the request"
+// @Param X-Device-ID header string false "Device ID the service issued, large debits from new devices are challenged"
+// @Param data body forms.PayoutRequestInput true "Destination and amount"
+// @Success 200 {object} forms.PayoutRequestResponse
+// @Failure 402 {object} forms.ProblemResponse "Insufficient funds"
+// @Failure 423 {object} forms.ProblemResponse "Wallet is frozen pending review"
+// @Failure 403 {object} forms.RiskRejectionResponse "Blocked or challenged by the risk rules, or seller not verified. A challenge passes with a token from /seller/mfa/verify"
+// @Failure 422 {object} forms.PolicyViolationResponse "Amount is not a valid money amount"
+// @Router /seller/payouts [post]
+func RequestPayout(c *gin.Context) {
+	var input forms.PayoutRequestInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	authHeader := c.GetHeader("Authorization")
+	tokenString := authHeader[len("Bearer "):]
+	userID, err := middlewares.GetSellerJwtMiddleware().GetUserIDFromToken(tokenString)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	currency, ok := walletCurrency(c, input.Currency)
+	if !ok {
+		return
+	}
+	if !assessRisk(c, models.RiskRequest{
+		Group:       enums.Seller,
+		UserID:      userID,
+		Operation:   enums.RiskOperationPayout,
+		Currency:    currency,
+		Amount:      input.Amount,
+		DeviceID:    middlewares.GetDeviceID(c),
+		MFAVerified: middlewares.GetSellerJwtMiddleware().IsMFAVerified(tokenString),
+	}) {
+		return
+	}
+	user := models.Seller{ID: userID}
+
+	request, err := user.RequestPayout(c.Request.Context(), currency, input.DestinationID, input.Amount)
+	if respondPolicyViolation(c, err) {
+		return
+	}
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	middlewares.MarkCommitted(c)
+	c.JSON(http.StatusOK, generatePayoutRequestData(*request))
+}
+
+// PingExample godoc
+// @Summary List payout requests
+// @Schemes
+// @Description List the seller payout requests newest first
+// @Tags payout
+// @Accept json
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer YourJWTToken"
+// @Success 200 {array} forms.PayoutRequestResponse
+// @Router /seller/payouts [get]
+func ListSellerPayouts(c *gin.Context) {
+	authHeader := c.GetHeader("Authorization")
+	tokenString := authHeader[len("Bearer "):]
+	userID, err := middlewares.GetSellerJwtMiddleware().GetUserIDFromToken(tokenString)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	user := models.Seller{ID: userID}
+
+	requests, err := user.ListPayoutRequests(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	response := make([]forms.PayoutRequestResponse, 0, len(requests))
+	for _, request := range requests {
+		response = append(response, generatePayoutRequestData(request))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// PingExample godoc
+// @Summary List payout requests for review
+// @Schemes
+// @Description List payout requests of every seller in a given state, pending by default
+// @Tags payout
+// @Accept json
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer ServiceJWTToken"
+// @Param status query string false "Payout status" Enums(pending, approved, processing, paid, failed)
+// @Success 200 {array} forms.PayoutRequestResponse
+// @Router /service/payouts [get]
+func ListPayoutsForReview(c *gin.Context) {
+	var query forms.PayoutStatusQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	if query.Status == "" {
+		query.Status = enums.PayoutPending
+	}
+	requests, err := models.ListPayoutRequestsByStatus(c.Request.Context(), query.Status)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	response := make([]forms.PayoutRequestResponse, 0, len(requests))
+	for _, request := range requests {
+		response = append(response, generatePayoutRequestData(request))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// PingExample godoc
+// @Summary Approve a payout request
+// @Schemes
+// @Description Approve a pending payout, it is sent with the next payout batch
+// @Tags payout
+// @Accept json
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer ServiceJWTToken"
+// @Param id path int true "Payout request ID"
+// @Success 200 {object} forms.PayoutRequestResponse
+// @Failure 409 {object} forms.ProblemResponse "Payout is no longer pending"
+// @Router /service/payouts/{id}/approve [post]
+func ApprovePayout(c *gin.Context) {
+	request, ok := retrievePayoutRequest(c)
+	if !ok {
+		return
+	}
+	if err := request.Approve(c.Request.Context()); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, generatePayoutRequestData(request))
+}
+
+// PingExample godoc
+// @Summary Reject a payout request
+// @Schemes
+// @Description Reject a pending payout and return the amount to the seller wallet
+// @Tags payout
+// @Accept json
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer ServiceJWTToken"
+// @Param id path int true "Payout request ID"
+// @Param data body forms.PayoutRejectInput true "Rejection reason"
+// @Success 200 {object} forms.PayoutRequestResponse
+// @Failure 409 {object} forms.ProblemResponse "Payout is no longer pending"
+// @Router /service/payouts/{id}/reject [post]
+func RejectPayout(c *gin.Context) {
+	var input forms.PayoutRejectInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	request, ok := retrievePayoutRequest(c)
+	if !ok {
+		return
+	}
+	if err := request.Reject(c.Request.Context(), input.Reason); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, generatePayoutRequestData(request))
+}
+
+func retrievePayoutRequest(c *gin.Context) (models.PayoutRequest, bool) {
+	request := models.PayoutRequest{}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		_ = c.Error(errInvalidID)
+		return request, false
+	}
+	if err := request.RetrieveByID(c.Request.Context(), uint(id)); err != nil {
+		_ = c.Error(err)
+		return request, false
+	}
+	return request, true
+}
+
+func generatePayoutDestinationData(destination models.PayoutDestination) forms.PayoutDestinationResponse {
+	return forms.PayoutDestinationResponse{
+		ID:            destination.ID,
+		AccountName:   destination.AccountName,
+		AccountNumber: destination.AccountNumber,
+		BankCode:      destination.BankCode,
+	}
+}
+
+func generatePayoutRequestData(request models.PayoutRequest) forms.PayoutRequestResponse {
+	return forms.PayoutRequestResponse{
+		ID:            request.ID,
+		SellerID:      request.SellerID,
+		DestinationID: request.DestinationID,
+		Currency:      request.Currency,
+		Amount:        request.Amount,
+		Fee:           request.Fee,
+		NetAmount:     request.NetAmount,
+		Status:        request.Status,
+		FailureReason: request.FailureReason,
+		CreatedAt:     request.CreatedAt,
+		UpdatedAt:     request.UpdatedAt,
+	}
+}
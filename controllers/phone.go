@@ -0,0 +1,322 @@
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"user-service/enums"
+	"user-service/forms"
+	"user-service/middlewares"
+	"user-service/models"
+	"user-service/service"
+)
+
+func generateOTPSentData(phone string) forms.OTPSentResponse {
+	return forms.OTPSentResponse{
+		Phone:     phone,
+		ExpiresIn: int(models.OTPTTL().Seconds()),
+	}
+}
+
+// PingExample godoc
+// @Summary Send buyer phone verification code
+// @Schemes
+// @Description Text a 6-digit code to the phone, the buyer phone changes once the code is confirmed
+// @Tags example
+// @Accept json
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer YourJWTToken"
+// @Param data body forms.PhoneInput true "Phone number"
+// @Success 202 {object} forms.OTPSentResponse
+// @Failure 409 {object} forms.ProblemResponse "Phone used by another account"
+// @Failure 422 {object} forms.ProblemResponse "Not a valid phone number"
+// @Failure 429 {object} forms.ProblemResponse "Code requested too recently, or too many codes today"
+// @Router /customer/phone [post]
+func RequestBuyerPhoneVerification(c *gin.Context) {
+	var input forms.PhoneInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	authHeader := c.GetHeader("Authorization")
+	tokenString := authHeader[len("Bearer "):]
+	userID, err := middlewares.GetCustomerJwtMiddleware().GetUserIDFromToken(tokenString)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	user := models.Buyer{ID: userID}
+
+	phone, err := user.RequestPhoneVerification(c.Request.Context(), input.Phone)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusAccepted, generateOTPSentData(phone))
+}
+
+// PingExample godoc
+// @Summary Confirm buyer phone
+// @Schemes
+// @Description Confirm the code sent to the phone and make it the buyer phone
+// @Tags example
+// @Accept json
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer YourJWTToken"
+// @Param data body forms.PhoneOTPInput true "Phone number and code"
+// @Success 200 {object} forms.UserResponse
+// @Failure 401 {object} forms.ProblemResponse "Wrong or expired code"
+// @Failure 409 {object} forms.ProblemResponse "Phone used by another account"
+// @Failure 429 {object} forms.ProblemResponse "Too many wrong codes, from this phone or this client"
+// @Router /customer/phone/verify [post]
+func VerifyBuyerPhone(c *gin.Context) {
+	var input forms.PhoneOTPInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	authHeader := c.GetHeader("Authorization")
+	tokenString := authHeader[len("Bearer "):]
+	userID, err := middlewares.GetCustomerJwtMiddleware().GetUserIDFromToken(tokenString)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	user := models.Buyer{ID: userID}
+
+	if err := user.VerifyPhone(c.Request.Context(), input.Phone, input.Code, c.ClientIP()); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, generateBuyerData(user))
+}
+
+// PingExample godoc
+// @Summary Send buyer login code
+// @Schemes
+// @Description Text a 6-digit login code to a verified buyer phone. The response is the same whether or not the phone is registered
+// @Tags example
+// @Accept json
+// @Produce json
+// @Param data body forms.PhoneInput true "Phone number"
+// @Success 202 {object} forms.OTPSentResponse
+// @Failure 422 {object} forms.ProblemResponse "Not a valid phone number"
+// @Router /customer/login/otp/request [post]
+func RequestBuyerLoginOTP(c *gin.Context) {
+	var input forms.PhoneInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	phone, err := models.RequestLoginOTP(c.Request.Context(), enums.Buyer, input.Phone)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusAccepted, generateOTPSentData(phone))
+}
+
+// PingExample godoc
+// @Summary BuyerLogin with phone
+// @Schemes
+// @Description Return JWT access and refresh pair, alongside user profile, for the code sent to the phone
+// @Tags example
+// @Accept json
+// @Produce json
+// @Param data body forms.PhoneOTPInput true "Phone number and code"
+// @Success 200 {object} forms.LoginResponse
+// @Failure 401 {object} forms.ProblemResponse "Wrong or expired code"
+// @Failure 429 {object} forms.ProblemResponse "Too many wrong codes, from this phone or this client"
+// @Router /customer/login/otp [post]
+func BuyerOTPLogin(c *gin.Context) {
+	var input forms.PhoneOTPInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	userModel := models.Buyer{}
+	if err := userModel.LoginWithOTP(c.Request.Context(), input.Phone, input.Code, c.ClientIP()); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	tokenUserInput := service.TokenUserInput{
+		Username:      userModel.Username,
+		UserID:        userModel.ID,
+		RoleGroupName: enums.Buyer,
+		Firstname:     userModel.BuyerProfile.FirstName,
+		Lastname:      userModel.BuyerProfile.LastName,
+		Locale:        models.PreferredLocale(c.Request.Context(), enums.Buyer, userModel.ID),
+	}
+	tokenString, err := middlewares.GetCustomerJwtMiddleware().GenerateAccessToken(&tokenUserInput)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	refreshTokenString, err := middlewares.GetCustomerJwtMiddleware().GenerateRefreshToken(&tokenUserInput)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, forms.LoginResponse{
+		Token:   tokenString,
+		Refresh: refreshTokenString,
+		User:    generateBuyerData(userModel),
+	})
+}
+
+// PingExample godoc
+// @Summary Send seller phone verification code
+// @Schemes
+// @Description Text a 6-digit code to the phone, the seller phone changes once the code is confirmed
+// @Tags example
+// @Accept json
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer YourJWTToken"
+// @Param data body forms.PhoneInput true "Phone number"
+// @Success 202 {object} forms.OTPSentResponse
+// @Failure 409 {object} forms.ProblemResponse "Phone used by another account"
+// @Failure 422 {object} forms.ProblemResponse "Not a valid phone number"
+// @Failure 429 {object} forms.ProblemResponse "Code requested too recently, or too many codes today"
+// @Router /seller/phone [post]
+func RequestSellerPhoneVerification(c *gin.Context) {
+	var input forms.PhoneInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	authHeader := c.GetHeader("Authorization")
+	tokenString := authHeader[len("Bearer "):]
+	userID, err := middlewares.GetSellerJwtMiddleware().GetUserIDFromToken(tokenString)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	user := models.Seller{ID: userID}
+
+	phone, err := user.RequestPhoneVerification(c.Request.Context(), input.Phone)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusAccepted, generateOTPSentData(phone))
+}
+
+// PingExample godoc
+// @Summary Confirm seller phone
+// @Schemes
+// @Description Confirm the code sent to the phone and make it the seller phone
+// @Tags example
+// @Accept json
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer YourJWTToken"
+// @Param data body forms.PhoneOTPInput true "Phone number and code"
+// @Success 200 {object} forms.UserResponse
+// @Failure 401 {object} forms.ProblemResponse "Wrong or expired code"
+// @Failure 409 {object} forms.ProblemResponse "Phone used by another account"
+// @Failure 429 {object} forms.ProblemResponse "Too many wrong codes, from this phone or this client"
+// @Router /seller/phone/verify [post]
+func VerifySellerPhone(c *gin.Context) {
+	var input forms.PhoneOTPInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	authHeader := c.GetHeader("Authorization")
+	tokenString := authHeader[len("Bearer "):]
+	userID, err := middlewares.GetSellerJwtMiddleware().GetUserIDFromToken(tokenString)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	user := models.Seller{ID: userID}
+
+	if err := user.VerifyPhone(c.Request.Context(), input.Phone, input.Code, c.ClientIP()); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, generateSellerData(user))
+}
+
+// PingExample godoc
+// @Summary Send seller login code
+// @Schemes
+// @Description Text a 6-digit login code to a verified seller phone. The response is the same whether or not the phone is registered
+// @Tags example
+// @Accept json
+// @Produce json
+// @Param data body forms.PhoneInput true "Phone number"
+// @Success 202 {object} forms.OTPSentResponse
+// @Failure 422 {object} forms.ProblemResponse "Not a valid phone number"
+// @Router /seller/login/otp/request [post]
+func RequestSellerLoginOTP(c *gin.Context) {
+	var input forms.PhoneInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	phone, err := models.RequestLoginOTP(c.Request.Context(), enums.Seller, input.Phone)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusAccepted, generateOTPSentData(phone))
+}
+
+// PingExample godoc
+// @Summary SellerLogin with phone
+// @Schemes
+// @Description Return JWT access and refresh pair, alongside user profile, for the code sent to the phone
+// @Tags example
+// @Accept json
+// @Produce json
+// @Param data body forms.PhoneOTPInput true "Phone number and code"
+// @Success 200 {object} forms.LoginResponse
+// @Failure 401 {object} forms.ProblemResponse "Wrong or expired code"
+// @Failure 429 {object} forms.ProblemResponse "Too many wrong codes, from this phone or this client"
+// @Router /seller/login/otp [post]
+func SellerOTPLogin(c *gin.Context) {
+	var input forms.PhoneOTPInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	userModel := models.Seller{}
+	if err := userModel.LoginWithOTP(c.Request.Context(), input.Phone, input.Code, c.ClientIP()); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	tokenUserInput := service.TokenUserInput{
+		Username:      userModel.Username,
+		UserID:        userModel.ID,
+		RoleGroupName: enums.Seller,
+		Firstname:     userModel.SellerProfile.FirstName,
+		Lastname:      userModel.SellerProfile.LastName,
+		Locale:        models.PreferredLocale(c.Request.Context(), enums.Seller, userModel.ID),
+	}
+	tokenString, err := middlewares.GetSellerJwtMiddleware().GenerateAccessToken(&tokenUserInput)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	refreshTokenString, err := middlewares.GetSellerJwtMiddleware().GenerateRefreshToken(&tokenUserInput)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, forms.LoginResponse{
+		Token:   tokenString,
+		Refresh: refreshTokenString,
+		User:    generateSellerData(userModel),
+	})
+}
@@ -0,0 +1,155 @@
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+	"user-service/enums"
+	"user-service/forms"
+	"user-service/middlewares"
+	"user-service/models"
+)
+
+func getPreferences(c *gin.Context, group string, userID uuid.UUID) {
+	preference, err := models.GetPreferences(c.Request.Context(), group, userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, generatePreferenceData(preference))
+}
+
+func savePreferences(c *gin.Context, group string, userID uuid.UUID, input forms.PreferencesInput) {
+	preference := models.UserPreference{
+		UserID:         userID,
+		UserGroup:      group,
+		Locale:         input.Locale,
+		Timezone:       input.Timezone,
+		Currency:       input.Currency,
+		MarketingEmail: input.MarketingOptIns.Email,
+		MarketingSMS:   input.MarketingOptIns.SMS,
+		Notifications:  input.Notifications,
+	}
+	if err := models.SavePreferences(c.Request.Context(), &preference); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, generatePreferenceData(preference))
+}
+
+// PingExample godoc
+// @Summary Get buyer preferences
+// @Schemes
+// @Description Locale, timezone, display currency, marketing opt-ins and notification channels, defaults are returned until saved
+// @Tags example
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer YourJWTToken"
+// @Success 200 {object} forms.PreferencesResponse
+// @Router /customer/preferences [get]
+func GetBuyerPreferences(c *gin.Context) {
+	authHeader := c.GetHeader("Authorization")
+	tokenString := authHeader[len("Bearer "):]
+	userID, err := middlewares.GetCustomerJwtMiddleware().GetUserIDFromToken(tokenString)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	getPreferences(c, enums.Buyer, userID)
+}
+
+// PingExample godoc
+// @Summary Replace buyer preferences
+// @Schemes
+// @Description Replace every preference. Tokens issued afterwards carry the new locale, refresh the access token to pick it up
+// @Tags example
+// @Accept json
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer YourJWTToken"
+// @Param data body forms.PreferencesInput true "Preferences"
+// @Success 200 {object} forms.PreferencesResponse
+// @Failure 422 {object} forms.ProblemResponse "Unknown locale, timezone or currency, or security alerts turned off"
+// @Router /customer/preferences [put]
+func UpdateBuyerPreferences(c *gin.Context) {
+	var input forms.PreferencesInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	authHeader := c.GetHeader("Authorization")
+	tokenString := authHeader[len("Bearer "):]
+	userID, err := middlewares.GetCustomerJwtMiddleware().GetUserIDFromToken(tokenString)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	savePreferences(c, enums.Buyer, userID, input)
+}
+
+// PingExample godoc
+// @Summary Get seller preferences
+// @Schemes
+// @Description Locale, timezone, display currency, marketing opt-ins and notification channels, defaults are returned until saved
+// @Tags example
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer YourJWTToken"
+// @Success 200 {object} forms.PreferencesResponse
+// @Router /seller/preferences [get]
+func GetSellerPreferences(c *gin.Context) {
+	authHeader := c.GetHeader("Authorization")
+	tokenString := authHeader[len("Bearer "):]
+	userID, err := middlewares.GetSellerJwtMiddleware().GetUserIDFromToken(tokenString)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	getPreferences(c, enums.Seller, userID)
+}
+
+// PingExample godoc
+// @Summary Replace seller preferences
+// @Schemes
+// @Description Replace every preference. Tokens issued afterwards carry the new locale, refresh the access token to pick it up
+// @Tags example
+// @Accept json
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer YourJWTToken"
+// @Param data body forms.PreferencesInput true "Preferences"
+// @Success 200 {object} forms.PreferencesResponse
+// @Failure 422 {object} forms.ProblemResponse "Unknown locale, timezone or currency, or security alerts turned off"
+// @Router /seller/preferences [put]
+func UpdateSellerPreferences(c *gin.Context) {
+	var input forms.PreferencesInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	authHeader := c.GetHeader("Authorization")
+	tokenString := authHeader[len("Bearer "):]
+	userID, err := middlewares.GetSellerJwtMiddleware().GetUserIDFromToken(tokenString)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	savePreferences(c, enums.Seller, userID, input)
+}
+
+func generatePreferenceData(preference models.UserPreference) forms.PreferencesResponse {
+	response := forms.PreferencesResponse{
+		Locale:   preference.Locale,
+		Timezone: preference.Timezone,
+		Currency: preference.Currency,
+		MarketingOptIns: forms.MarketingOptIns{
+			Email: preference.MarketingEmail,
+			SMS:   preference.MarketingSMS,
+		},
+		Notifications: preference.Notifications,
+	}
+	if !preference.UpdatedAt.IsZero() {
+		response.UpdatedAt = &preference.UpdatedAt
+	}
+	return response
+}
@@ -0,0 +1,148 @@
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"user-service/forms"
+	"user-service/middlewares"
+	"user-service/models"
+)
+
+// profileETag is the entity tag of a profile version, the database keeps
+// timestamps to the microsecond
+func profileETag(updatedAt time.Time) string {
+	return `"` + strconv.FormatInt(updatedAt.UnixMicro(), 10) + `"`
+}
+
+// checkIfMatch compares the If-Match header with the current version and
+// records a 428 or 412 error when the update must not go ahead
+func checkIfMatch(c *gin.Context, updatedAt time.Time) bool {
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" {
+		_ = c.Error(errIfMatchRequired)
+		return false
+	}
+	current := profileETag(updatedAt)
+	for _, tag := range strings.Split(ifMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || tag == current {
+			return true
+		}
+	}
+	c.Header("ETag", current)
+	_ = c.Error(models.ErrProfileModified)
+	return false
+}
+
+// PingExample godoc
+// @Summary Update Buyer BuyerProfile
+// @Schemes
+// @Description Change some of the profile fields, send the ETag of the profile in If-Match. Tokens issued afterwards carry the new names
+// @Tags example
+// @Accept json
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer YourJWTToken"
+// @Param If-Match header string true "ETag returned by the last profile read"
+// @Param data body forms.UpdateProfileInput true "Fields to change"
+// @Success 200 {object} forms.UserResponse
+// @Failure 412 {object} forms.ProblemResponse "Profile changed since it was read"
+// @Failure 422 {object} forms.ProblemResponse "Invalid or empty update"
+// @Failure 428 {object} forms.ProblemResponse "If-Match header missing"
+// @Router /customer/profile [patch]
+func UpdateBuyerProfile(c *gin.Context) {
+	var input forms.UpdateProfileInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	authHeader := c.GetHeader("Authorization")
+	tokenString := authHeader[len("Bearer "):]
+	userID, err := middlewares.GetCustomerJwtMiddleware().GetUserIDFromToken(tokenString)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	user := models.Buyer{}
+	if err := user.RetrieveByUserIDWithProfile(c.Request.Context(), userID); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	version := user.BuyerProfile.UpdatedAt
+	if !checkIfMatch(c, version) {
+		return
+	}
+
+	update := models.ProfileUpdate{FirstName: input.FirstName, LastName: input.LastName}
+	if err := user.UpdateProfile(c.Request.Context(), update, version); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.Header("ETag", profileETag(user.BuyerProfile.UpdatedAt))
+	c.JSON(http.StatusOK, generateBuyerData(user))
+}
+
+// PingExample godoc
+// @Summary Update Seller SellerProfile
+// @Schemes
+// @Description Change some of the profile and storefront fields, send the ETag of the profile in If-Match. Slugs are unique across sellers
+// @Tags example
+// @Accept json
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer YourJWTToken"
+// @Param If-Match header string true "ETag returned by the last profile read"
+// @Param data body forms.UpdateSellerProfileInput true "Fields to change"
+// @Success 200 {object} forms.UserResponse
+// @Failure 409 {object} forms.ProblemResponse "Slug already taken"
+// @Failure 412 {object} forms.ProblemResponse "Profile changed since it was read"
+// @Failure 422 {object} forms.ProblemResponse "Invalid or empty update"
+// @Failure 428 {object} forms.ProblemResponse "If-Match header missing"
+// @Router /seller/profile [patch]
+func UpdateSellerProfile(c *gin.Context) {
+	var input forms.UpdateSellerProfileInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	authHeader := c.GetHeader("Authorization")
+	tokenString := authHeader[len("Bearer "):]
+	userID, err := middlewares.GetSellerJwtMiddleware().GetUserIDFromToken(tokenString)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	user := models.Seller{}
+	if err := user.RetrieveByUserIDWithProfile(c.Request.Context(), userID); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	version := user.SellerProfile.UpdatedAt
+	if !checkIfMatch(c, version) {
+		return
+	}
+
+	update := models.SellerProfileUpdate{
+		ProfileUpdate: models.ProfileUpdate{FirstName: input.FirstName, LastName: input.LastName},
+		DisplayName:   input.DisplayName,
+		Bio:           input.Bio,
+		Slug:          input.Slug,
+		Country:       input.Country,
+	}
+	if input.SocialLinks != nil {
+		links := make([]models.SocialLink, 0, len(*input.SocialLinks))
+		for _, link := range *input.SocialLinks {
+			links = append(links, models.SocialLink{Platform: link.Platform, URL: link.URL})
+		}
+		update.SocialLinks = &links
+	}
+	if err := user.UpdateProfile(c.Request.Context(), update, version); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.Header("ETag", profileETag(user.SellerProfile.UpdatedAt))
+	c.JSON(http.StatusOK, generateSellerData(user))
+}
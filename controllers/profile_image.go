@@ -0,0 +1,183 @@
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"user-service/enums"
+	"user-service/imaging"
+	"user-service/middlewares"
+	"user-service/models"
+)
+
+// imageFormField is the multipart field holding the uploaded file
+const imageFormField = "image"
+
+// readImageUpload reads the uploaded file without trusting its declared size
+// or type, it records the error when there is no usable file
+func readImageUpload(c *gin.Context) ([]byte, bool) {
+	maxBytes := models.ImageMaxBytes()
+	// Leave room for the multipart framing around the file
+	maxBody := maxBytes + 64<<10
+	if c.Request.ContentLength > maxBody {
+		_ = c.Error(imaging.ErrTooLarge)
+		return nil, false
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBody)
+	header, err := c.FormFile(imageFormField)
+	if err != nil {
+		_ = c.Error(badRequest(err))
+		return nil, false
+	}
+	file, err := header.Open()
+	if err != nil {
+		_ = c.Error(badRequest(err))
+		return nil, false
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxBytes+1))
+	if err != nil {
+		_ = c.Error(badRequest(err))
+		return nil, false
+	}
+	return data, true
+}
+
+// PingExample godoc
+// @Summary Upload buyer avatar
+// @Schemes
+// @Description Upload a JPEG, PNG, GIF or WebP avatar, it is cropped square and stored in several sizes without metadata
+// @Tags example
+// @Accept multipart/form-data
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer YourJWTToken"
+// @Param image formData file true "Avatar image"
+// @Success 200 {object} forms.UserResponse
+// @Failure 413 {object} forms.ProblemResponse "Image too large"
+// @Failure 415 {object} forms.ProblemResponse "Not a supported image"
+// @Router /customer/profile/avatar [put]
+func UploadBuyerAvatar(c *gin.Context) {
+	authHeader := c.GetHeader("Authorization")
+	tokenString := authHeader[len("Bearer "):]
+	userID, err := middlewares.GetCustomerJwtMiddleware().GetUserIDFromToken(tokenString)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	data, ok := readImageUpload(c)
+	if !ok {
+		return
+	}
+	user := models.Buyer{ID: userID}
+	if err := user.SetAvatar(c.Request.Context(), data); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, generateBuyerData(user))
+}
+
+// PingExample godoc
+// @Summary Remove buyer avatar
+// @Schemes
+// @Description Delete the avatar and every stored size of it
+// @Tags example
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer YourJWTToken"
+// @Success 200 {object} forms.UserResponse
+// @Router /customer/profile/avatar [delete]
+func DeleteBuyerAvatar(c *gin.Context) {
+	authHeader := c.GetHeader("Authorization")
+	tokenString := authHeader[len("Bearer "):]
+	userID, err := middlewares.GetCustomerJwtMiddleware().GetUserIDFromToken(tokenString)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	user := models.Buyer{ID: userID}
+	if err := user.SetAvatar(c.Request.Context(), nil); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, generateBuyerData(user))
+}
+
+// PingExample godoc
+// @Summary Upload seller logo or banner
+// @Schemes
+// @Description Upload a JPEG, PNG, GIF or WebP image, logos are cropped square and banners keep their aspect ratio. Every size is stored without metadata
+// @Tags example
+// @Accept multipart/form-data
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer YourJWTToken"
+// @Param kind path string true "Image kind" Enums(logo, banner)
+// @Param image formData file true "Image"
+// @Success 200 {object} forms.UserResponse
+// @Failure 413 {object} forms.ProblemResponse "Image too large"
+// @Failure 415 {object} forms.ProblemResponse "Not a supported image"
+// @Router /seller/profile/{kind} [put]
+func UploadSellerImage(c *gin.Context) {
+	authHeader := c.GetHeader("Authorization")
+	tokenString := authHeader[len("Bearer "):]
+	userID, err := middlewares.GetSellerJwtMiddleware().GetUserIDFromToken(tokenString)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	kind, ok := sellerImageKind(c)
+	if !ok {
+		return
+	}
+	data, ok := readImageUpload(c)
+	if !ok {
+		return
+	}
+	user := models.Seller{ID: userID}
+	if err := user.SetImage(c.Request.Context(), kind, data); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, generateSellerData(user))
+}
+
+// PingExample godoc
+// @Summary Remove seller logo or banner
+// @Schemes
+// @Description Delete the image and every stored size of it
+// @Tags example
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer YourJWTToken"
+// @Param kind path string true "Image kind" Enums(logo, banner)
+// @Success 200 {object} forms.UserResponse
+// @Router /seller/profile/{kind} [delete]
+func DeleteSellerImage(c *gin.Context) {
+	authHeader := c.GetHeader("Authorization")
+	tokenString := authHeader[len("Bearer "):]
+	userID, err := middlewares.GetSellerJwtMiddleware().GetUserIDFromToken(tokenString)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	kind, ok := sellerImageKind(c)
+	if !ok {
+		return
+	}
+	user := models.Seller{ID: userID}
+	if err := user.SetImage(c.Request.Context(), kind, nil); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, generateSellerData(user))
+}
+
+func sellerImageKind(c *gin.Context) (string, bool) {
+	kind := c.Param("kind")
+	if kind != enums.ImageLogo && kind != enums.ImageBanner {
+		_ = c.Error(models.ErrInvalidImageKind)
+		return "", false
+	}
+	return kind, true
+}
@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+	"user-service/forms"
+	"user-service/models"
+)
+
+// PingExample godoc
+// @Summary List frozen wallets
+// @Schemes
+// @Description List wallets frozen by reconciliation because their balance did not match the ledger
+// @Tags wallet
+// @Accept json
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer ServiceJWTToken"
+// @Success 200 {array} forms.FrozenWalletResponse
+// @Router /service/wallets/frozen [get]
+func ListFrozenWallets(c *gin.Context) {
+	wallets, err := models.ListFrozenWallets(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	response := make([]forms.FrozenWalletResponse, 0, len(wallets))
+	for _, wallet := range wallets {
+		response = append(response, forms.FrozenWalletResponse{
+			Group:        wallet.Group,
+			UserID:       wallet.UserID,
+			Currency:     wallet.Currency,
+			Balance:      wallet.Balance,
+			FrozenReason: wallet.FrozenReason,
+			FrozenAt:     wallet.UpdatedAt,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// PingExample godoc
+// @Summary Unfreeze a wallet
+// @Schemes
+// @Description Lift a reconciliation freeze once the wallet has been reviewed
+// @Tags wallet
+// @Accept json
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer ServiceJWTToken"
+// @Param data body forms.UnfreezeWalletInput true "Wallet owner and currency"
+// @Success 204
+// @Failure 404 {object} forms.ProblemResponse "Wallet not found"
+// @Router /service/wallets/unfreeze [post]
+func UnfreezeWallet(c *gin.Context) {
+	var input forms.UnfreezeWalletInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	err := models.UnfreezeWallet(c.Request.Context(), input.Group, input.UserID, input.Currency)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		_ = c.Error(errWalletNotFound)
+		return
+	}
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
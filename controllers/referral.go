@@ -0,0 +1,28 @@
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+	"user-service/enums"
+	"user-service/forms"
+	"user-service/middlewares"
+	"user-service/models"
+)
+
+// signupSignal is what the referral fraud guards compare. The client IP only
+// honours forwarding headers from the trusted proxies and the device ID is one
+// the service signed
+func signupSignal(c *gin.Context) models.SignupSignal {
+	return models.SignupSignal{
+		IP:       c.ClientIP(),
+		DeviceID: middlewares.GetDeviceID(c),
+	}
+}
+
+func generateReferralData(code string, stats map[string]int64) forms.ReferralResponse {
+	return forms.ReferralResponse{
+		Code:     code,
+		Pending:  stats[enums.ReferralPending],
+		Rewarded: stats[enums.ReferralRewarded],
+		Rejected: stats[enums.ReferralRejected],
+	}
+}
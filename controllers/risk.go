@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+	"user-service/enums"
+	"user-service/forms"
+	"user-service/i18n"
+	"user-service/middlewares"
+	"user-service/models"
+)
+
+const defaultRiskDecisionLimit = 50
+
+// buyerPassedMFA checks the token a service forwards as proof of the second
+// factor, it must be an access token of that buyer issued after MFA
+func buyerPassedMFA(token string, buyerID uuid.UUID) bool {
+	if token == "" {
+		return false
+	}
+	jwtMiddleware := middlewares.GetCustomerJwtMiddleware()
+	userID, err := jwtMiddleware.GetUserIDFromToken(token)
+	return err == nil && userID == buyerID && jwtMiddleware.IsMFAVerified(token)
+}
+
+// assessRisk runs the risk rules on a wallet operation and writes the
+// rejection when it may not go ahead. It reports whether the caller can
+// continue
+func assessRisk(c *gin.Context, request models.RiskRequest) bool {
+	request.IP = c.ClientIP()
+	_, err := models.EvaluateRisk(c.Request.Context(), request)
+	var rejection *models.RiskRejection
+	if errors.As(err, &rejection) {
+		// The key is freed so the client can retry with its second factor, the
+		// MFA claim is not part of the request hash
+		middlewares.MarkRetryable(c)
+		code := i18n.CodeOperationBlocked
+		if rejection.Decision.Decision == enums.RiskChallenge {
+			code = i18n.CodeMFARequired
+		}
+		middlewares.WriteProblem(c, http.StatusForbidden, forms.RiskRejectionResponse{
+			ProblemResponse: middlewares.NewProblem(c, http.StatusForbidden, code),
+			DecisionID:      rejection.Decision.ID,
+			Rules:           rejection.Decision.TriggeredRules(),
+		})
+		return false
+	}
+	if err != nil {
+		_ = c.Error(err)
+		return false
+	}
+	return true
+}
+
+// PingExample godoc
+// @Summary List risk decisions
+// @Schemes
+// @Description List the latest decisions of the wallet risk rules for review, newest first
+// @Tags wallet
+// @Accept json
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer ServiceJWTToken"
+// @Param decision query string false "Outcome" Enums(allow, challenge, block)
+// @Param user_id query string false "Buyer or seller ID"
+// @Param limit query int false "Number of decisions, at most 100"
+// @Success 200 {array} forms.RiskDecisionResponse
+// @Router /service/risk/decisions [get]
+func ListRiskDecisions(c *gin.Context) {
+	var query forms.RiskDecisionQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultRiskDecisionLimit
+	}
+	userID := uuid.Nil
+	if query.UserID != "" {
+		userID = uuid.MustParse(query.UserID)
+	}
+	decisions, err := models.ListRiskDecisions(c.Request.Context(), query.Decision, userID, query.Limit)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	response := make([]forms.RiskDecisionResponse, 0, len(decisions))
+	for _, decision := range decisions {
+		response = append(response, forms.RiskDecisionResponse{
+			ID:          decision.ID,
+			UserID:      decision.UserID,
+			UserGroup:   decision.UserGroup,
+			Operation:   decision.Operation,
+			Currency:    decision.Currency,
+			Amount:      decision.Amount,
+			DeviceID:    decision.DeviceID,
+			IP:          decision.IP,
+			MFAVerified: decision.MFAVerified,
+			Decision:    decision.Decision,
+			Rules:       decision.TriggeredRules(),
+			Reason:      decision.Reason,
+			CreatedAt:   decision.CreatedAt,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
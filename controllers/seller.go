@@ -1,8 +1,10 @@
 package controllers
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 	"net/http"
 	"user-service/enums"
 	"user-service/forms"
@@ -24,18 +26,22 @@ import (
 func SellerLogin(c *gin.Context) {
 	var loginData forms.UserSignIn
 	if err := c.ShouldBind(&loginData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
 	var userModel = models.Seller{}
 	isSuccess, err := userModel.Login(c.Request.Context(), loginData)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// Same answer as a wrong password so usernames cannot be probed
+		isSuccess, err = false, nil
+	}
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 	if !isSuccess {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "authentication failed"})
+		_ = c.Error(errAuthenticationFailed)
 		return
 	}
 
@@ -45,16 +51,17 @@ func SellerLogin(c *gin.Context) {
 		RoleGroupName: enums.Seller,
 		Firstname:     userModel.SellerProfile.FirstName,
 		Lastname:      userModel.SellerProfile.LastName,
+		Locale:        models.PreferredLocale(c.Request.Context(), enums.Seller, userModel.ID),
 	}
 	tokenString, err := middlewares.GetSellerJwtMiddleware().GenerateAccessToken(&tokenUserInput)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
 	refreshTokenString, err := middlewares.GetSellerJwtMiddleware().GenerateRefreshToken(&tokenUserInput)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
@@ -74,19 +81,21 @@ func SellerLogin(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param data body forms.UserSignUp true "Signup input"
+// @Param X-Device-ID header string false "Device ID the service issued, a new one is returned when missing"
 // @Success 200 {object} forms.LoginResponse
+// @Header 200 {string} X-Device-ID "Newly issued device ID, send it on later requests"
 // @Router /seller/register [post]
 func SellerRegister(c *gin.Context) {
 	var input forms.UserSignUp
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 	var userModel = new(models.Seller)
-	newUser, err := userModel.CreateAccount(c.Request.Context(), input)
+	newUser, err := userModel.CreateAccount(c.Request.Context(), input, signupSignal(c))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 	tokenUserInput := service.TokenUserInput{
@@ -95,17 +104,18 @@ func SellerRegister(c *gin.Context) {
 		RoleGroupName: enums.Seller,
 		Firstname:     newUser.SellerProfile.FirstName,
 		Lastname:      newUser.SellerProfile.LastName,
+		Locale:        models.PreferredLocale(c.Request.Context(), enums.Seller, newUser.ID),
 	}
 
 	tokenString, err := middlewares.GetSellerJwtMiddleware().GenerateAccessToken(&tokenUserInput)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
 	refreshTokenString, err := middlewares.GetSellerJwtMiddleware().GenerateRefreshToken(&tokenUserInput)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
@@ -131,23 +141,23 @@ func SellerRefreshToken(c *gin.Context) {
 	var input forms.RefreshTokenRequest
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
 	claims, err := middlewares.GetSellerJwtMiddleware().ValidateRefreshAccessToken(input.RefreshToken)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 	userId, err := uuid.Parse(claims["userid"].(string))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(service.ErrInvalidToken)
 		return
 	}
 	var user models.Seller
 	if err := user.RetrieveByUserIDWithProfile(c.Request.Context(), userId); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 	tokenUserInput := service.TokenUserInput{
@@ -156,10 +166,11 @@ func SellerRefreshToken(c *gin.Context) {
 		RoleGroupName: enums.Seller,
 		Firstname:     user.SellerProfile.FirstName,
 		Lastname:      user.SellerProfile.LastName,
+		Locale:        models.PreferredLocale(c.Request.Context(), enums.Seller, user.ID),
 	}
 	accessToken, err := middlewares.GetSellerJwtMiddleware().GenerateAccessToken(&tokenUserInput)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"access_token": accessToken})
@@ -175,38 +186,219 @@ func SellerRefreshToken(c *gin.Context) {
 // @Security JWT Key
 // @param Authorization header string true "Bearer YourJWTToken"
 // @Success 200 {object} forms.UserResponse
+// @Header 200 {string} ETag "Version of the profile, send it in If-Match to update"
 // @Router /seller/profile [get]
 func GetSellerProfile(c *gin.Context) {
 	authHeader := c.GetHeader("Authorization")
 	tokenString := authHeader[len("Bearer "):]
 	userID, err := middlewares.GetSellerJwtMiddleware().GetUserIDFromToken(tokenString)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 	user := models.Seller{}
 
 	err = user.RetrieveByUserIDWithProfile(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
 	loginResponse := generateSellerData(user)
+	c.Header("ETag", profileETag(user.SellerProfile.UpdatedAt))
 	c.JSON(http.StatusOK, loginResponse)
 }
 
 func generateSellerData(userModel models.Seller) forms.UserResponse {
 	return forms.UserResponse{
-		ID:       userModel.ID,
-		Username: userModel.Username,
+		ID:           userModel.ID,
+		Username:     userModel.Username,
+		ReferralCode: userModel.ReferralCode,
+		Phone:        userModel.Phone,
 		Profile: forms.UserProfileResponse{
-			FirstName: userModel.SellerProfile.FirstName,
-			LastName:  userModel.SellerProfile.LastName,
+			FirstName:          userModel.SellerProfile.FirstName,
+			LastName:           userModel.SellerProfile.LastName,
+			Logo:               models.ImageURLs(enums.ImageLogo, userModel.SellerProfile.Logo),
+			Banner:             models.ImageURLs(enums.ImageBanner, userModel.SellerProfile.Banner),
+			DisplayName:        userModel.SellerProfile.DisplayName,
+			Bio:                userModel.SellerProfile.Bio,
+			Slug:               userModel.SellerProfile.Slug,
+			Verified:           userModel.SellerProfile.Verified,
+			Country:            userModel.SellerProfile.Country,
+			VerificationStatus: userModel.SellerProfile.VerificationStatus,
+			SocialLinks:        generateSocialLinkData(userModel.SocialLinks),
 		},
 		Group: forms.UserGroupResponse{
 			Name: enums.Seller,
 		},
 		WalletBalance: userModel.SellerWallet.Balance,
+		Wallets:       generateSellerWalletData(userModel),
+	}
+}
+
+func generateSellerWalletData(userModel models.Seller) []forms.WalletResponse {
+	wallets := userModel.Wallets
+	if len(wallets) == 0 {
+		// Freshly created accounts only carry the default wallet
+		wallets = []models.SellerWallet{userModel.SellerWallet}
+	}
+	response := make([]forms.WalletResponse, 0, len(wallets))
+	for _, wallet := range wallets {
+		response = append(response, forms.WalletResponse{
+			Currency: wallet.Currency,
+			Balance:  wallet.Balance,
+		})
+	}
+	return response
+}
+
+// PingExample godoc
+// @Summary Get seller wallet transaction history
+// @Schemes
+// @Description List wallet transactions newest first, use next_cursor to fetch the following page
+// @Tags example
+// @Accept json
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer YourJWTToken"
+// @Param cursor query string false "Cursor returned by the previous page"
+// @Param limit query int false "Page size, at most 100"
+// @Param type query string false "Transaction type" Enums(sale, refund, payout, payout_reversal, referral_reward, opening_balance)
+// @Param currency query string false "ISO 4217 currency code of the wallet"
+// @Param from query string false "Start date (YYYY-MM-DD)"
+// @Param to query string false "End date inclusive (YYYY-MM-DD)"
+// @Success 200 {object} forms.WalletTransactionListResponse
+// @Router /seller/wallet/transactions [get]
+func GetSellerWalletTransactions(c *gin.Context) {
+	var query forms.WalletTransactionQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	if query.Type != "" && !enums.IsValidTransactionType(query.Type) {
+		_ = c.Error(errInvalidTransaction)
+		return
+	}
+	if query.Currency != "" && !enums.IsValidCurrency(query.Currency) {
+		_ = c.Error(models.ErrInvalidCurrency)
+		return
+	}
+	authHeader := c.GetHeader("Authorization")
+	tokenString := authHeader[len("Bearer "):]
+	userID, err := middlewares.GetSellerJwtMiddleware().GetUserIDFromToken(tokenString)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	user := models.Seller{ID: userID}
+
+	transactions, nextCursor, err := user.ListWalletTransactions(c.Request.Context(), query)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	response := forms.WalletTransactionListResponse{
+		Transactions: make([]forms.WalletTransactionResponse, 0, len(transactions)),
+		NextCursor:   nextCursor,
+	}
+	for _, transaction := range transactions {
+		response.Transactions = append(response.Transactions, forms.WalletTransactionResponse{
+			ID:                    transaction.ID,
+			Type:                  transaction.Type,
+			Currency:              transaction.Currency,
+			Amount:                transaction.Amount,
+			BalanceAfter:          transaction.BalanceAfter,
+			Reference:             transaction.Reference,
+			CreatedAt:             transaction.CreatedAt,
+			OriginalTransactionID: transaction.OriginalTransactionID,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// PingExample godoc
+// @Summary Open a seller wallet in another currency
+// @Schemes
+// @Description Create an empty wallet in the given ISO 4217 currency, one wallet per currency
+// @Tags example
+// @Accept json
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer YourJWTToken"
+// @Param data body forms.OpenWalletInput true "Currency of the new wallet"
+// @Success 200 {object} forms.WalletResponse
+// @Failure 409 {object} forms.ProblemResponse "Wallet already exists"
+// @Router /seller/wallets [post]
+func OpenSellerWallet(c *gin.Context) {
+	var input forms.OpenWalletInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	authHeader := c.GetHeader("Authorization")
+	tokenString := authHeader[len("Bearer "):]
+	userID, err := middlewares.GetSellerJwtMiddleware().GetUserIDFromToken(tokenString)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	user := models.Seller{ID: userID}
+
+	wallet, err := user.OpenWallet(c.Request.Context(), input.Currency)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, forms.WalletResponse{
+		Currency: wallet.Currency,
+		Balance:  wallet.Balance,
+	})
+}
+
+// PingExample godoc
+// @Summary Get seller referral code
+// @Schemes
+// @Description Return the referral code to share and how many referrals are pending, rewarded or rejected
+// @Tags example
+// @Accept json
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer YourJWTToken"
+// @Success 200 {object} forms.ReferralResponse
+// @Router /seller/referral [get]
+func GetSellerReferral(c *gin.Context) {
+	authHeader := c.GetHeader("Authorization")
+	tokenString := authHeader[len("Bearer "):]
+	userID, err := middlewares.GetSellerJwtMiddleware().GetUserIDFromToken(tokenString)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	user := models.Seller{}
+	if err := user.RetrieveByUserID(c.Request.Context(), userID); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	if err := user.EnsureReferralCode(c.Request.Context()); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	stats, err := models.ReferralStats(c.Request.Context(), user.ID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, generateReferralData(user.ReferralCode, stats))
+}
+
+func generateSocialLinkData(links []models.SellerSocialLink) []forms.SocialLinkResponse {
+	response := make([]forms.SocialLinkResponse, 0, len(links))
+	for _, link := range links {
+		response = append(response, forms.SocialLinkResponse{
+			Platform: link.Platform,
+			URL:      link.URL,
+		})
 	}
+	return response
 }
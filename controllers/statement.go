@@ -0,0 +1,136 @@
+package controllers
+
+import (
+	"bytes"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+	"time"
+	"user-service/forms"
+	"user-service/middlewares"
+	"user-service/models"
+	"user-service/statement"
+)
+
+const (
+	statementFormatCSV = "csv"
+	statementFormatPDF = "pdf"
+)
+
+// PingExample godoc
+// @Summary Download a buyer wallet statement
+// @Schemes
+// @Description Monthly statement with opening balance, every transaction and closing balance, as CSV or PDF
+// @Tags example
+// @Produce text/csv
+// @Produce application/pdf
+// @Security JWT Key
+// @param Authorization header string true "Bearer YourJWTToken"
+// @Param month query string true "Statement month (YYYY-MM)"
+// @Param currency query string false "ISO 4217 currency code of the wallet"
+// @Param format query string false "Document format, csv by default" Enums(csv, pdf)
+// @Success 200 {file} file
+// @Failure 404 {object} forms.ProblemResponse "Wallet not found"
+// @Router /customer/wallet/statements [get]
+func GetBuyerWalletStatement(c *gin.Context) {
+	query, month, currency, ok := bindStatementQuery(c)
+	if !ok {
+		return
+	}
+	authHeader := c.GetHeader("Authorization")
+	tokenString := authHeader[len("Bearer "):]
+	userID, err := middlewares.GetCustomerJwtMiddleware().GetUserIDFromToken(tokenString)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	user := models.Buyer{ID: userID}
+
+	walletStatement, err := user.Statement(c.Request.Context(), currency, month)
+	if err != nil {
+		respondStatementError(c, err)
+		return
+	}
+	writeStatement(c, *walletStatement, query.Format)
+}
+
+// PingExample godoc
+// @Summary Download a seller wallet statement
+// @Schemes
+// @Description Monthly statement with opening balance, every transaction with its fee and commission and closing balance, as CSV or PDF
+// @Tags example
+// @Produce text/csv
+// @Produce application/pdf
+// @Security JWT Key
+// @param Authorization header string true "Bearer YourJWTToken"
+// @Param month query string true "Statement month (YYYY-MM)"
+// @Param currency query string false "ISO 4217 currency code of the wallet"
+// @Param format query string false "Document format, csv by default" Enums(csv, pdf)
+// @Success 200 {file} file
+// @Failure 404 {object} forms.ProblemResponse "Wallet not found"
+// @Router /seller/wallet/statements [get]
+func GetSellerWalletStatement(c *gin.Context) {
+	query, month, currency, ok := bindStatementQuery(c)
+	if !ok {
+		return
+	}
+	authHeader := c.GetHeader("Authorization")
+	tokenString := authHeader[len("Bearer "):]
+	userID, err := middlewares.GetSellerJwtMiddleware().GetUserIDFromToken(tokenString)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	user := models.Seller{ID: userID}
+
+	walletStatement, err := user.Statement(c.Request.Context(), currency, month)
+	if err != nil {
+		respondStatementError(c, err)
+		return
+	}
+	writeStatement(c, *walletStatement, query.Format)
+}
+
+func bindStatementQuery(c *gin.Context) (forms.WalletStatementQuery, time.Time, string, bool) {
+	var query forms.WalletStatementQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		_ = c.Error(err)
+		return query, time.Time{}, "", false
+	}
+	month, err := time.Parse("2006-01", query.Month)
+	if err != nil {
+		_ = c.Error(errInvalidMonth)
+		return query, time.Time{}, "", false
+	}
+	currency, ok := walletCurrency(c, query.Currency)
+	return query, month, currency, ok
+}
+
+func respondStatementError(c *gin.Context, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = errWalletNotFound
+	}
+	_ = c.Error(err)
+}
+
+// writeStatement renders the whole document before sending it so a rendering
+// error can still be reported as a problem
+func writeStatement(c *gin.Context, walletStatement models.Statement, format string) {
+	var document bytes.Buffer
+	contentType := "text/csv"
+	var err error
+	if format == statementFormatPDF {
+		contentType = "application/pdf"
+		err = statement.WritePDF(&document, walletStatement)
+	} else {
+		format = statementFormatCSV
+		err = statement.WriteCSV(&document, walletStatement)
+	}
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="`+walletStatement.Filename(format)+`"`)
+	c.Data(http.StatusOK, contentType, document.Bytes())
+}
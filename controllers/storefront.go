@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+	"strconv"
+	"strings"
+	"user-service/enums"
+	"user-service/forms"
+	"user-service/models"
+)
+
+// storefrontMaxAge lets clients and proxies reuse a storefront briefly before
+// revalidating it with If-None-Match
+const storefrontMaxAge = "public, max-age=60"
+
+// PingExample godoc
+// @Summary Get seller storefront
+// @Schemes
+// @Description Public storefront of a seller looked up by ID or slug. Send the returned ETag in If-None-Match to get 304 when nothing changed
+// @Tags example
+// @Produce json
+// @Param id path string true "Seller ID or slug"
+// @Param If-None-Match header string false "ETag returned by the last read"
+// @Success 200 {object} forms.SellerStorefrontResponse
+// @Header 200 {string} ETag "Version of the storefront"
+// @Success 304 "Not modified"
+// @Failure 404 {object} forms.ProblemResponse "No such seller"
+// @Router /sellers/{id} [get]
+func GetSellerStorefront(c *gin.Context) {
+	seller := models.Seller{}
+	if err := seller.RetrieveStorefront(c.Request.Context(), c.Param("id")); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = errSellerNotFound
+		}
+		_ = c.Error(err)
+		return
+	}
+
+	// Weak since the body is rebuilt from the profile rather than stored
+	etag := `W/"` + strconv.FormatInt(seller.SellerProfile.UpdatedAt.UnixMicro(), 10) + `"`
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", storefrontMaxAge)
+	for _, tag := range strings.Split(c.GetHeader("If-None-Match"), ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == etag || tag == strings.TrimPrefix(etag, "W/") || tag == "*" {
+			c.Status(http.StatusNotModified)
+			return
+		}
+	}
+	c.JSON(http.StatusOK, generateStorefrontData(seller))
+}
+
+func generateStorefrontData(seller models.Seller) forms.SellerStorefrontResponse {
+	profile := seller.SellerProfile
+	return forms.SellerStorefrontResponse{
+		ID:          seller.ID,
+		Slug:        profile.Slug,
+		DisplayName: profile.StorefrontName(),
+		Bio:         profile.Bio,
+		Logo:        models.ImageURLs(enums.ImageLogo, profile.Logo),
+		Banner:      models.ImageURLs(enums.ImageBanner, profile.Banner),
+		Country:     profile.Country,
+		JoinedAt:    seller.CreatedAt,
+		Verified:    profile.Verified,
+		SocialLinks: generateSocialLinkData(seller.SocialLinks),
+	}
+}
+
+// PingExample godoc
+// @Summary List sellers
+// @Schemes
+// @Description Search and filter the seller directory. Results are sorted by relevance when searching and newest first otherwise, use next_cursor to fetch the following page
+// @Tags example
+// @Produce json
+// @Param q query string false "Search display name, name and bio"
+// @Param verified query bool false "Only verified or only unverified sellers"
+// @Param country query string false "ISO 3166-1 alpha-2 country code"
+// @Param joined_from query string false "Joined on or after, YYYY-MM-DD"
+// @Param joined_to query string false "Joined on or before, YYYY-MM-DD"
+// @Param sort query string false "Ordering" Enums(relevance, newest)
+// @Param cursor query string false "Cursor returned by the previous page"
+// @Param limit query int false "Page size, at most 100"
+// @Success 200 {object} forms.SellerDirectoryResponse
+// @Router /sellers [get]
+func ListSellers(c *gin.Context) {
+	var query forms.SellerDirectoryQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	entries, nextCursor, err := models.ListSellerDirectory(c.Request.Context(), query)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	response := forms.SellerDirectoryResponse{
+		Sellers:    make([]forms.SellerSummaryResponse, 0, len(entries)),
+		NextCursor: nextCursor,
+	}
+	for _, entry := range entries {
+		response.Sellers = append(response.Sellers, forms.SellerSummaryResponse{
+			ID:          entry.ID,
+			Slug:        entry.Slug,
+			DisplayName: entry.StorefrontName(),
+			Bio:         entry.Bio,
+			Logo:        models.ImageURLs(enums.ImageLogo, entry.Logo),
+			Country:     entry.Country,
+			JoinedAt:    entry.JoinedAt,
+			Verified:    entry.Verified,
+		})
+	}
+	c.Header("Cache-Control", storefrontMaxAge)
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,326 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"user-service/enums"
+	"user-service/forms"
+	"user-service/middlewares"
+	"user-service/models"
+)
+
+// readVerificationDocuments reads one file per document type from the
+// multipart form, it records the error when the upload is unusable
+func readVerificationDocuments(c *gin.Context) ([]models.VerificationUpload, bool) {
+	maxBytes := models.DocumentMaxBytes()
+	// Every document type at its largest, plus room for the text fields
+	maxBody := maxBytes*int64(len(enums.VerificationDocumentTypes)) + 64<<10
+	if c.Request.ContentLength > maxBody {
+		_ = c.Error(models.ErrDocumentTooLarge)
+		return nil, false
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBody)
+	form, err := c.MultipartForm()
+	if err != nil {
+		_ = c.Error(badRequest(err))
+		return nil, false
+	}
+	var uploads []models.VerificationUpload
+	for _, documentType := range enums.VerificationDocumentTypes {
+		headers := form.File[documentType]
+		if len(headers) == 0 {
+			continue
+		}
+		file, err := headers[0].Open()
+		if err != nil {
+			_ = c.Error(badRequest(err))
+			return nil, false
+		}
+		data, err := io.ReadAll(io.LimitReader(file, maxBytes+1))
+		file.Close()
+		if err != nil {
+			_ = c.Error(badRequest(err))
+			return nil, false
+		}
+		uploads = append(uploads, models.VerificationUpload{
+			Type:     documentType,
+			Filename: filepath.Base(headers[0].Filename),
+			Data:     data,
+		})
+	}
+	return uploads, true
+}
+
+func respondVerificationError(c *gin.Context, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = errVerificationNotFound
+	}
+	_ = c.Error(err)
+}
+
+// PingExample godoc
+// @Summary Submit seller verification
+// @Schemes
+// @Description Send the business details and documents for review. Documents are PDF, JPEG or PNG files sent in fields named after their type, business_registration is required. Verified sellers get the storefront badge and can request payouts
+// @Tags payout
+// @Accept multipart/form-data
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer YourJWTToken"
+// @Param legal_name formData string true "Registered legal name"
+// @Param business_registration_number formData string true "Business registration number"
+// @Param tax_id formData string true "Tax ID"
+// @Param business_registration formData file true "Business registration certificate"
+// @Param identity formData file false "Identity document of the owner"
+// @Param tax_certificate formData file false "Tax registration certificate"
+// @Success 200 {object} forms.VerificationResponse
+// @Failure 409 {object} forms.ProblemResponse "Already verified or under review"
+// @Failure 413 {object} forms.ProblemResponse "Document too large"
+// @Failure 415 {object} forms.ProblemResponse "Not a PDF, JPEG or PNG file"
+// @Failure 422 {object} forms.ProblemResponse "Required document missing"
+// @Router /seller/verification [post]
+func SubmitSellerVerification(c *gin.Context) {
+	authHeader := c.GetHeader("Authorization")
+	tokenString := authHeader[len("Bearer "):]
+	userID, err := middlewares.GetSellerJwtMiddleware().GetUserIDFromToken(tokenString)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	uploads, ok := readVerificationDocuments(c)
+	if !ok {
+		return
+	}
+	var input forms.SubmitVerificationInput
+	if err := c.ShouldBind(&input); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	user := models.Seller{ID: userID}
+	verification, err := user.SubmitVerification(c.Request.Context(), models.VerificationSubmission{
+		LegalName:                  input.LegalName,
+		BusinessRegistrationNumber: input.BusinessRegistrationNumber,
+		TaxID:                      input.TaxID,
+		Documents:                  uploads,
+	})
+	if err != nil {
+		respondVerificationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, generateVerificationData(*verification))
+}
+
+// PingExample godoc
+// @Summary Get seller verification
+// @Schemes
+// @Description Latest verification submission of the seller and its review outcome
+// @Tags payout
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer YourJWTToken"
+// @Success 200 {object} forms.VerificationResponse
+// @Failure 404 {object} forms.ProblemResponse "Nothing submitted yet"
+// @Router /seller/verification [get]
+func GetSellerVerification(c *gin.Context) {
+	authHeader := c.GetHeader("Authorization")
+	tokenString := authHeader[len("Bearer "):]
+	userID, err := middlewares.GetSellerJwtMiddleware().GetUserIDFromToken(tokenString)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	verification := models.SellerVerification{}
+	if err := verification.RetrieveLatest(c.Request.Context(), userID); err != nil {
+		respondVerificationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, generateVerificationData(verification))
+}
+
+// PingExample godoc
+// @Summary List seller verifications
+// @Schemes
+// @Description List verification submissions by status, oldest first
+// @Tags payout
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer ServiceJWTToken"
+// @Param status query string false "Status, pending by default" Enums(pending, approved, rejected)
+// @Success 200 {array} forms.VerificationResponse
+// @Router /service/verifications [get]
+func ListSellerVerifications(c *gin.Context) {
+	var query forms.VerificationStatusQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	status := query.Status
+	if status == "" {
+		status = enums.VerificationPending
+	}
+	verifications, err := models.ListVerificationsByStatus(c.Request.Context(), status)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	response := make([]forms.VerificationResponse, 0, len(verifications))
+	for _, verification := range verifications {
+		response = append(response, generateVerificationData(verification))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// PingExample godoc
+// @Summary Get a seller verification for review
+// @Schemes
+// @Description Latest verification submission of a seller with its documents
+// @Tags payout
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer ServiceJWTToken"
+// @Param seller_id path string true "Seller ID"
+// @Success 200 {object} forms.VerificationResponse
+// @Failure 404 {object} forms.ProblemResponse "Nothing submitted"
+// @Router /service/verifications/{seller_id} [get]
+func GetSellerVerificationForReview(c *gin.Context) {
+	verification, ok := retrieveSellerVerification(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, generateVerificationData(verification))
+}
+
+// PingExample godoc
+// @Summary Download a verification document
+// @Schemes
+// @Description Download a document of the latest verification submission of a seller
+// @Tags payout
+// @Produce application/pdf,image/jpeg,image/png
+// @Security JWT Key
+// @param Authorization header string true "Bearer ServiceJWTToken"
+// @Param seller_id path string true "Seller ID"
+// @Param document_id path int true "Document ID"
+// @Success 200 {file} file
+// @Failure 404 {object} forms.ProblemResponse "No such document"
+// @Router /service/verifications/{seller_id}/documents/{document_id} [get]
+func DownloadVerificationDocument(c *gin.Context) {
+	verification, ok := retrieveSellerVerification(c)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("document_id"), 10, 64)
+	if err != nil {
+		_ = c.Error(errInvalidID)
+		return
+	}
+	document, data, err := verification.Document(c.Request.Context(), uint(id))
+	if err != nil {
+		respondVerificationError(c, err)
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": document.Filename}))
+	c.Data(http.StatusOK, document.ContentType, data)
+}
+
+// PingExample godoc
+// @Summary Approve a seller verification
+// @Schemes
+// @Description Verify the seller, which shows the storefront badge and allows payouts
+// @Tags payout
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer ServiceJWTToken"
+// @Param seller_id path string true "Seller ID"
+// @Success 200 {object} forms.VerificationResponse
+// @Failure 409 {object} forms.ProblemResponse "Verification is not pending"
+// @Router /service/verifications/{seller_id}/approve [post]
+func ApproveSellerVerification(c *gin.Context) {
+	verification, ok := retrieveSellerVerification(c)
+	if !ok {
+		return
+	}
+	if err := verification.Approve(c.Request.Context()); err != nil {
+		respondVerificationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, generateVerificationData(verification))
+}
+
+// PingExample godoc
+// @Summary Reject a seller verification
+// @Schemes
+// @Description Reject the pending submission, the seller sees the reason and can submit again
+// @Tags payout
+// @Accept json
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer ServiceJWTToken"
+// @Param seller_id path string true "Seller ID"
+// @Param data body forms.VerificationRejectInput true "Rejection reason"
+// @Success 200 {object} forms.VerificationResponse
+// @Failure 409 {object} forms.ProblemResponse "Verification is not pending"
+// @Router /service/verifications/{seller_id}/reject [post]
+func RejectSellerVerification(c *gin.Context) {
+	var input forms.VerificationRejectInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	verification, ok := retrieveSellerVerification(c)
+	if !ok {
+		return
+	}
+	if err := verification.Reject(c.Request.Context(), input.Reason); err != nil {
+		respondVerificationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, generateVerificationData(verification))
+}
+
+func retrieveSellerVerification(c *gin.Context) (models.SellerVerification, bool) {
+	verification := models.SellerVerification{}
+	sellerID, err := uuid.Parse(c.Param("seller_id"))
+	if err != nil {
+		_ = c.Error(errInvalidID)
+		return verification, false
+	}
+	if err := verification.RetrieveLatest(c.Request.Context(), sellerID); err != nil {
+		respondVerificationError(c, err)
+		return verification, false
+	}
+	return verification, true
+}
+
+func generateVerificationData(verification models.SellerVerification) forms.VerificationResponse {
+	documents := make([]forms.VerificationDocumentResponse, 0, len(verification.Documents))
+	for _, document := range verification.Documents {
+		documents = append(documents, forms.VerificationDocumentResponse{
+			ID:          document.ID,
+			Type:        document.Type,
+			Filename:    document.Filename,
+			ContentType: document.ContentType,
+			Size:        document.Size,
+			CreatedAt:   document.CreatedAt,
+		})
+	}
+	return forms.VerificationResponse{
+		ID:                         verification.ID,
+		SellerID:                   verification.SellerID,
+		LegalName:                  verification.LegalName,
+		BusinessRegistrationNumber: verification.BusinessRegistrationNumber,
+		TaxID:                      verification.TaxID,
+		Status:                     verification.Status,
+		RejectionReason:            verification.RejectionReason,
+		Documents:                  documents,
+		CreatedAt:                  verification.CreatedAt,
+		ReviewedAt:                 verification.ReviewedAt,
+	}
+}
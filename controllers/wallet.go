@@ -0,0 +1,472 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"time"
+	"user-service/enums"
+	"user-service/forms"
+	"user-service/middlewares"
+	"user-service/models"
+)
+
+// PingExample godoc
+// @Summary Debit buyer wallet for a purchase
+// @Schemes
+// @Description Charge a buyer wallet, only available to service clients with the wallet:debit permission
+// @Tags wallet
+// @Accept json
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer ServiceJWTToken"
+// @Param Idempotency-Key header string false "Unique key to safely retry the request"
+// @Param data body forms.WalletDebitInput true "Buyer, amount and order reference"
+// @Success 200 {object} forms.WalletDebitResponse
+// @Failure 402 {object} forms.ProblemResponse "Insufficient funds"
+// @Failure 423 {object} forms.ProblemResponse "Wallet is frozen pending review"
+// @Failure 403 {object} forms.RiskRejectionResponse "Blocked or challenged by the risk rules. A challenge passes with the buyer token from /customer/mfa/verify as mfa_token"
+// @Failure 422 {object} forms.PolicyViolationResponse "Amount is not a valid money amount"
+// @Router /service/wallet/debit [post]
+func DebitBuyerWallet(c *gin.Context) {
+	var input forms.WalletDebitInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	currency, ok := walletCurrency(c, input.Currency)
+	if !ok {
+		return
+	}
+	if !assessRisk(c, models.RiskRequest{
+		Group:       enums.Buyer,
+		UserID:      input.BuyerID,
+		Operation:   enums.RiskOperationDebit,
+		Currency:    currency,
+		Amount:      input.Amount,
+		DeviceID:    input.DeviceID,
+		MFAVerified: buyerPassedMFA(input.MFAToken, input.BuyerID),
+	}) {
+		return
+	}
+	user := models.Buyer{ID: input.BuyerID}
+
+	transaction, err := user.Debit(c.Request.Context(), currency, input.Amount, input.OrderReference)
+	if respondPolicyViolation(c, err) {
+		return
+	}
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	middlewares.MarkCommitted(c)
+
+	c.JSON(http.StatusOK, forms.WalletDebitResponse{
+		TransactionID: transaction.ID,
+		NewBalance:    transaction.BalanceAfter,
+	})
+}
+
+// PingExample godoc
+// @Summary Authorize an escrow hold on a buyer wallet
+// @Schemes
+// @Description Reserve part of the buyer available balance for an order until it is captured or voided
+// @Tags wallet
+// @Accept json
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer ServiceJWTToken"
+// @Param Idempotency-Key header string false "Unique key to safely retry the request"
+// @Param data body forms.WalletHoldInput true "Buyer, seller, amount and order reference"
+// @Success 200 {object} forms.WalletHoldResponse
+// @Failure 402 {object} forms.ProblemResponse "Insufficient funds"
+// @Failure 423 {object} forms.ProblemResponse "Wallet is frozen pending review"
+// @Failure 403 {object} forms.RiskRejectionResponse "Blocked or challenged by the risk rules. A challenge passes with the buyer token from /customer/mfa/verify as mfa_token"
+// @Failure 422 {object} forms.PolicyViolationResponse "Amount is not a valid money amount"
+// @Router /service/wallet/holds [post]
+func AuthorizeWalletHold(c *gin.Context) {
+	var input forms.WalletHoldInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	currency, ok := walletCurrency(c, input.Currency)
+	if !ok {
+		return
+	}
+	seller := models.Seller{}
+	if err := seller.RetrieveByUserID(c.Request.Context(), input.SellerID); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	ttl := models.HoldTTL()
+	if input.ExpiresIn > 0 {
+		ttl = time.Duration(input.ExpiresIn) * time.Second
+	}
+	if !assessRisk(c, models.RiskRequest{
+		Group:       enums.Buyer,
+		UserID:      input.BuyerID,
+		Operation:   enums.RiskOperationHold,
+		Currency:    currency,
+		Amount:      input.Amount,
+		DeviceID:    input.DeviceID,
+		MFAVerified: buyerPassedMFA(input.MFAToken, input.BuyerID),
+	}) {
+		return
+	}
+	user := models.Buyer{ID: input.BuyerID}
+	hold, err := user.AuthorizeHold(c.Request.Context(), currency, input.SellerID, input.Amount, input.Category, input.Reference, ttl)
+	if respondPolicyViolation(c, err) {
+		return
+	}
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	middlewares.MarkCommitted(c)
+	c.JSON(http.StatusOK, generateHoldData(*hold))
+}
+
+// PingExample godoc
+// @Summary Capture an escrow hold
+// @Schemes
+// @Description Charge the held amount to the buyer and credit the seller wallet minus the platform commission
+// @Tags wallet
+// @Accept json
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer ServiceJWTToken"
+// @Param Idempotency-Key header string false "Unique key to safely retry the request"
+// @Param id path int true "Hold ID"
+// @Success 200 {object} forms.WalletHoldResponse
+// @Failure 409 {object} forms.ProblemResponse "Hold is no longer authorized"
+// @Failure 423 {object} forms.ProblemResponse "Wallet is frozen pending review"
+// @Router /service/wallet/holds/{id}/capture [post]
+func CaptureWalletHold(c *gin.Context) {
+	hold, ok := retrieveHold(c)
+	if !ok {
+		return
+	}
+	if err := hold.Capture(c.Request.Context()); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	middlewares.MarkCommitted(c)
+	c.JSON(http.StatusOK, generateHoldData(hold))
+}
+
+// PingExample godoc
+// @Summary Void an escrow hold
+// @Schemes
+// @Description Release the held amount back to the buyer available balance
+// @Tags wallet
+// @Accept json
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer ServiceJWTToken"
+// @Param Idempotency-Key header string false "Unique key to safely retry the request"
+// @Param id path int true "Hold ID"
+// @Success 200 {object} forms.WalletHoldResponse
+// @Failure 409 {object} forms.ProblemResponse "Hold is no longer authorized"
+// @Router /service/wallet/holds/{id}/void [post]
+func VoidWalletHold(c *gin.Context) {
+	hold, ok := retrieveHold(c)
+	if !ok {
+		return
+	}
+	if err := hold.Void(c.Request.Context()); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	middlewares.MarkCommitted(c)
+	c.JSON(http.StatusOK, generateHoldData(hold))
+}
+
+func retrieveHold(c *gin.Context) (models.BuyerWalletHold, bool) {
+	hold := models.BuyerWalletHold{}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		_ = c.Error(errInvalidID)
+		return hold, false
+	}
+	if err := hold.RetrieveByID(c.Request.Context(), uint(id)); err != nil {
+		_ = c.Error(err)
+		return hold, false
+	}
+	return hold, true
+}
+
+func generateHoldData(hold models.BuyerWalletHold) forms.WalletHoldResponse {
+	return forms.WalletHoldResponse{
+		ID:        hold.ID,
+		BuyerID:   hold.BuyerID,
+		SellerID:  hold.SellerID,
+		Currency:  hold.Currency,
+		Amount:    hold.Amount,
+		Fee:       hold.Fee,
+		Status:    hold.Status,
+		Reference: hold.Reference,
+		ExpiresAt: hold.ExpiresAt,
+	}
+}
+
+// PingExample godoc
+// @Summary Settle a purchase from a buyer to a seller
+// @Schemes
+// @Description Debit the buyer, credit the seller and book the platform commission in one transaction
+// @Tags wallet
+// @Accept json
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer ServiceJWTToken"
+// @Param Idempotency-Key header string false "Unique key to safely retry the request"
+// @Param data body forms.SettlementInput true "Buyer, seller, amount, category and order reference"
+// @Success 200 {object} forms.SettlementResponse
+// @Failure 402 {object} forms.ProblemResponse "Insufficient funds"
+// @Failure 423 {object} forms.ProblemResponse "Wallet is frozen pending review"
+// @Failure 403 {object} forms.RiskRejectionResponse "Blocked or challenged by the risk rules. A challenge passes with the buyer token from /customer/mfa/verify as mfa_token"
+// @Failure 422 {object} forms.PolicyViolationResponse "Amount is not a valid money amount"
+// @Router /service/wallet/settlements [post]
+func SettleWallet(c *gin.Context) {
+	var input forms.SettlementInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	currency, ok := walletCurrency(c, input.Currency)
+	if !ok {
+		return
+	}
+	if !assessRisk(c, models.RiskRequest{
+		Group:       enums.Buyer,
+		UserID:      input.BuyerID,
+		Operation:   enums.RiskOperationSettlement,
+		Currency:    currency,
+		Amount:      input.Amount,
+		DeviceID:    input.DeviceID,
+		MFAVerified: buyerPassedMFA(input.MFAToken, input.BuyerID),
+	}) {
+		return
+	}
+	user := models.Buyer{ID: input.BuyerID}
+	settlement, err := user.Settle(c.Request.Context(), currency, input.SellerID, input.Amount, input.Category, input.Reference)
+	if respondPolicyViolation(c, err) {
+		return
+	}
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	middlewares.MarkCommitted(c)
+	c.JSON(http.StatusOK, forms.SettlementResponse{
+		ID:                 settlement.ID,
+		BuyerID:            settlement.BuyerID,
+		SellerID:           settlement.SellerID,
+		Currency:           settlement.Currency,
+		Amount:             settlement.Amount,
+		PromoAmount:        settlement.PromoAmount,
+		CommissionRate:     settlement.CommissionRate,
+		Commission:         settlement.Commission,
+		SellerAmount:       settlement.SellerAmount,
+		SellerCurrency:     settlement.SellerCurrency,
+		SellerCreditAmount: settlement.SellerCreditAmount,
+		ExchangeRate:       settlement.ExchangeRate,
+		RateAsOf:           settlement.RateAsOf,
+		Category:           settlement.Category,
+		Reference:          settlement.Reference,
+	})
+}
+
+// PingExample godoc
+// @Summary List commission rates
+// @Schemes
+// @Description List the commission overrides per seller and category
+// @Tags wallet
+// @Accept json
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer ServiceJWTToken"
+// @Success 200 {array} forms.CommissionRateResponse
+// @Router /service/commission_rates [get]
+func ListCommissionRates(c *gin.Context) {
+	rates, err := models.ListCommissionRates(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	response := make([]forms.CommissionRateResponse, 0, len(rates))
+	for _, rate := range rates {
+		response = append(response, generateCommissionRateData(rate))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// PingExample godoc
+// @Summary Set a commission rate
+// @Schemes
+// @Description Create or replace the commission rate for a seller, a category or both
+// @Tags wallet
+// @Accept json
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer ServiceJWTToken"
+// @Param data body forms.CommissionRateInput true "Scope and rate between 0 and 1"
+// @Success 200 {object} forms.CommissionRateResponse
+// @Router /service/commission_rates [put]
+func SaveCommissionRate(c *gin.Context) {
+	var input forms.CommissionRateInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	rate := models.CommissionRate{
+		SellerID: input.SellerID,
+		Category: input.Category,
+		Rate:     input.Rate,
+	}
+	if err := rate.Save(c.Request.Context()); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, generateCommissionRateData(rate))
+}
+
+func generateCommissionRateData(rate models.CommissionRate) forms.CommissionRateResponse {
+	return forms.CommissionRateResponse{
+		ID:       rate.ID,
+		SellerID: rate.SellerID,
+		Category: rate.Category,
+		Rate:     rate.Rate,
+	}
+}
+
+// PingExample godoc
+// @Summary Refund a purchase
+// @Schemes
+// @Description Return all or part of a purchase to the buyer and reverse the seller share and commission
+// @Tags wallet
+// @Accept json
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer ServiceJWTToken"
+// @Param Idempotency-Key header string false "Unique key to safely retry the request"
+// @Param data body forms.RefundInput true "Purchase transaction, amount and reference"
+// @Success 200 {object} forms.RefundResponse
+// @Failure 422 {object} forms.PolicyViolationResponse "Refund exceeds the amount charged, or is not a valid money amount"
+// @Router /service/wallet/refunds [post]
+func RefundWallet(c *gin.Context) {
+	var input forms.RefundInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	user := models.Buyer{ID: input.BuyerID}
+	refund, err := user.Refund(c.Request.Context(), input.TransactionID, input.Amount, input.Reference)
+	if respondPolicyViolation(c, err) {
+		return
+	}
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	middlewares.MarkCommitted(c)
+
+	response := forms.RefundResponse{
+		Currency:           refund.BuyerTransaction.Currency,
+		Amount:             refund.Amount,
+		PromoAmount:        refund.PromoAmount,
+		SellerCurrency:     refund.SellerCurrency,
+		SellerAmount:       refund.SellerAmount,
+		Commission:         refund.Commission,
+		BuyerTransactionID: refund.BuyerTransaction.ID,
+		NewBuyerBalance:    refund.BuyerTransaction.BalanceAfter,
+	}
+	if refund.SellerTransaction != nil {
+		response.SellerTransactionID = &refund.SellerTransaction.ID
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// PingExample godoc
+// @Summary Grant promotional credit to a buyer
+// @Schemes
+// @Description Add promo credit from a campaign to a buyer wallet, it is spent before cash, cannot be withdrawn and expires
+// @Tags wallet
+// @Accept json
+// @Produce json
+// @Security JWT Key
+// @param Authorization header string true "Bearer ServiceJWTToken"
+// @Param Idempotency-Key header string false "Unique key to safely retry the request"
+// @Param data body forms.PromoCreditInput true "Buyer, amount, campaign and expiry"
+// @Success 200 {object} forms.PromoCreditResponse
+// @Failure 422 {object} forms.PolicyViolationResponse
+// @Router /service/wallet/promo_credits [post]
+func GrantPromoCredit(c *gin.Context) {
+	var input forms.PromoCreditInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	currency, ok := walletCurrency(c, input.Currency)
+	if !ok {
+		return
+	}
+	user := models.Buyer{ID: input.BuyerID}
+	credit, err := user.GrantPromoCredit(c.Request.Context(), currency, input.Amount, input.Campaign, input.ExpiresAt)
+	if respondPolicyViolation(c, err) {
+		return
+	}
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	middlewares.MarkCommitted(c)
+	c.JSON(http.StatusOK, forms.PromoCreditResponse{
+		ID:        credit.ID,
+		BuyerID:   credit.BuyerID,
+		Currency:  credit.Currency,
+		Campaign:  credit.Campaign,
+		Amount:    credit.Amount,
+		Remaining: credit.Remaining,
+		ExpiresAt: credit.ExpiresAt,
+	})
+}
+
+// walletCurrency defaults an omitted currency to the default one and rejects
+// codes wallets cannot hold, it reports whether the handler may go on
+func walletCurrency(c *gin.Context, currency string) (string, bool) {
+	if currency == "" {
+		return enums.DefaultCurrency, true
+	}
+	if !models.IsSupportedCurrency(c.Request.Context(), currency) {
+		_ = c.Error(models.ErrInvalidCurrency)
+		return "", false
+	}
+	return currency, true
+}
+
+// respondPolicyViolation writes the problem with its limit when err is a
+// wallet policy violation and reports whether it did
+func respondPolicyViolation(c *gin.Context, err error) bool {
+	var violation *models.PolicyViolation
+	if !errors.As(err, &violation) {
+		return false
+	}
+	var args []interface{}
+	response := forms.PolicyViolationResponse{}
+	if !violation.Limit.IsZero() {
+		args = append(args, violation.Limit.StringFixed(2))
+		response.Limit = &violation.Limit
+	}
+	response.ProblemResponse = middlewares.NewProblem(c, http.StatusUnprocessableEntity, violation.Code, args...)
+	middlewares.WriteProblem(c, http.StatusUnprocessableEntity, response)
+	return true
+}
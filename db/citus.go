@@ -13,6 +13,32 @@ func InitDistributedTable(db *gorm.DB) error {
 		"SELECT create_distributed_table('buyers', 'id')",
 		"SELECT create_distributed_table('buyer_wallets', 'buyer_id')",
 		"SELECT create_distributed_table('buyer_profiles', 'buyer_id')",
+		"SELECT create_distributed_table('buyer_addresses', 'buyer_id')",
+		"SELECT create_distributed_table('user_preferences', 'user_id')",
+		"SELECT create_distributed_table('notification_settings', 'user_id')",
+		"SELECT create_distributed_table('buyer_wallet_transactions', 'buyer_id')",
+		"SELECT create_distributed_table('seller_wallet_transactions', 'seller_id')",
+		"SELECT create_distributed_table('idempotency_keys', 'scope')",
+		"SELECT create_distributed_table('buyer_wallet_holds', 'buyer_id')",
+		"SELECT create_distributed_table('settlements', 'buyer_id')",
+		"SELECT create_distributed_table('payout_destinations', 'seller_id')",
+		"SELECT create_distributed_table('payout_requests', 'seller_id')",
+		"SELECT create_distributed_table('topup_intents', 'buyer_id')",
+		"SELECT create_distributed_table('buyer_promo_credits', 'buyer_id')",
+		"SELECT create_distributed_table('referrals', 'referrer_id')",
+		"SELECT create_distributed_table('risk_decisions', 'user_id')",
+		"SELECT create_distributed_table('known_devices', 'user_id')",
+		"SELECT create_distributed_table('seller_social_links', 'seller_id')",
+		"SELECT create_distributed_table('seller_verifications', 'seller_id')",
+		"SELECT create_distributed_table('seller_verification_documents', 'seller_id')",
+		"SELECT create_distributed_table('one_time_passwords', 'phone')",
+		"SELECT create_distributed_table('otp_failures', 'phone')",
+		"SELECT create_distributed_table('platform_ledger_entries', 'reference')",
+		"SELECT create_reference_table('commission_rates')",
+		"SELECT create_reference_table('payout_batches')",
+		"SELECT create_reference_table('referral_codes')",
+		"SELECT create_reference_table('seller_slugs')",
+		"SELECT create_reference_table('phone_numbers')",
 	}
 	for _, query := range queries {
 		if err := db.Exec(query).Error; err != nil {
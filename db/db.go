@@ -26,6 +26,12 @@ func Init() *gorm.DB {
 		name,
 		port,
 	)
+	return Open(dsn)
+}
+
+// Open connects to the database at dsn, Init builds the DSN from DB_*
+// variables
+func Open(dsn string) *gorm.DB {
 	var err error
 	db, err = gorm.Open(postgres.New(postgres.Config{
 		DSN:                  dsn,
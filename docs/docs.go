@@ -25,14 +25,59 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/customer/increase_balance": {
+        "/customer/addresses": {
+            "get": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "List the address book, default addresses first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "example"
+                ],
+                "summary": "List buyer addresses",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer YourJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "shipping",
+                            "billing"
+                        ],
+                        "type": "string",
+                        "description": "Address type",
+                        "name": "type",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/forms.AddressResponse"
+                            }
+                        }
+                    }
+                }
+            },
             "post": {
                 "security": [
                     {
                         "JWT Key": []
                     }
                 ],
-                "description": "Increase custom wallet balance",
+                "description": "Add a shipping or billing address, the first address of each type becomes the default",
                 "consumes": [
                     "application/json"
                 ],
@@ -42,7 +87,7 @@ const docTemplate = `{
                 "tags": [
                     "example"
                 ],
-                "summary": "Topup customer wallet balance to purchase stuff",
+                "summary": "Add a buyer address",
                 "parameters": [
                     {
                         "type": "string",
@@ -52,28 +97,89 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "Increment balance by certain amount",
+                        "description": "Address",
                         "name": "data",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/forms.AddWalletBalanceInput"
+                            "$ref": "#/definitions/forms.AddressInput"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/forms.AddressResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Address book is full",
                         "schema": {
-                            "$ref": "#/definitions/forms.AddWalletBalanceResponse"
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Postal code does not match the country",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
                         }
                     }
                 }
             }
         },
-        "/customer/login": {
-            "post": {
-                "description": "Return JWT access and refresh pair, alongside user profile",
+        "/customer/addresses/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "example"
+                ],
+                "summary": "Get a buyer address",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer YourJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Address ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/forms.AddressResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No such address",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Replace every field of the address, setting is_default moves the default of its type to it",
                 "consumes": [
                     "application/json"
                 ],
@@ -83,15 +189,29 @@ const docTemplate = `{
                 "tags": [
                     "example"
                 ],
-                "summary": "BuyerLogin user",
+                "summary": "Replace a buyer address",
                 "parameters": [
                     {
-                        "description": "BuyerLogin input",
+                        "type": "string",
+                        "description": "Bearer YourJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Address ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Address",
                         "name": "data",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/forms.UserSignIn"
+                            "$ref": "#/definitions/forms.AddressInput"
                         }
                     }
                 ],
@@ -99,20 +219,71 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/forms.LoginResponse"
+                            "$ref": "#/definitions/forms.AddressResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No such address",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Postal code does not match the country",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Remove the address, when it was the default the most recent address of the same type takes over",
+                "tags": [
+                    "example"
+                ],
+                "summary": "Delete a buyer address",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer YourJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Address ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": ""
+                    },
+                    "404": {
+                        "description": "No such address",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
                         }
                     }
                 }
             }
         },
-        "/customer/profile": {
-            "get": {
+        "/customer/increase_balance": {
+            "post": {
                 "security": [
                     {
                         "JWT Key": []
                     }
                 ],
-                "description": "Get customer profile from Authorization JWT header",
+                "description": "Start a top-up payment, the wallet is credited once the payment provider confirms it",
                 "consumes": [
                     "application/json"
                 ],
@@ -122,7 +293,7 @@ const docTemplate = `{
                 "tags": [
                     "example"
                 ],
-                "summary": "Get Buyer BuyerProfile",
+                "summary": "Topup customer wallet balance to purchase stuff",
                 "parameters": [
                     {
                         "type": "string",
@@ -130,21 +301,60 @@ const docTemplate = `{
                         "name": "Authorization",
                         "in": "header",
                         "required": true
+                    },
+                    {
+                        "description": "Increment balance by certain amount",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/forms.AddWalletBalanceInput"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key to safely retry the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Device ID the service issued, large top-ups from new devices are challenged",
+                        "name": "X-Device-ID",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/forms.UserResponse"
+                            "$ref": "#/definitions/forms.TopupIntentResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Blocked or challenged by the risk rules. A challenge passes with a token from /customer/mfa/verify",
+                        "schema": {
+                            "$ref": "#/definitions/forms.RiskRejectionResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/forms.PolicyViolationResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "No payment provider is configured",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
                         }
                     }
                 }
             }
         },
-        "/customer/refresh_token": {
+        "/customer/login": {
             "post": {
-                "description": "Return JWT access token given refresh token",
+                "description": "Return JWT access and refresh pair, alongside user profile",
                 "consumes": [
                     "application/json"
                 ],
@@ -154,15 +364,15 @@ const docTemplate = `{
                 "tags": [
                     "example"
                 ],
-                "summary": "Refresh token handler",
+                "summary": "BuyerLogin user",
                 "parameters": [
                     {
-                        "description": "Receive refresh token",
+                        "description": "BuyerLogin input",
                         "name": "data",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/forms.RefreshTokenRequest"
+                            "$ref": "#/definitions/forms.UserSignIn"
                         }
                     }
                 ],
@@ -170,15 +380,15 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/forms.LoginResponse"
                         }
                     }
                 }
             }
         },
-        "/customer/register": {
+        "/customer/login/otp": {
             "post": {
-                "description": "Register buyer account",
+                "description": "Return JWT access and refresh pair, alongside user profile, for the code sent to the phone",
                 "consumes": [
                     "application/json"
                 ],
@@ -188,15 +398,15 @@ const docTemplate = `{
                 "tags": [
                     "example"
                 ],
-                "summary": "Register customer",
+                "summary": "BuyerLogin with phone",
                 "parameters": [
                     {
-                        "description": "Signup input",
+                        "description": "Phone number and code",
                         "name": "data",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/forms.UserSignUp"
+                            "$ref": "#/definitions/forms.PhoneOTPInput"
                         }
                     }
                 ],
@@ -206,13 +416,25 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/forms.LoginResponse"
                         }
+                    },
+                    "401": {
+                        "description": "Wrong or expired code",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many wrong codes, from this phone or this client",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
                     }
                 }
             }
         },
-        "/seller/login": {
+        "/customer/login/otp/request": {
             "post": {
-                "description": "Return JWT access and refresh pair, alongside user profile",
+                "description": "Text a 6-digit login code to a verified buyer phone. The response is the same whether or not the phone is registered",
                 "consumes": [
                     "application/json"
                 ],
@@ -222,36 +444,42 @@ const docTemplate = `{
                 "tags": [
                     "example"
                 ],
-                "summary": "SellerLogin user",
+                "summary": "Send buyer login code",
                 "parameters": [
                     {
-                        "description": "SellerLogin input",
+                        "description": "Phone number",
                         "name": "data",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/forms.UserSignIn"
+                            "$ref": "#/definitions/forms.PhoneInput"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "202": {
+                        "description": "Accepted",
                         "schema": {
-                            "$ref": "#/definitions/forms.LoginResponse"
+                            "$ref": "#/definitions/forms.OTPSentResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Not a valid phone number",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
                         }
                     }
                 }
             }
         },
-        "/seller/profile": {
-            "get": {
+        "/customer/mfa": {
+            "post": {
                 "security": [
                     {
                         "JWT Key": []
                     }
                 ],
-                "description": "Get seller profile from Authorization JWT header",
+                "description": "Text a 6-digit code to the verified buyer phone, confirming it gives an access token that passes risk challenges",
                 "consumes": [
                     "application/json"
                 ],
@@ -261,7 +489,7 @@ const docTemplate = `{
                 "tags": [
                     "example"
                 ],
-                "summary": "Get Seller SellerProfile",
+                "summary": "Send buyer second factor code",
                 "parameters": [
                     {
                         "type": "string",
@@ -272,18 +500,35 @@ const docTemplate = `{
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "202": {
+                        "description": "Accepted",
                         "schema": {
-                            "$ref": "#/definitions/forms.UserResponse"
+                            "$ref": "#/definitions/forms.OTPSentResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "No verified phone",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Code requested too recently, or too many codes today",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
                         }
                     }
                 }
             }
         },
-        "/seller/refresh_token": {
+        "/customer/mfa/verify": {
             "post": {
-                "description": "Return JWT access token given refresh token",
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Confirm the code sent to the buyer phone and return an access token with the mfa claim",
                 "consumes": [
                     "application/json"
                 ],
@@ -293,15 +538,22 @@ const docTemplate = `{
                 "tags": [
                     "example"
                 ],
-                "summary": "Refresh token handler",
+                "summary": "Confirm buyer second factor",
                 "parameters": [
                     {
-                        "description": "Receive refresh token",
+                        "type": "string",
+                        "description": "Bearer YourJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Code",
                         "name": "data",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/forms.RefreshTokenRequest"
+                            "$ref": "#/definitions/forms.MFAInput"
                         }
                     }
                 ],
@@ -309,15 +561,32 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/forms.MFATokenResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Wrong or expired code",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many wrong codes, from this phone or this client",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
                         }
                     }
                 }
             }
         },
-        "/seller/register": {
+        "/customer/phone": {
             "post": {
-                "description": "Register seller account",
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Text a 6-digit code to the phone, the buyer phone changes once the code is confirmed",
                 "consumes": [
                     "application/json"
                 ],
@@ -327,71 +596,4450 @@ const docTemplate = `{
                 "tags": [
                     "example"
                 ],
-                "summary": "Register customer",
+                "summary": "Send buyer phone verification code",
                 "parameters": [
                     {
-                        "description": "Signup input",
+                        "type": "string",
+                        "description": "Bearer YourJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Phone number",
                         "name": "data",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/forms.UserSignUp"
+                            "$ref": "#/definitions/forms.PhoneInput"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "202": {
+                        "description": "Accepted",
                         "schema": {
-                            "$ref": "#/definitions/forms.LoginResponse"
+                            "$ref": "#/definitions/forms.OTPSentResponse"
                         }
-                    }
+                    },
+                    "409": {
+                        "description": "Phone used by another account",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Not a valid phone number",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Code requested too recently, or too many codes today",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/customer/phone/verify": {
+            "post": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Confirm the code sent to the phone and make it the buyer phone",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "example"
+                ],
+                "summary": "Confirm buyer phone",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer YourJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Phone number and code",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/forms.PhoneOTPInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/forms.UserResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Wrong or expired code",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Phone used by another account",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many wrong codes, from this phone or this client",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/customer/preferences": {
+            "get": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Locale, timezone, display currency, marketing opt-ins and notification channels, defaults are returned until saved",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "example"
+                ],
+                "summary": "Get buyer preferences",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer YourJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/forms.PreferencesResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Replace every preference. Tokens issued afterwards carry the new locale, refresh the access token to pick it up",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "example"
+                ],
+                "summary": "Replace buyer preferences",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer YourJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Preferences",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/forms.PreferencesInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/forms.PreferencesResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unknown locale, timezone or currency, or security alerts turned off",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/customer/profile": {
+            "get": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Get customer profile from Authorization JWT header",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "example"
+                ],
+                "summary": "Get Buyer BuyerProfile",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer YourJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/forms.UserResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the profile, send it in If-Match to update"
+                            }
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Change some of the profile fields, send the ETag of the profile in If-Match. Tokens issued afterwards carry the new names",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "example"
+                ],
+                "summary": "Update Buyer BuyerProfile",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer YourJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag returned by the last profile read",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to change",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/forms.UpdateProfileInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/forms.UserResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Profile changed since it was read",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid or empty update",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header missing",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/customer/profile/avatar": {
+            "put": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Upload a JPEG, PNG, GIF or WebP avatar, it is cropped square and stored in several sizes without metadata",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "example"
+                ],
+                "summary": "Upload buyer avatar",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer YourJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Avatar image",
+                        "name": "image",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/forms.UserResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Image too large",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Not a supported image",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Delete the avatar and every stored size of it",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "example"
+                ],
+                "summary": "Remove buyer avatar",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer YourJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/forms.UserResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/customer/referral": {
+            "get": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Return the referral code to share and how many referrals are pending, rewarded or rejected",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "example"
+                ],
+                "summary": "Get buyer referral code",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer YourJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ReferralResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/customer/refresh_token": {
+            "post": {
+                "description": "Return JWT access token given refresh token",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "example"
+                ],
+                "summary": "Refresh token handler",
+                "parameters": [
+                    {
+                        "description": "Receive refresh token",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/forms.RefreshTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/customer/register": {
+            "post": {
+                "description": "Register buyer account",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "example"
+                ],
+                "summary": "Register customer",
+                "parameters": [
+                    {
+                        "description": "Signup input",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/forms.UserSignUp"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Device ID the service issued, a new one is returned when missing",
+                        "name": "X-Device-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/forms.LoginResponse"
+                        },
+                        "headers": {
+                            "X-Device-ID": {
+                                "type": "string",
+                                "description": "Newly issued device ID, send it on later requests"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/customer/wallet/statements": {
+            "get": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Monthly statement with opening balance, every transaction and closing balance, as CSV or PDF",
+                "produces": [
+                    "text/csv",
+                    "application/pdf"
+                ],
+                "tags": [
+                    "example"
+                ],
+                "summary": "Download a buyer wallet statement",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer YourJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Statement month (YYYY-MM)",
+                        "name": "month",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ISO 4217 currency code of the wallet",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "csv",
+                            "pdf"
+                        ],
+                        "type": "string",
+                        "description": "Document format, csv by default",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "Wallet not found",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/customer/wallet/transactions": {
+            "get": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "List wallet transactions newest first, use next_cursor to fetch the following page",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "example"
+                ],
+                "summary": "Get customer wallet transaction history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer YourJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor returned by the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "topup",
+                            "purchase",
+                            "refund",
+                            "promo_credit",
+                            "promo_expiry",
+                            "opening_balance"
+                        ],
+                        "type": "string",
+                        "description": "Transaction type",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ISO 4217 currency code of the wallet",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start date (YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date inclusive (YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/forms.WalletTransactionListResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/customer/wallets": {
+            "post": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Create an empty wallet in the given ISO 4217 currency, one wallet per currency",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "example"
+                ],
+                "summary": "Open a buyer wallet in another currency",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer YourJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Currency of the new wallet",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/forms.OpenWalletInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/forms.WalletResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Wallet already exists",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/payments/fake/{reference}": {
+            "post": {
+                "description": "Make the fake payment provider send a signed webhook for a pending top-up, only available when PAYMENT_PROVIDER is fake and PAYMENT_FAKE_ENABLED is true",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payment"
+                ],
+                "summary": "Simulate a payment with the fake provider",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider reference of the top-up",
+                        "name": "reference",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Payment outcome",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/forms.FakePaymentInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": ""
+                    }
+                }
+            }
+        },
+        "/payments/webhook": {
+            "post": {
+                "description": "Receive signed payment notifications and credit confirmed top-ups",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payment"
+                ],
+                "summary": "Payment provider webhook",
+                "responses": {
+                    "204": {
+                        "description": ""
+                    }
+                }
+            }
+        },
+        "/seller/login": {
+            "post": {
+                "description": "Return JWT access and refresh pair, alongside user profile",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "example"
+                ],
+                "summary": "SellerLogin user",
+                "parameters": [
+                    {
+                        "description": "SellerLogin input",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/forms.UserSignIn"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/forms.LoginResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/seller/login/otp": {
+            "post": {
+                "description": "Return JWT access and refresh pair, alongside user profile, for the code sent to the phone",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "example"
+                ],
+                "summary": "SellerLogin with phone",
+                "parameters": [
+                    {
+                        "description": "Phone number and code",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/forms.PhoneOTPInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/forms.LoginResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Wrong or expired code",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many wrong codes, from this phone or this client",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/seller/login/otp/request": {
+            "post": {
+                "description": "Text a 6-digit login code to a verified seller phone. The response is the same whether or not the phone is registered",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "example"
+                ],
+                "summary": "Send seller login code",
+                "parameters": [
+                    {
+                        "description": "Phone number",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/forms.PhoneInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/forms.OTPSentResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Not a valid phone number",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/seller/mfa": {
+            "post": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Text a 6-digit code to the verified seller phone, confirming it gives an access token that passes risk challenges",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "example"
+                ],
+                "summary": "Send seller second factor code",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer YourJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/forms.OTPSentResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "No verified phone",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Code requested too recently, or too many codes today",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/seller/mfa/verify": {
+            "post": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Confirm the code sent to the seller phone and return an access token with the mfa claim",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "example"
+                ],
+                "summary": "Confirm seller second factor",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer YourJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Code",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/forms.MFAInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/forms.MFATokenResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Wrong or expired code",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many wrong codes, from this phone or this client",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/seller/payout_destinations": {
+            "get": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "List the bank accounts the seller can withdraw to",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payout"
+                ],
+                "summary": "List payout destinations",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer YourJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/forms.PayoutDestinationResponse"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Add a bank account the seller can withdraw to",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payout"
+                ],
+                "summary": "Register a payout destination",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer YourJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Bank account",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/forms.PayoutDestinationInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/forms.PayoutDestinationResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/seller/payout_destinations/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Remove a bank account, past payouts keep their reference",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payout"
+                ],
+                "summary": "Remove a payout destination",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer YourJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Destination ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": ""
+                    }
+                }
+            }
+        },
+        "/seller/payouts": {
+            "get": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "List the seller payout requests newest first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payout"
+                ],
+                "summary": "List payout requests",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer YourJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/forms.PayoutRequestResponse"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Withdraw from the seller wallet, the amount is deducted immediately and returned if the payout fails. Only verified sellers can request payouts",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payout"
+                ],
+                "summary": "Request a payout",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer YourJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key to safely retry the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Device ID the service issued, large debits from new devices are challenged",
+                        "name": "X-Device-ID",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Destination and amount",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/forms.PayoutRequestInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/forms.PayoutRequestResponse"
+                        }
+                    },
+                    "402": {
+                        "description": "Insufficient funds",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Blocked or challenged by the risk rules, or seller not verified. A challenge passes with a token from /seller/mfa/verify",
+                        "schema": {
+                            "$ref": "#/definitions/forms.RiskRejectionResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Amount is not a valid money amount",
+                        "schema": {
+                            "$ref": "#/definitions/forms.PolicyViolationResponse"
+                        }
+                    },
+                    "423": {
+                        "description": "Wallet is frozen pending review",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/seller/phone": {
+            "post": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Text a 6-digit code to the phone, the seller phone changes once the code is confirmed",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "example"
+                ],
+                "summary": "Send seller phone verification code",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer YourJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Phone number",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/forms.PhoneInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/forms.OTPSentResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Phone used by another account",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Not a valid phone number",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Code requested too recently, or too many codes today",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/seller/phone/verify": {
+            "post": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Confirm the code sent to the phone and make it the seller phone",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "example"
+                ],
+                "summary": "Confirm seller phone",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer YourJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Phone number and code",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/forms.PhoneOTPInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/forms.UserResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Wrong or expired code",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Phone used by another account",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many wrong codes, from this phone or this client",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/seller/preferences": {
+            "get": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Locale, timezone, display currency, marketing opt-ins and notification channels, defaults are returned until saved",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "example"
+                ],
+                "summary": "Get seller preferences",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer YourJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/forms.PreferencesResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Replace every preference. Tokens issued afterwards carry the new locale, refresh the access token to pick it up",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "example"
+                ],
+                "summary": "Replace seller preferences",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer YourJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Preferences",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/forms.PreferencesInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/forms.PreferencesResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unknown locale, timezone or currency, or security alerts turned off",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/seller/profile": {
+            "get": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Get seller profile from Authorization JWT header",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "example"
+                ],
+                "summary": "Get Seller SellerProfile",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer YourJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/forms.UserResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the profile, send it in If-Match to update"
+                            }
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Change some of the profile and storefront fields, send the ETag of the profile in If-Match. Slugs are unique across sellers",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "example"
+                ],
+                "summary": "Update Seller SellerProfile",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer YourJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag returned by the last profile read",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to change",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/forms.UpdateSellerProfileInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/forms.UserResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Slug already taken",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Profile changed since it was read",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid or empty update",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header missing",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/seller/profile/{kind}": {
+            "put": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Upload a JPEG, PNG, GIF or WebP image, logos are cropped square and banners keep their aspect ratio. Every size is stored without metadata",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "example"
+                ],
+                "summary": "Upload seller logo or banner",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer YourJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "logo",
+                            "banner"
+                        ],
+                        "type": "string",
+                        "description": "Image kind",
+                        "name": "kind",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Image",
+                        "name": "image",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/forms.UserResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Image too large",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Not a supported image",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Delete the image and every stored size of it",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "example"
+                ],
+                "summary": "Remove seller logo or banner",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer YourJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "logo",
+                            "banner"
+                        ],
+                        "type": "string",
+                        "description": "Image kind",
+                        "name": "kind",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/forms.UserResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/seller/referral": {
+            "get": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Return the referral code to share and how many referrals are pending, rewarded or rejected",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "example"
+                ],
+                "summary": "Get seller referral code",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer YourJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ReferralResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/seller/refresh_token": {
+            "post": {
+                "description": "Return JWT access token given refresh token",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "example"
+                ],
+                "summary": "Refresh token handler",
+                "parameters": [
+                    {
+                        "description": "Receive refresh token",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/forms.RefreshTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/seller/register": {
+            "post": {
+                "description": "Register seller account",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "example"
+                ],
+                "summary": "Register customer",
+                "parameters": [
+                    {
+                        "description": "Signup input",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/forms.UserSignUp"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Device ID the service issued, a new one is returned when missing",
+                        "name": "X-Device-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/forms.LoginResponse"
+                        },
+                        "headers": {
+                            "X-Device-ID": {
+                                "type": "string",
+                                "description": "Newly issued device ID, send it on later requests"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/seller/verification": {
+            "get": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Latest verification submission of the seller and its review outcome",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payout"
+                ],
+                "summary": "Get seller verification",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer YourJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/forms.VerificationResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Nothing submitted yet",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Send the business details and documents for review. Documents are PDF, JPEG or PNG files sent in fields named after their type, business_registration is required. Verified sellers get the storefront badge and can request payouts",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payout"
+                ],
+                "summary": "Submit seller verification",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer YourJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Registered legal name",
+                        "name": "legal_name",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Business registration number",
+                        "name": "business_registration_number",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Tax ID",
+                        "name": "tax_id",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Business registration certificate",
+                        "name": "business_registration",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Identity document of the owner",
+                        "name": "identity",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "file",
+                        "description": "Tax registration certificate",
+                        "name": "tax_certificate",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/forms.VerificationResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Already verified or under review",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Document too large",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Not a PDF, JPEG or PNG file",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Required document missing",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/seller/wallet/statements": {
+            "get": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Monthly statement with opening balance, every transaction with its fee and commission and closing balance, as CSV or PDF",
+                "produces": [
+                    "text/csv",
+                    "application/pdf"
+                ],
+                "tags": [
+                    "example"
+                ],
+                "summary": "Download a seller wallet statement",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer YourJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Statement month (YYYY-MM)",
+                        "name": "month",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ISO 4217 currency code of the wallet",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "csv",
+                            "pdf"
+                        ],
+                        "type": "string",
+                        "description": "Document format, csv by default",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "Wallet not found",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/seller/wallet/transactions": {
+            "get": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "List wallet transactions newest first, use next_cursor to fetch the following page",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "example"
+                ],
+                "summary": "Get seller wallet transaction history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer YourJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor returned by the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "sale",
+                            "refund",
+                            "payout",
+                            "payout_reversal",
+                            "referral_reward",
+                            "opening_balance"
+                        ],
+                        "type": "string",
+                        "description": "Transaction type",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ISO 4217 currency code of the wallet",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start date (YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date inclusive (YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/forms.WalletTransactionListResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/seller/wallets": {
+            "post": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Create an empty wallet in the given ISO 4217 currency, one wallet per currency",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "example"
+                ],
+                "summary": "Open a seller wallet in another currency",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer YourJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Currency of the new wallet",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/forms.OpenWalletInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/forms.WalletResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Wallet already exists",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/sellers": {
+            "get": {
+                "description": "Search and filter the seller directory. Results are sorted by relevance when searching and newest first otherwise, use next_cursor to fetch the following page",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "example"
+                ],
+                "summary": "List sellers",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search display name, name and bio",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only verified or only unverified sellers",
+                        "name": "verified",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ISO 3166-1 alpha-2 country code",
+                        "name": "country",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Joined on or after, YYYY-MM-DD",
+                        "name": "joined_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Joined on or before, YYYY-MM-DD",
+                        "name": "joined_to",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "relevance",
+                            "newest"
+                        ],
+                        "type": "string",
+                        "description": "Ordering",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor returned by the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/forms.SellerDirectoryResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/sellers/{id}": {
+            "get": {
+                "description": "Public storefront of a seller looked up by ID or slug. Send the returned ETag in If-None-Match to get 304 when nothing changed",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "example"
+                ],
+                "summary": "Get seller storefront",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Seller ID or slug",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag returned by the last read",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/forms.SellerStorefrontResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the storefront"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified"
+                    },
+                    "404": {
+                        "description": "No such seller",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/service/commission_rates": {
+            "get": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "List the commission overrides per seller and category",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "wallet"
+                ],
+                "summary": "List commission rates",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer ServiceJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/forms.CommissionRateResponse"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Create or replace the commission rate for a seller, a category or both",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "wallet"
+                ],
+                "summary": "Set a commission rate",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer ServiceJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Scope and rate between 0 and 1",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/forms.CommissionRateInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/forms.CommissionRateResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/service/payouts": {
+            "get": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "List payout requests of every seller in a given state, pending by default",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payout"
+                ],
+                "summary": "List payout requests for review",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer ServiceJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "approved",
+                            "processing",
+                            "paid",
+                            "failed"
+                        ],
+                        "type": "string",
+                        "description": "Payout status",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/forms.PayoutRequestResponse"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/service/payouts/{id}/approve": {
+            "post": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Approve a pending payout, it is sent with the next payout batch",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payout"
+                ],
+                "summary": "Approve a payout request",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer ServiceJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Payout request ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/forms.PayoutRequestResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Payout is no longer pending",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/service/payouts/{id}/reject": {
+            "post": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Reject a pending payout and return the amount to the seller wallet",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payout"
+                ],
+                "summary": "Reject a payout request",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer ServiceJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Payout request ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Rejection reason",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/forms.PayoutRejectInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/forms.PayoutRequestResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Payout is no longer pending",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/service/risk/decisions": {
+            "get": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "List the latest decisions of the wallet risk rules for review, newest first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "wallet"
+                ],
+                "summary": "List risk decisions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer ServiceJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "allow",
+                            "challenge",
+                            "block"
+                        ],
+                        "type": "string",
+                        "description": "Outcome",
+                        "name": "decision",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Buyer or seller ID",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of decisions, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/forms.RiskDecisionResponse"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/service/verifications": {
+            "get": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "List verification submissions by status, oldest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payout"
+                ],
+                "summary": "List seller verifications",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer ServiceJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "approved",
+                            "rejected"
+                        ],
+                        "type": "string",
+                        "description": "Status, pending by default",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/forms.VerificationResponse"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/service/verifications/{seller_id}": {
+            "get": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Latest verification submission of a seller with its documents",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payout"
+                ],
+                "summary": "Get a seller verification for review",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer ServiceJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Seller ID",
+                        "name": "seller_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/forms.VerificationResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Nothing submitted",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/service/verifications/{seller_id}/approve": {
+            "post": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Verify the seller, which shows the storefront badge and allows payouts",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payout"
+                ],
+                "summary": "Approve a seller verification",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer ServiceJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Seller ID",
+                        "name": "seller_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/forms.VerificationResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Verification is not pending",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/service/verifications/{seller_id}/documents/{document_id}": {
+            "get": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Download a document of the latest verification submission of a seller",
+                "produces": [
+                    "application/pdf",
+                    "image/jpeg",
+                    "image/png"
+                ],
+                "tags": [
+                    "payout"
+                ],
+                "summary": "Download a verification document",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer ServiceJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Seller ID",
+                        "name": "seller_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Document ID",
+                        "name": "document_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "No such document",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/service/verifications/{seller_id}/reject": {
+            "post": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Reject the pending submission, the seller sees the reason and can submit again",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payout"
+                ],
+                "summary": "Reject a seller verification",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer ServiceJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Seller ID",
+                        "name": "seller_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Rejection reason",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/forms.VerificationRejectInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/forms.VerificationResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Verification is not pending",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/service/wallet/debit": {
+            "post": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Charge a buyer wallet, only available to service clients with the wallet:debit permission",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "wallet"
+                ],
+                "summary": "Debit buyer wallet for a purchase",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer ServiceJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key to safely retry the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Buyer, amount and order reference",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/forms.WalletDebitInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/forms.WalletDebitResponse"
+                        }
+                    },
+                    "402": {
+                        "description": "Insufficient funds",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Blocked or challenged by the risk rules. A challenge passes with the buyer token from /customer/mfa/verify as mfa_token",
+                        "schema": {
+                            "$ref": "#/definitions/forms.RiskRejectionResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Amount is not a valid money amount",
+                        "schema": {
+                            "$ref": "#/definitions/forms.PolicyViolationResponse"
+                        }
+                    },
+                    "423": {
+                        "description": "Wallet is frozen pending review",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/service/wallet/holds": {
+            "post": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Reserve part of the buyer available balance for an order until it is captured or voided",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "wallet"
+                ],
+                "summary": "Authorize an escrow hold on a buyer wallet",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer ServiceJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key to safely retry the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Buyer, seller, amount and order reference",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/forms.WalletHoldInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/forms.WalletHoldResponse"
+                        }
+                    },
+                    "402": {
+                        "description": "Insufficient funds",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Blocked or challenged by the risk rules. A challenge passes with the buyer token from /customer/mfa/verify as mfa_token",
+                        "schema": {
+                            "$ref": "#/definitions/forms.RiskRejectionResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Amount is not a valid money amount",
+                        "schema": {
+                            "$ref": "#/definitions/forms.PolicyViolationResponse"
+                        }
+                    },
+                    "423": {
+                        "description": "Wallet is frozen pending review",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/service/wallet/holds/{id}/capture": {
+            "post": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Charge the held amount to the buyer and credit the seller wallet minus the platform commission",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "wallet"
+                ],
+                "summary": "Capture an escrow hold",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer ServiceJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key to safely retry the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Hold ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/forms.WalletHoldResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Hold is no longer authorized",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    },
+                    "423": {
+                        "description": "Wallet is frozen pending review",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/service/wallet/holds/{id}/void": {
+            "post": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Release the held amount back to the buyer available balance",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "wallet"
+                ],
+                "summary": "Void an escrow hold",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer ServiceJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key to safely retry the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Hold ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/forms.WalletHoldResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Hold is no longer authorized",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/service/wallet/promo_credits": {
+            "post": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Add promo credit from a campaign to a buyer wallet, it is spent before cash, cannot be withdrawn and expires",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "wallet"
+                ],
+                "summary": "Grant promotional credit to a buyer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer ServiceJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key to safely retry the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Buyer, amount, campaign and expiry",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/forms.PromoCreditInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/forms.PromoCreditResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/forms.PolicyViolationResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/service/wallet/refunds": {
+            "post": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Return all or part of a purchase to the buyer and reverse the seller share and commission",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "wallet"
+                ],
+                "summary": "Refund a purchase",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer ServiceJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key to safely retry the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Purchase transaction, amount and reference",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/forms.RefundInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/forms.RefundResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Refund exceeds the amount charged, or is not a valid money amount",
+                        "schema": {
+                            "$ref": "#/definitions/forms.PolicyViolationResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/service/wallet/settlements": {
+            "post": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Debit the buyer, credit the seller and book the platform commission in one transaction",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "wallet"
+                ],
+                "summary": "Settle a purchase from a buyer to a seller",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer ServiceJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key to safely retry the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Buyer, seller, amount, category and order reference",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/forms.SettlementInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/forms.SettlementResponse"
+                        }
+                    },
+                    "402": {
+                        "description": "Insufficient funds",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Blocked or challenged by the risk rules. A challenge passes with the buyer token from /customer/mfa/verify as mfa_token",
+                        "schema": {
+                            "$ref": "#/definitions/forms.RiskRejectionResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Amount is not a valid money amount",
+                        "schema": {
+                            "$ref": "#/definitions/forms.PolicyViolationResponse"
+                        }
+                    },
+                    "423": {
+                        "description": "Wallet is frozen pending review",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/service/wallets/frozen": {
+            "get": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "List wallets frozen by reconciliation because their balance did not match the ledger",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "wallet"
+                ],
+                "summary": "List frozen wallets",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer ServiceJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/forms.FrozenWalletResponse"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/service/wallets/unfreeze": {
+            "post": {
+                "security": [
+                    {
+                        "JWT Key": []
+                    }
+                ],
+                "description": "Lift a reconciliation freeze once the wallet has been reviewed",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "wallet"
+                ],
+                "summary": "Unfreeze a wallet",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer ServiceJWTToken",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Wallet owner and currency",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/forms.UnfreezeWalletInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": ""
+                    },
+                    "404": {
+                        "description": "Wallet not found",
+                        "schema": {
+                            "$ref": "#/definitions/forms.ProblemResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "forms.AddWalletBalanceInput": {
+            "type": "object",
+            "required": [
+                "add_balance"
+            ],
+            "properties": {
+                "add_balance": {
+                    "type": "number"
+                },
+                "currency": {
+                    "description": "ISO 4217 code of the wallet to top up, defaults to THB",
+                    "type": "string"
+                }
+            }
+        },
+        "forms.AddressInput": {
+            "type": "object",
+            "required": [
+                "city",
+                "country",
+                "line1",
+                "recipient_name",
+                "type"
+            ],
+            "properties": {
+                "city": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "country": {
+                    "description": "ISO 3166-1 alpha-2 code",
+                    "type": "string"
+                },
+                "is_default": {
+                    "type": "boolean"
+                },
+                "label": {
+                    "description": "Name shown in the address picker, like Home or Office",
+                    "type": "string",
+                    "maxLength": 50
+                },
+                "line1": {
+                    "type": "string",
+                    "maxLength": 200
+                },
+                "line2": {
+                    "type": "string",
+                    "maxLength": 200
+                },
+                "phone": {
+                    "type": "string",
+                    "maxLength": 30
+                },
+                "postal_code": {
+                    "description": "Checked against the format of the country, empty where there are none",
+                    "type": "string",
+                    "maxLength": 12
+                },
+                "recipient_name": {
+                    "type": "string",
+                    "maxLength": 200
+                },
+                "state": {
+                    "description": "State, province or region",
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "shipping",
+                        "billing"
+                    ]
+                }
+            }
+        },
+        "forms.AddressResponse": {
+            "type": "object",
+            "properties": {
+                "city": {
+                    "type": "string"
+                },
+                "country": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "is_default": {
+                    "type": "boolean"
+                },
+                "label": {
+                    "type": "string"
+                },
+                "line1": {
+                    "type": "string"
+                },
+                "line2": {
+                    "type": "string"
+                },
+                "phone": {
+                    "type": "string"
+                },
+                "postal_code": {
+                    "type": "string"
+                },
+                "recipient_name": {
+                    "type": "string"
+                },
+                "state": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "forms.CommissionRateInput": {
+            "type": "object",
+            "required": [
+                "rate"
+            ],
+            "properties": {
+                "category": {
+                    "description": "Leave empty to apply the rate to every category",
+                    "type": "string"
+                },
+                "rate": {
+                    "type": "number"
+                },
+                "seller_id": {
+                    "description": "Leave empty to apply the rate to every seller",
+                    "type": "string"
+                }
+            }
+        },
+        "forms.CommissionRateResponse": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "rate": {
+                    "type": "number"
+                },
+                "seller_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "forms.FakePaymentInput": {
+            "type": "object",
+            "required": [
+                "status"
+            ],
+            "properties": {
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "succeeded",
+                        "failed"
+                    ]
+                }
+            }
+        },
+        "forms.FrozenWalletResponse": {
+            "type": "object",
+            "properties": {
+                "balance": {
+                    "type": "number"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "frozen_at": {
+                    "type": "string"
+                },
+                "frozen_reason": {
+                    "type": "string"
+                },
+                "group": {
+                    "description": "customer or seller",
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "forms.LoginResponse": {
+            "type": "object",
+            "properties": {
+                "access_token": {
+                    "type": "string"
+                },
+                "refresh_token": {
+                    "type": "string"
+                },
+                "user": {
+                    "$ref": "#/definitions/forms.UserResponse"
+                }
+            }
+        },
+        "forms.MFAInput": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                }
+            }
+        },
+        "forms.MFATokenResponse": {
+            "type": "object",
+            "properties": {
+                "access_token": {
+                    "description": "Access token carrying the mfa claim, it lets challenged wallet\noperations through until it expires",
+                    "type": "string"
+                }
+            }
+        },
+        "forms.MarketingOptIns": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "boolean"
+                },
+                "sms": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "forms.OTPSentResponse": {
+            "type": "object",
+            "properties": {
+                "expires_in": {
+                    "description": "Seconds the code stays valid",
+                    "type": "integer"
+                },
+                "phone": {
+                    "description": "Normalized E.164 number the code was sent to",
+                    "type": "string"
+                }
+            }
+        },
+        "forms.OpenWalletInput": {
+            "type": "object",
+            "required": [
+                "currency"
+            ],
+            "properties": {
+                "currency": {
+                    "description": "ISO 4217 currency code",
+                    "type": "string"
+                }
+            }
+        },
+        "forms.PayoutDestinationInput": {
+            "type": "object",
+            "required": [
+                "account_name",
+                "account_number",
+                "bank_code"
+            ],
+            "properties": {
+                "account_name": {
+                    "type": "string"
+                },
+                "account_number": {
+                    "description": "IBAN or local bank account number",
+                    "type": "string"
+                },
+                "bank_code": {
+                    "description": "BIC / SWIFT code of the receiving bank",
+                    "type": "string"
+                }
+            }
+        },
+        "forms.PayoutDestinationResponse": {
+            "type": "object",
+            "properties": {
+                "account_name": {
+                    "type": "string"
+                },
+                "account_number": {
+                    "type": "string"
+                },
+                "bank_code": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "forms.PayoutRejectInput": {
+            "type": "object",
+            "required": [
+                "reason"
+            ],
+            "properties": {
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "forms.PayoutRequestInput": {
+            "type": "object",
+            "required": [
+                "amount",
+                "destination_id"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "currency": {
+                    "description": "ISO 4217 code of the seller wallet to withdraw from, defaults to THB",
+                    "type": "string"
+                },
+                "destination_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "forms.PayoutRequestResponse": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "destination_id": {
+                    "type": "integer"
+                },
+                "failure_reason": {
+                    "type": "string"
+                },
+                "fee": {
+                    "type": "number"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "net_amount": {
+                    "type": "number"
+                },
+                "seller_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "forms.PhoneInput": {
+            "type": "object",
+            "required": [
+                "phone"
+            ],
+            "properties": {
+                "phone": {
+                    "description": "E.164, or national format for the default country",
+                    "type": "string",
+                    "maxLength": 30
+                }
+            }
+        },
+        "forms.PhoneOTPInput": {
+            "type": "object",
+            "required": [
+                "code",
+                "phone"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "phone": {
+                    "type": "string",
+                    "maxLength": 30
+                }
+            }
+        },
+        "forms.PolicyViolationResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "Stable code from the error catalog, like insufficient_funds",
+                    "type": "string"
+                },
+                "fields": {
+                    "description": "Message of every field that failed validation, keyed by its json name",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "instance": {
+                    "description": "Path of the request that failed",
+                    "type": "string"
+                },
+                "limit": {
+                    "type": "number"
+                },
+                "status": {
+                    "type": "integer"
+                },
+                "title": {
+                    "description": "Summary of the problem type in the locale of the request",
+                    "type": "string"
+                },
+                "trace_id": {
+                    "description": "Quote it to support to find the request in the logs",
+                    "type": "string"
+                },
+                "type": {
+                    "description": "URI of the problem type, urn:problem:user-service:\u003ccode\u003e",
+                    "type": "string"
+                }
+            }
+        },
+        "forms.PreferencesInput": {
+            "type": "object",
+            "required": [
+                "currency",
+                "locale",
+                "timezone"
+            ],
+            "properties": {
+                "currency": {
+                    "description": "ISO 4217 code amounts are displayed in",
+                    "type": "string"
+                },
+                "locale": {
+                    "type": "string",
+                    "enum": [
+                        "en",
+                        "th"
+                    ]
+                },
+                "marketing_opt_ins": {
+                    "$ref": "#/definitions/forms.MarketingOptIns"
+                },
+                "notifications": {
+                    "description": "Event to channel to enabled, events and channels left out get their default",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "object",
+                        "additionalProperties": {
+                            "type": "boolean"
+                        }
+                    }
+                },
+                "timezone": {
+                    "description": "IANA timezone name, like Asia/Bangkok",
+                    "type": "string",
+                    "maxLength": 64
+                }
+            }
+        },
+        "forms.PreferencesResponse": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string"
+                },
+                "locale": {
+                    "type": "string"
+                },
+                "marketing_opt_ins": {
+                    "$ref": "#/definitions/forms.MarketingOptIns"
+                },
+                "notifications": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "object",
+                        "additionalProperties": {
+                            "type": "boolean"
+                        }
+                    }
+                },
+                "timezone": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "forms.ProblemResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "Stable code from the error catalog, like insufficient_funds",
+                    "type": "string"
+                },
+                "fields": {
+                    "description": "Message of every field that failed validation, keyed by its json name",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "instance": {
+                    "description": "Path of the request that failed",
+                    "type": "string"
+                },
+                "status": {
+                    "type": "integer"
+                },
+                "title": {
+                    "description": "Summary of the problem type in the locale of the request",
+                    "type": "string"
+                },
+                "trace_id": {
+                    "description": "Quote it to support to find the request in the logs",
+                    "type": "string"
+                },
+                "type": {
+                    "description": "URI of the problem type, urn:problem:user-service:\u003ccode\u003e",
+                    "type": "string"
+                }
+            }
+        },
+        "forms.PromoCreditInput": {
+            "type": "object",
+            "required": [
+                "amount",
+                "buyer_id",
+                "campaign",
+                "expires_at"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "buyer_id": {
+                    "type": "string"
+                },
+                "campaign": {
+                    "type": "string"
+                },
+                "currency": {
+                    "description": "ISO 4217 code of the buyer wallet to credit, defaults to THB",
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "forms.PromoCreditResponse": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "buyer_id": {
+                    "type": "string"
+                },
+                "campaign": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "remaining": {
+                    "type": "number"
+                }
+            }
+        },
+        "forms.ReferralResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "pending": {
+                    "type": "integer"
+                },
+                "rejected": {
+                    "type": "integer"
+                },
+                "rewarded": {
+                    "type": "integer"
+                }
+            }
+        },
+        "forms.RefreshTokenRequest": {
+            "type": "object",
+            "required": [
+                "refresh_token"
+            ],
+            "properties": {
+                "refresh_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "forms.RefundInput": {
+            "type": "object",
+            "required": [
+                "buyer_id",
+                "reference",
+                "transaction_id"
+            ],
+            "properties": {
+                "amount": {
+                    "description": "Leave empty to refund everything not refunded yet",
+                    "type": "number"
+                },
+                "buyer_id": {
+                    "type": "string"
+                },
+                "reference": {
+                    "type": "string"
+                },
+                "transaction_id": {
+                    "description": "ID of the buyer purchase transaction being refunded",
+                    "type": "integer"
+                }
+            }
+        },
+        "forms.RefundResponse": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "buyer_transaction_id": {
+                    "type": "integer"
+                },
+                "commission": {
+                    "type": "number"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "new_buyer_balance": {
+                    "type": "number"
+                },
+                "promo_amount": {
+                    "type": "number"
+                },
+                "seller_amount": {
+                    "type": "number"
+                },
+                "seller_currency": {
+                    "type": "string"
+                },
+                "seller_transaction_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "forms.RiskDecisionResponse": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "decision": {
+                    "type": "string"
+                },
+                "device_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "mfa_verified": {
+                    "type": "boolean"
+                },
+                "operation": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "rules": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "user_group": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "forms.RiskRejectionResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "Stable code from the error catalog, like insufficient_funds",
+                    "type": "string"
+                },
+                "decision_id": {
+                    "type": "integer"
+                },
+                "fields": {
+                    "description": "Message of every field that failed validation, keyed by its json name",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "instance": {
+                    "description": "Path of the request that failed",
+                    "type": "string"
+                },
+                "rules": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "status": {
+                    "type": "integer"
+                },
+                "title": {
+                    "description": "Summary of the problem type in the locale of the request",
+                    "type": "string"
+                },
+                "trace_id": {
+                    "description": "Quote it to support to find the request in the logs",
+                    "type": "string"
+                },
+                "type": {
+                    "description": "URI of the problem type, urn:problem:user-service:\u003ccode\u003e",
+                    "type": "string"
+                }
+            }
+        },
+        "forms.SellerDirectoryResponse": {
+            "type": "object",
+            "properties": {
+                "next_cursor": {
+                    "type": "string"
+                },
+                "sellers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/forms.SellerSummaryResponse"
+                    }
+                }
+            }
+        },
+        "forms.SellerStorefrontResponse": {
+            "type": "object",
+            "properties": {
+                "banner": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "bio": {
+                    "type": "string"
+                },
+                "country": {
+                    "type": "string"
+                },
+                "display_name": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "joined_at": {
+                    "type": "string"
+                },
+                "logo": {
+                    "description": "Image URLs keyed by variant: small, medium and large",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "slug": {
+                    "type": "string"
+                },
+                "social_links": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/forms.SocialLinkResponse"
+                    }
+                },
+                "verified": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "forms.SellerSummaryResponse": {
+            "type": "object",
+            "properties": {
+                "bio": {
+                    "type": "string"
+                },
+                "country": {
+                    "type": "string"
+                },
+                "display_name": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "joined_at": {
+                    "type": "string"
+                },
+                "logo": {
+                    "description": "Image URLs keyed by variant: small, medium and large",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "slug": {
+                    "type": "string"
+                },
+                "verified": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "forms.SettlementInput": {
+            "type": "object",
+            "required": [
+                "amount",
+                "buyer_id",
+                "reference",
+                "seller_id"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "buyer_id": {
+                    "type": "string"
+                },
+                "category": {
+                    "type": "string"
+                },
+                "currency": {
+                    "description": "ISO 4217 code of the buyer wallet to charge, defaults to THB",
+                    "type": "string"
+                },
+                "device_id": {
+                    "description": "Device of the buyer, forwarded for the risk rules. After a challenge\nMFAToken is the access token the buyer got from /customer/mfa/verify,\nit proves the buyer passed the second factor",
+                    "type": "string"
+                },
+                "mfa_token": {
+                    "type": "string"
+                },
+                "reference": {
+                    "type": "string"
+                },
+                "seller_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "forms.SettlementResponse": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "buyer_id": {
+                    "type": "string"
+                },
+                "category": {
+                    "type": "string"
+                },
+                "commission": {
+                    "type": "number"
+                },
+                "commission_rate": {
+                    "type": "number"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "exchange_rate": {
+                    "type": "number"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "promo_amount": {
+                    "type": "number"
+                },
+                "rate_as_of": {
+                    "type": "string"
+                },
+                "reference": {
+                    "type": "string"
+                },
+                "seller_amount": {
+                    "type": "number"
+                },
+                "seller_credit_amount": {
+                    "type": "number"
+                },
+                "seller_currency": {
+                    "description": "What the seller wallet was credited, in the seller wallet currency",
+                    "type": "string"
+                },
+                "seller_id": {
+                    "type": "string"
                 }
             }
-        }
-    },
-    "definitions": {
-        "forms.AddWalletBalanceInput": {
+        },
+        "forms.SocialLinkInput": {
             "type": "object",
             "required": [
-                "add_balance"
+                "platform",
+                "url"
             ],
             "properties": {
-                "add_balance": {
-                    "type": "integer"
+                "platform": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
                 }
             }
         },
-        "forms.AddWalletBalanceResponse": {
+        "forms.SocialLinkResponse": {
             "type": "object",
             "properties": {
-                "new_balance": {
-                    "type": "integer"
+                "platform": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
                 }
             }
         },
-        "forms.LoginResponse": {
+        "forms.TopupIntentResponse": {
             "type": "object",
             "properties": {
-                "access_token": {
+                "amount": {
+                    "type": "number"
+                },
+                "checkout_url": {
                     "type": "string"
                 },
-                "refresh_token": {
+                "currency": {
                     "type": "string"
                 },
-                "user": {
-                    "$ref": "#/definitions/forms.UserResponse"
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
                 }
             }
         },
-        "forms.RefreshTokenRequest": {
+        "forms.UnfreezeWalletInput": {
             "type": "object",
             "required": [
-                "refresh_token"
+                "currency",
+                "group",
+                "user_id"
             ],
             "properties": {
-                "refresh_token": {
+                "currency": {
+                    "type": "string"
+                },
+                "group": {
+                    "type": "string",
+                    "enum": [
+                        "customer",
+                        "seller"
+                    ]
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "forms.UpdateProfileInput": {
+            "type": "object",
+            "properties": {
+                "first_name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "last_name": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "forms.UpdateSellerProfileInput": {
+            "type": "object",
+            "properties": {
+                "bio": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "country": {
                     "type": "string"
+                },
+                "display_name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "first_name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "last_name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "slug": {
+                    "type": "string",
+                    "maxLength": 40
+                },
+                "social_links": {
+                    "type": "array",
+                    "maxItems": 10,
+                    "items": {
+                        "$ref": "#/definitions/forms.SocialLinkInput"
+                    }
                 }
             }
         },
@@ -406,11 +5054,56 @@ const docTemplate = `{
         "forms.UserProfileResponse": {
             "type": "object",
             "properties": {
+                "avatar": {
+                    "description": "Image URLs keyed by variant: small, medium and large",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "banner": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "bio": {
+                    "type": "string"
+                },
+                "country": {
+                    "type": "string"
+                },
+                "display_name": {
+                    "description": "Storefront fields, sellers only",
+                    "type": "string"
+                },
                 "first_name": {
                     "type": "string"
                 },
                 "last_name": {
                     "type": "string"
+                },
+                "logo": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "slug": {
+                    "type": "string"
+                },
+                "social_links": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/forms.SocialLinkResponse"
+                    }
+                },
+                "verification_status": {
+                    "description": "One of unverified, pending, approved or rejected",
+                    "type": "string"
+                },
+                "verified": {
+                    "type": "boolean"
                 }
             }
         },
@@ -420,17 +5113,38 @@ const docTemplate = `{
                 "group": {
                     "$ref": "#/definitions/forms.UserGroupResponse"
                 },
+                "held_balance": {
+                    "type": "number"
+                },
                 "id": {
-                    "type": "integer"
+                    "type": "string"
+                },
+                "phone": {
+                    "description": "Verified phone in E.164 format",
+                    "type": "string"
                 },
                 "profile": {
                     "$ref": "#/definitions/forms.UserProfileResponse"
                 },
+                "promo_balance": {
+                    "type": "number"
+                },
+                "referral_code": {
+                    "description": "Code other users can sign up with",
+                    "type": "string"
+                },
                 "username": {
                     "type": "string"
                 },
                 "wallet_balance": {
-                    "type": "integer"
+                    "description": "Balances of the default currency wallet",
+                    "type": "number"
+                },
+                "wallets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/forms.WalletResponse"
+                    }
                 }
             }
         },
@@ -467,10 +5181,268 @@ const docTemplate = `{
                 "password": {
                     "type": "string"
                 },
+                "referral_code": {
+                    "description": "Optional code of the buyer or seller who referred the new account",
+                    "type": "string"
+                },
                 "username": {
                     "type": "string"
                 }
             }
+        },
+        "forms.VerificationDocumentResponse": {
+            "type": "object",
+            "properties": {
+                "content_type": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "filename": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "forms.VerificationRejectInput": {
+            "type": "object",
+            "required": [
+                "reason"
+            ],
+            "properties": {
+                "reason": {
+                    "description": "Shown to the seller",
+                    "type": "string",
+                    "maxLength": 500
+                }
+            }
+        },
+        "forms.VerificationResponse": {
+            "type": "object",
+            "properties": {
+                "business_registration_number": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "documents": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/forms.VerificationDocumentResponse"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "legal_name": {
+                    "type": "string"
+                },
+                "rejection_reason": {
+                    "type": "string"
+                },
+                "reviewed_at": {
+                    "type": "string"
+                },
+                "seller_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "tax_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "forms.WalletDebitInput": {
+            "type": "object",
+            "required": [
+                "amount",
+                "buyer_id",
+                "order_reference"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "buyer_id": {
+                    "type": "string"
+                },
+                "currency": {
+                    "description": "ISO 4217 code of the buyer wallet to charge, defaults to THB",
+                    "type": "string"
+                },
+                "device_id": {
+                    "description": "Device of the buyer, forwarded for the risk rules. After a challenge\nMFAToken is the access token the buyer got from /customer/mfa/verify,\nit proves the buyer passed the second factor",
+                    "type": "string"
+                },
+                "mfa_token": {
+                    "type": "string"
+                },
+                "order_reference": {
+                    "type": "string"
+                }
+            }
+        },
+        "forms.WalletDebitResponse": {
+            "type": "object",
+            "properties": {
+                "new_balance": {
+                    "type": "number"
+                },
+                "transaction_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "forms.WalletHoldInput": {
+            "type": "object",
+            "required": [
+                "amount",
+                "buyer_id",
+                "reference",
+                "seller_id"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "buyer_id": {
+                    "type": "string"
+                },
+                "category": {
+                    "type": "string"
+                },
+                "currency": {
+                    "description": "ISO 4217 code of the buyer wallet to charge, defaults to THB",
+                    "type": "string"
+                },
+                "device_id": {
+                    "description": "Device of the buyer, forwarded for the risk rules. After a challenge\nMFAToken is the access token the buyer got from /customer/mfa/verify,\nit proves the buyer passed the second factor",
+                    "type": "string"
+                },
+                "expires_in": {
+                    "description": "Optional hold lifetime in seconds, defaults to WALLET_HOLD_TTL",
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "mfa_token": {
+                    "type": "string"
+                },
+                "reference": {
+                    "type": "string"
+                },
+                "seller_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "forms.WalletHoldResponse": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "buyer_id": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "fee": {
+                    "type": "number"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "reference": {
+                    "type": "string"
+                },
+                "seller_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "forms.WalletResponse": {
+            "type": "object",
+            "properties": {
+                "balance": {
+                    "type": "number"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "held_balance": {
+                    "type": "number"
+                },
+                "promo_balance": {
+                    "type": "number"
+                }
+            }
+        },
+        "forms.WalletTransactionListResponse": {
+            "type": "object",
+            "properties": {
+                "next_cursor": {
+                    "type": "string"
+                },
+                "transactions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/forms.WalletTransactionResponse"
+                    }
+                }
+            }
+        },
+        "forms.WalletTransactionResponse": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "balance_after": {
+                    "type": "number"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "original_transaction_id": {
+                    "description": "Set on refunds, the transaction being reversed",
+                    "type": "integer"
+                },
+                "promo_amount": {
+                    "description": "Part of amount that was promo credit",
+                    "type": "number"
+                },
+                "reference": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
         }
     },
     "securityDefinitions": {
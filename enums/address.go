@@ -0,0 +1,7 @@
+package enums
+
+// Kinds of buyer address
+const (
+	AddressShipping = "shipping"
+	AddressBilling  = "billing"
+)
@@ -0,0 +1,20 @@
+package enums
+
+// DefaultCurrency is the currency of the wallet every account starts with
+const DefaultCurrency = "THB"
+
+// Currencies are the ISO 4217 codes a wallet may hold
+var Currencies = map[string]bool{
+	"AED": true, "ARS": true, "AUD": true, "BDT": true, "BND": true, "BRL": true,
+	"CAD": true, "CHF": true, "CLP": true, "CNY": true, "COP": true, "CZK": true,
+	"DKK": true, "EGP": true, "EUR": true, "GBP": true, "HKD": true, "HUF": true,
+	"IDR": true, "ILS": true, "INR": true, "JPY": true, "KHR": true, "KRW": true,
+	"LAK": true, "LKR": true, "MMK": true, "MXN": true, "MYR": true, "NOK": true,
+	"NZD": true, "PHP": true, "PKR": true, "PLN": true, "RON": true, "RUB": true,
+	"SAR": true, "SEK": true, "SGD": true, "THB": true, "TRY": true, "TWD": true,
+	"UAH": true, "USD": true, "VND": true, "ZAR": true,
+}
+
+func IsValidCurrency(currency string) bool {
+	return Currencies[currency]
+}
@@ -0,0 +1,7 @@
+package enums
+
+// Orderings of the seller directory
+const (
+	SellerSortRelevance = "relevance"
+	SellerSortNewest    = "newest"
+)
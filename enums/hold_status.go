@@ -0,0 +1,8 @@
+package enums
+
+const (
+	HoldAuthorized = "authorized"
+	HoldCaptured   = "captured"
+	HoldVoided     = "voided"
+	HoldExpired    = "expired"
+)
@@ -0,0 +1,8 @@
+package enums
+
+// Kinds of profile image
+const (
+	ImageAvatar = "avatar"
+	ImageLogo   = "logo"
+	ImageBanner = "banner"
+)
@@ -0,0 +1,9 @@
+package enums
+
+// What a one-time password was sent for
+const (
+	OTPPurposeVerifyPhone = "verify_phone"
+	OTPPurposeLogin       = "login"
+	// OTPPurposeMFA steps up a signed in session for risky wallet operations
+	OTPPurposeMFA = "mfa"
+)
@@ -0,0 +1,14 @@
+package enums
+
+const (
+	PayoutPending    = "pending"
+	PayoutApproved   = "approved"
+	PayoutProcessing = "processing"
+	PayoutPaid       = "paid"
+	PayoutFailed     = "failed"
+)
+
+const (
+	PayoutFormatCSV     = "csv"
+	PayoutFormatPain001 = "pain001"
+)
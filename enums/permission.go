@@ -0,0 +1,14 @@
+package enums
+
+// Permissions granted to internal service clients through the "permissions" claim
+const (
+	PermissionWalletDebit     = "wallet:debit"
+	PermissionWalletHold      = "wallet:hold"
+	PermissionWalletSettle    = "wallet:settle"
+	PermissionWalletRefund    = "wallet:refund"
+	PermissionCommissionWrite = "commission:write"
+	PermissionPayoutApprove   = "payout:approve"
+	PermissionPromoGrant      = "promo:grant"
+	PermissionWalletReview    = "wallet:review"
+	PermissionSellerVerify    = "seller:verify"
+)
@@ -0,0 +1,43 @@
+package enums
+
+// DefaultLocale and DefaultTimezone apply until a user saves preferences
+const (
+	DefaultLocale   = "th"
+	DefaultTimezone = "Asia/Bangkok"
+)
+
+// Locales are the languages the service has messages in
+var Locales = map[string]bool{
+	"en": true,
+	"th": true,
+}
+
+func IsValidLocale(locale string) bool {
+	return Locales[locale]
+}
+
+// Events users are notified about, each with its own channel settings
+const (
+	NotificationSecurityAlerts = "security_alerts"
+	NotificationPayouts        = "payouts"
+	NotificationPromotions     = "promotions"
+)
+
+var NotificationEvents = []string{
+	NotificationSecurityAlerts,
+	NotificationPayouts,
+	NotificationPromotions,
+}
+
+// Channels notifications are delivered through
+const (
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+	ChannelPush  = "push"
+)
+
+var NotificationChannels = []string{
+	ChannelEmail,
+	ChannelSMS,
+	ChannelPush,
+}
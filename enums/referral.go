@@ -0,0 +1,14 @@
+package enums
+
+const (
+	ReferralPending  = "pending"
+	ReferralRewarded = "rewarded"
+	ReferralRejected = "rejected"
+)
+
+// Reasons a referral is rejected by the fraud guards
+const (
+	ReferralSelfReferral  = "self_referral"
+	ReferralCapReached    = "referrer_cap_reached"
+	ReferralSimilarSignup = "similar_signup"
+)
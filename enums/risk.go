@@ -0,0 +1,42 @@
+package enums
+
+// Wallet operations evaluated by the risk rules
+const (
+	RiskOperationTopup      = "topup"
+	RiskOperationDebit      = "debit"
+	RiskOperationHold       = "hold"
+	RiskOperationSettlement = "settlement"
+	RiskOperationPayout     = "payout"
+)
+
+// Outcomes of a risk rule, ordered from least to most severe
+const (
+	RiskAllow     = "allow"
+	RiskChallenge = "challenge"
+	RiskBlock     = "block"
+)
+
+var riskSeverity = map[string]int{
+	RiskAllow:     0,
+	RiskChallenge: 1,
+	RiskBlock:     2,
+}
+
+func IsValidRiskAction(action string) bool {
+	_, ok := riskSeverity[action]
+	return ok
+}
+
+// MoreSevereRisk returns whichever of two outcomes is more severe
+func MoreSevereRisk(a string, b string) string {
+	if riskSeverity[b] > riskSeverity[a] {
+		return b
+	}
+	return a
+}
+
+const (
+	RiskRuleTopupVelocity  = "topup_velocity"
+	RiskRuleAmountSpike    = "amount_spike"
+	RiskRuleNewDeviceDebit = "new_device_large_debit"
+)
@@ -0,0 +1,16 @@
+package enums
+
+// Platforms a seller can link from the storefront
+var SocialPlatforms = map[string]bool{
+	"website":   true,
+	"facebook":  true,
+	"instagram": true,
+	"x":         true,
+	"tiktok":    true,
+	"youtube":   true,
+	"line":      true,
+}
+
+func IsValidSocialPlatform(platform string) bool {
+	return SocialPlatforms[platform]
+}
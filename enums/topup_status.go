@@ -0,0 +1,8 @@
+package enums
+
+const (
+	TopupPending   = "pending"
+	TopupSucceeded = "succeeded"
+	TopupFailed    = "failed"
+	TopupExpired   = "expired"
+)
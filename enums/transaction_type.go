@@ -0,0 +1,53 @@
+package enums
+
+const (
+	TransactionTopup    = "topup"
+	TransactionPurchase = "purchase"
+	TransactionRefund   = "refund"
+	TransactionPayout   = "payout"
+	TransactionSale     = "sale"
+	// TransactionPayoutReversal returns a rejected or failed payout to the
+	// seller wallet
+	TransactionPayoutReversal = "payout_reversal"
+	// Promotional credit granted to and expired from a buyer wallet
+	TransactionPromoCredit = "promo_credit"
+	TransactionPromoExpiry = "promo_expiry"
+	// TransactionReferralReward credits a seller for a successful referral
+	TransactionReferralReward = "referral_reward"
+	// TransactionOpeningBalance carries over the balance a wallet had before
+	// the ledger existed
+	TransactionOpeningBalance = "opening_balance"
+	// TransactionCommission is only booked on the platform revenue wallet
+	TransactionCommission = "commission"
+	// TransactionPromoFunding is only booked on the platform wallet, the
+	// platform pays sellers for the promo credit buyers spend
+	TransactionPromoFunding = "promo_funding"
+	// TransactionPayoutFee is only booked on the platform wallet, the fee kept
+	// from every payout and given back when the payout fails
+	TransactionPayoutFee = "payout_fee"
+	// TransactionExchange is only booked on the platform wallet, the platform
+	// takes the buyer currency and pays the seller in another one
+	TransactionExchange = "exchange"
+)
+
+var TransactionTypes = []string{
+	TransactionTopup,
+	TransactionPurchase,
+	TransactionRefund,
+	TransactionPayout,
+	TransactionSale,
+	TransactionPayoutReversal,
+	TransactionPromoCredit,
+	TransactionPromoExpiry,
+	TransactionReferralReward,
+	TransactionOpeningBalance,
+}
+
+func IsValidTransactionType(transactionType string) bool {
+	for _, t := range TransactionTypes {
+		if t == transactionType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,22 @@
+package enums
+
+// Seller verification statuses
+const (
+	VerificationUnverified = "unverified"
+	VerificationPending    = "pending"
+	VerificationApproved   = "approved"
+	VerificationRejected   = "rejected"
+)
+
+// Documents a seller attaches to a verification, each is a multipart field
+const (
+	DocumentIdentity             = "identity"
+	DocumentBusinessRegistration = "business_registration"
+	DocumentTaxCertificate       = "tax_certificate"
+)
+
+var VerificationDocumentTypes = []string{
+	DocumentIdentity,
+	DocumentBusinessRegistration,
+	DocumentTaxCertificate,
+}
@@ -0,0 +1,13 @@
+package enums
+
+// Error codes returned when a wallet operation violates the wallet policy
+const (
+	PolicyAmountNotPositive    = "amount_not_positive"
+	PolicyAmountPrecision      = "amount_precision"
+	PolicyAmountOverflow       = "amount_overflow"
+	PolicyTopupBelowMinimum    = "topup_below_minimum"
+	PolicyTopupAboveMaximum    = "topup_above_maximum"
+	PolicyDailyLimitExceeded   = "daily_topup_limit_exceeded"
+	PolicyMonthlyLimitExceeded = "monthly_topup_limit_exceeded"
+	PolicyBalanceLimitExceeded = "balance_limit_exceeded"
+)
@@ -0,0 +1,54 @@
+package errs
+
+import "errors"
+
+// Kinds of failure the service distinguishes, domain errors are built from
+// one of them with New or Wrap and answered with its HTTP status
+var (
+	ErrBadRequest           = errors.New("bad request")
+	ErrUnauthorized         = errors.New("unauthorized")
+	ErrPaymentRequired      = errors.New("payment required")
+	ErrForbidden            = errors.New("forbidden")
+	ErrNotFound             = errors.New("not found")
+	ErrConflict             = errors.New("conflict")
+	ErrPreconditionFailed   = errors.New("precondition failed")
+	ErrTooLarge             = errors.New("too large")
+	ErrUnsupportedMedia     = errors.New("unsupported media type")
+	ErrValidation           = errors.New("validation failed")
+	ErrLocked               = errors.New("locked")
+	ErrPreconditionRequired = errors.New("precondition required")
+	ErrTooManyRequests      = errors.New("too many requests")
+	ErrUpstream             = errors.New("upstream failure")
+	ErrUnavailable          = errors.New("unavailable")
+)
+
+// Error is a failure of a Kind. Code picks the message clients see from the
+// i18n catalog, Message and the wrapped Err are for logs only
+type Error struct {
+	Kind    error
+	Code    string
+	Message string
+	Err     error
+}
+
+func New(kind error, code string, message string) error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+// Wrap gives err a kind and a code, keeping it for errors.Is and errors.As
+func Wrap(kind error, code string, err error) error {
+	return &Error{Kind: kind, Code: code, Message: err.Error(), Err: err}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is makes errors.Is match the kind of the error as well as the error itself
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
@@ -0,0 +1,42 @@
+package forms
+
+import "time"
+
+type AddressInput struct {
+	Type string `json:"type" binding:"required,oneof=shipping billing"`
+	// Name shown in the address picker, like Home or Office
+	Label         string `json:"label" binding:"max=50"`
+	RecipientName string `json:"recipient_name" binding:"required,max=200"`
+	Phone         string `json:"phone" binding:"max=30"`
+	Line1         string `json:"line1" binding:"required,max=200"`
+	Line2         string `json:"line2" binding:"max=200"`
+	City          string `json:"city" binding:"required,max=100"`
+	// State, province or region
+	State string `json:"state" binding:"max=100"`
+	// Checked against the format of the country, empty where there are none
+	PostalCode string `json:"postal_code" binding:"max=12"`
+	// ISO 3166-1 alpha-2 code
+	Country   string `json:"country" binding:"required,iso3166_1_alpha2"`
+	IsDefault bool   `json:"is_default"`
+}
+
+type AddressQuery struct {
+	Type string `form:"type" binding:"omitempty,oneof=shipping billing"`
+}
+
+type AddressResponse struct {
+	ID            uint      `json:"id"`
+	Type          string    `json:"type"`
+	Label         string    `json:"label"`
+	RecipientName string    `json:"recipient_name"`
+	Phone         string    `json:"phone"`
+	Line1         string    `json:"line1"`
+	Line2         string    `json:"line2"`
+	City          string    `json:"city"`
+	State         string    `json:"state"`
+	PostalCode    string    `json:"postal_code"`
+	Country       string    `json:"country"`
+	IsDefault     bool      `json:"is_default"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
@@ -0,0 +1,19 @@
+package forms
+
+// ProblemResponse is an RFC 7807 problem detail, served as
+// application/problem+json
+type ProblemResponse struct {
+	// URI of the problem type, urn:problem:user-service:<code>
+	Type string `json:"type"`
+	// Summary of the problem type in the locale of the request
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	// Path of the request that failed
+	Instance string `json:"instance"`
+	// Stable code from the error catalog, like insufficient_funds
+	Code string `json:"code"`
+	// Quote it to support to find the request in the logs
+	TraceID string `json:"trace_id"`
+	// Message of every field that failed validation, keyed by its json name
+	Fields map[string]string `json:"fields,omitempty"`
+}
@@ -0,0 +1,5 @@
+package forms
+
+type FakePaymentInput struct {
+	Status string `json:"status" binding:"required,oneof=succeeded failed"`
+}
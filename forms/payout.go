@@ -0,0 +1,51 @@
+package forms
+
+import (
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+type PayoutDestinationInput struct {
+	AccountName string `json:"account_name" binding:"required"`
+	// IBAN or local bank account number
+	AccountNumber string `json:"account_number" binding:"required"`
+	// BIC / SWIFT code of the receiving bank
+	BankCode string `json:"bank_code" binding:"required"`
+}
+
+type PayoutDestinationResponse struct {
+	ID            uint   `json:"id"`
+	AccountName   string `json:"account_name"`
+	AccountNumber string `json:"account_number"`
+	BankCode      string `json:"bank_code"`
+}
+
+type PayoutRequestInput struct {
+	// ISO 4217 code of the seller wallet to withdraw from, defaults to THB
+	Currency      string          `json:"currency"`
+	DestinationID uint            `json:"destination_id" binding:"required"`
+	Amount        decimal.Decimal `json:"amount" binding:"required"`
+}
+
+type PayoutRejectInput struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+type PayoutStatusQuery struct {
+	Status string `form:"status"`
+}
+
+type PayoutRequestResponse struct {
+	ID            uint            `json:"id"`
+	SellerID      uuid.UUID       `json:"seller_id"`
+	DestinationID uint            `json:"destination_id"`
+	Currency      string          `json:"currency"`
+	Amount        decimal.Decimal `json:"amount"`
+	Fee           decimal.Decimal `json:"fee"`
+	NetAmount     decimal.Decimal `json:"net_amount"`
+	Status        string          `json:"status"`
+	FailureReason string          `json:"failure_reason,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
@@ -0,0 +1,28 @@
+package forms
+
+type PhoneInput struct {
+	// E.164, or national format for the default country
+	Phone string `json:"phone" binding:"required,max=30"`
+}
+
+type PhoneOTPInput struct {
+	Phone string `json:"phone" binding:"required,max=30"`
+	Code  string `json:"code" binding:"required,len=6,numeric"`
+}
+
+type OTPSentResponse struct {
+	// Normalized E.164 number the code was sent to
+	Phone string `json:"phone"`
+	// Seconds the code stays valid
+	ExpiresIn int `json:"expires_in"`
+}
+
+type MFAInput struct {
+	Code string `json:"code" binding:"required,len=6,numeric"`
+}
+
+type MFATokenResponse struct {
+	// Access token carrying the mfa claim, it lets challenged wallet
+	// operations through until it expires
+	Token string `json:"access_token"`
+}
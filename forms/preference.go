@@ -0,0 +1,28 @@
+package forms
+
+import "time"
+
+type MarketingOptIns struct {
+	Email bool `json:"email"`
+	SMS   bool `json:"sms"`
+}
+
+type PreferencesInput struct {
+	Locale string `json:"locale" binding:"required,oneof=en th"`
+	// IANA timezone name, like Asia/Bangkok
+	Timezone string `json:"timezone" binding:"required,max=64"`
+	// ISO 4217 code amounts are displayed in
+	Currency        string          `json:"currency" binding:"required,len=3"`
+	MarketingOptIns MarketingOptIns `json:"marketing_opt_ins"`
+	// Event to channel to enabled, events and channels left out get their default
+	Notifications map[string]map[string]bool `json:"notifications"`
+}
+
+type PreferencesResponse struct {
+	Locale          string                     `json:"locale"`
+	Timezone        string                     `json:"timezone"`
+	Currency        string                     `json:"currency"`
+	MarketingOptIns MarketingOptIns            `json:"marketing_opt_ins"`
+	Notifications   map[string]map[string]bool `json:"notifications"`
+	UpdatedAt       *time.Time                 `json:"updated_at"`
+}
@@ -3,6 +3,7 @@ package forms
 import (
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
+	"time"
 )
 
 type UserSignUp struct {
@@ -10,6 +11,8 @@ type UserSignUp struct {
 	Password  string `form:"password" json:"password" binding:"required"`
 	FirstName string `form:"first_name" json:"first_name" binding:"required"`
 	LastName  string `form:"last_name" json:"last_name" binding:"required"`
+	// Optional code of the buyer or seller who referred the new account
+	ReferralCode string `form:"referral_code" json:"referral_code"`
 }
 
 type UserSignIn struct {
@@ -23,15 +26,52 @@ type RefreshTokenRequest struct {
 
 type AddWalletBalanceInput struct {
 	AddBalance decimal.Decimal `json:"add_balance" binding:"required"`
+	// ISO 4217 code of the wallet to top up, defaults to THB
+	Currency string `json:"currency"`
 }
 
-type AddWalletBalanceResponse struct {
-	NewBalance decimal.Decimal `json:"new_balance"`
+type OpenWalletInput struct {
+	// ISO 4217 currency code
+	Currency string `json:"currency" binding:"required,len=3"`
+}
+
+type WalletResponse struct {
+	Currency     string          `json:"currency"`
+	Balance      decimal.Decimal `json:"balance"`
+	HeldBalance  decimal.Decimal `json:"held_balance"`
+	PromoBalance decimal.Decimal `json:"promo_balance"`
+}
+
+type TopupIntentResponse struct {
+	ID          uint            `json:"id"`
+	Currency    string          `json:"currency"`
+	Amount      decimal.Decimal `json:"amount"`
+	Status      string          `json:"status"`
+	CheckoutURL string          `json:"checkout_url"`
+	ExpiresAt   time.Time       `json:"expires_at"`
 }
 
 type UserProfileResponse struct {
 	FirstName string `json:"first_name"`
 	LastName  string `json:"last_name"`
+	// Image URLs keyed by variant: small, medium and large
+	Avatar map[string]string `json:"avatar,omitempty"`
+	Logo   map[string]string `json:"logo,omitempty"`
+	Banner map[string]string `json:"banner,omitempty"`
+	// Storefront fields, sellers only
+	DisplayName string `json:"display_name,omitempty"`
+	Bio         string `json:"bio,omitempty"`
+	Slug        string `json:"slug,omitempty"`
+	Verified    bool   `json:"verified,omitempty"`
+	// One of unverified, pending, approved or rejected
+	VerificationStatus string               `json:"verification_status,omitempty"`
+	Country            string               `json:"country,omitempty"`
+	SocialLinks        []SocialLinkResponse `json:"social_links,omitempty"`
+}
+
+type SocialLinkResponse struct {
+	Platform string `json:"platform"`
+	URL      string `json:"url"`
 }
 
 type UserGroupResponse struct {
@@ -39,11 +79,19 @@ type UserGroupResponse struct {
 }
 
 type UserResponse struct {
-	ID            uuid.UUID           `json:"id"`
-	Username      string              `json:"username"`
-	Profile       UserProfileResponse `json:"profile"`
-	Group         UserGroupResponse   `json:"group"`
-	WalletBalance decimal.Decimal     `json:"wallet_balance"`
+	ID       uuid.UUID           `json:"id"`
+	Username string              `json:"username"`
+	Profile  UserProfileResponse `json:"profile"`
+	Group    UserGroupResponse   `json:"group"`
+	// Code other users can sign up with
+	ReferralCode string `json:"referral_code,omitempty"`
+	// Verified phone in E.164 format
+	Phone string `json:"phone,omitempty"`
+	// Balances of the default currency wallet
+	WalletBalance decimal.Decimal  `json:"wallet_balance"`
+	HeldBalance   decimal.Decimal  `json:"held_balance"`
+	PromoBalance  decimal.Decimal  `json:"promo_balance"`
+	Wallets       []WalletResponse `json:"wallets"`
 }
 
 type LoginResponse struct {
@@ -51,3 +99,115 @@ type LoginResponse struct {
 	Token   string       `json:"access_token"`
 	Refresh string       `json:"refresh_token"`
 }
+
+type WalletTransactionQuery struct {
+	Cursor   string    `form:"cursor"`
+	Limit    int       `form:"limit" binding:"omitempty,min=1,max=100"`
+	Type     string    `form:"type"`
+	Currency string    `form:"currency"`
+	From     time.Time `form:"from" time_format:"2006-01-02"`
+	To       time.Time `form:"to" time_format:"2006-01-02"`
+}
+
+type WalletTransactionResponse struct {
+	ID       uint            `json:"id"`
+	Type     string          `json:"type"`
+	Currency string          `json:"currency"`
+	Amount   decimal.Decimal `json:"amount"`
+	// Part of amount that was promo credit
+	PromoAmount  decimal.Decimal `json:"promo_amount"`
+	BalanceAfter decimal.Decimal `json:"balance_after"`
+	Reference    string          `json:"reference"`
+	CreatedAt    time.Time       `json:"created_at"`
+	// Set on refunds, the transaction being reversed
+	OriginalTransactionID *uint `json:"original_transaction_id,omitempty"`
+}
+
+type WalletTransactionListResponse struct {
+	Transactions []WalletTransactionResponse `json:"transactions"`
+	NextCursor   string                      `json:"next_cursor"`
+}
+
+type ReferralResponse struct {
+	Code     string `json:"code"`
+	Pending  int64  `json:"pending"`
+	Rewarded int64  `json:"rewarded"`
+	Rejected int64  `json:"rejected"`
+}
+
+type WalletStatementQuery struct {
+	// Month in YYYY-MM format
+	Month string `form:"month" binding:"required"`
+	// ISO 4217 code of the wallet, defaults to THB
+	Currency string `form:"currency"`
+	Format   string `form:"format" binding:"omitempty,oneof=csv pdf"`
+}
+
+// UpdateProfileInput is a partial update, omitted fields keep their value
+type UpdateProfileInput struct {
+	FirstName *string `json:"first_name" binding:"omitempty,max=100"`
+	LastName  *string `json:"last_name" binding:"omitempty,max=100"`
+}
+
+type SocialLinkInput struct {
+	Platform string `json:"platform" binding:"required"`
+	URL      string `json:"url" binding:"required,url"`
+}
+
+// UpdateSellerProfileInput is a partial update, omitted fields keep their
+// value. An empty display name or slug clears it and social_links replaces
+// every link
+type UpdateSellerProfileInput struct {
+	UpdateProfileInput
+	DisplayName *string            `json:"display_name" binding:"omitempty,max=100"`
+	Bio         *string            `json:"bio" binding:"omitempty,max=1000"`
+	Slug        *string            `json:"slug" binding:"omitempty,max=40"`
+	Country     *string            `json:"country" binding:"omitempty,iso3166_1_alpha2"`
+	SocialLinks *[]SocialLinkInput `json:"social_links" binding:"omitempty,max=10,dive"`
+}
+
+// SellerStorefrontResponse is the public side of a seller
+type SellerStorefrontResponse struct {
+	ID          uuid.UUID `json:"id"`
+	Slug        string    `json:"slug,omitempty"`
+	DisplayName string    `json:"display_name"`
+	Bio         string    `json:"bio"`
+	// Image URLs keyed by variant: small, medium and large
+	Logo        map[string]string    `json:"logo,omitempty"`
+	Banner      map[string]string    `json:"banner,omitempty"`
+	Country     string               `json:"country,omitempty"`
+	JoinedAt    time.Time            `json:"joined_at"`
+	Verified    bool                 `json:"verified"`
+	SocialLinks []SocialLinkResponse `json:"social_links"`
+}
+
+type SellerDirectoryQuery struct {
+	// Full-text search over display name, name and bio
+	Q        string `form:"q" binding:"max=200"`
+	Verified *bool  `form:"verified"`
+	// ISO 3166-1 alpha-2 code
+	Country    string    `form:"country" binding:"omitempty,iso3166_1_alpha2"`
+	JoinedFrom time.Time `form:"joined_from" time_format:"2006-01-02"`
+	JoinedTo   time.Time `form:"joined_to" time_format:"2006-01-02"`
+	// relevance needs q and is the default with it, newest otherwise
+	Sort   string `form:"sort" binding:"omitempty,oneof=relevance newest"`
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+type SellerSummaryResponse struct {
+	ID          uuid.UUID `json:"id"`
+	Slug        string    `json:"slug,omitempty"`
+	DisplayName string    `json:"display_name"`
+	Bio         string    `json:"bio"`
+	// Image URLs keyed by variant: small, medium and large
+	Logo     map[string]string `json:"logo,omitempty"`
+	Country  string            `json:"country,omitempty"`
+	JoinedAt time.Time         `json:"joined_at"`
+	Verified bool              `json:"verified"`
+}
+
+type SellerDirectoryResponse struct {
+	Sellers    []SellerSummaryResponse `json:"sellers"`
+	NextCursor string                  `json:"next_cursor"`
+}
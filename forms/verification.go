@@ -0,0 +1,46 @@
+package forms
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// SubmitVerificationInput holds the text fields of the multipart submission,
+// documents are sent as files named after their type
+type SubmitVerificationInput struct {
+	LegalName                  string `form:"legal_name" binding:"required,max=200"`
+	BusinessRegistrationNumber string `form:"business_registration_number" binding:"required,max=50"`
+	TaxID                      string `form:"tax_id" binding:"required,max=50"`
+}
+
+type VerificationRejectInput struct {
+	// Shown to the seller
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+type VerificationStatusQuery struct {
+	// Defaults to pending
+	Status string `form:"status" binding:"omitempty,oneof=pending approved rejected"`
+}
+
+type VerificationDocumentResponse struct {
+	ID          uint      `json:"id"`
+	Type        string    `json:"type"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	Size        int       `json:"size"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type VerificationResponse struct {
+	ID                         uint                           `json:"id"`
+	SellerID                   uuid.UUID                      `json:"seller_id"`
+	LegalName                  string                         `json:"legal_name"`
+	BusinessRegistrationNumber string                         `json:"business_registration_number"`
+	TaxID                      string                         `json:"tax_id"`
+	Status                     string                         `json:"status"`
+	RejectionReason            string                         `json:"rejection_reason,omitempty"`
+	Documents                  []VerificationDocumentResponse `json:"documents"`
+	CreatedAt                  time.Time                      `json:"created_at"`
+	ReviewedAt                 *time.Time                     `json:"reviewed_at,omitempty"`
+}
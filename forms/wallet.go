@@ -0,0 +1,194 @@
+package forms
+
+import (
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+type WalletDebitInput struct {
+	// ISO 4217 code of the buyer wallet to charge, defaults to THB
+	Currency       string          `json:"currency"`
+	BuyerID        uuid.UUID       `json:"buyer_id" binding:"required"`
+	Amount         decimal.Decimal `json:"amount" binding:"required"`
+	OrderReference string          `json:"order_reference" binding:"required"`
+	// Device of the buyer, forwarded for the risk rules. After a challenge
+	// MFAToken is the access token the buyer got from /customer/mfa/verify,
+	// it proves the buyer passed the second factor
+	DeviceID string `json:"device_id"`
+	MFAToken string `json:"mfa_token"`
+}
+
+type WalletDebitResponse struct {
+	TransactionID uint            `json:"transaction_id"`
+	NewBalance    decimal.Decimal `json:"new_balance"`
+}
+
+type WalletHoldInput struct {
+	// ISO 4217 code of the buyer wallet to charge, defaults to THB
+	Currency  string          `json:"currency"`
+	BuyerID   uuid.UUID       `json:"buyer_id" binding:"required"`
+	SellerID  uuid.UUID       `json:"seller_id" binding:"required"`
+	Amount    decimal.Decimal `json:"amount" binding:"required"`
+	Category  string          `json:"category"`
+	Reference string          `json:"reference" binding:"required"`
+	// Optional hold lifetime in seconds, defaults to WALLET_HOLD_TTL
+	ExpiresIn int `json:"expires_in" binding:"omitempty,min=1"`
+	// Device of the buyer, forwarded for the risk rules. After a challenge
+	// MFAToken is the access token the buyer got from /customer/mfa/verify,
+	// it proves the buyer passed the second factor
+	DeviceID string `json:"device_id"`
+	MFAToken string `json:"mfa_token"`
+}
+
+type WalletHoldResponse struct {
+	ID        uint            `json:"id"`
+	BuyerID   uuid.UUID       `json:"buyer_id"`
+	SellerID  uuid.UUID       `json:"seller_id"`
+	Currency  string          `json:"currency"`
+	Amount    decimal.Decimal `json:"amount"`
+	Fee       decimal.Decimal `json:"fee"`
+	Status    string          `json:"status"`
+	Reference string          `json:"reference"`
+	ExpiresAt time.Time       `json:"expires_at"`
+}
+
+type SettlementInput struct {
+	// ISO 4217 code of the buyer wallet to charge, defaults to THB
+	Currency  string          `json:"currency"`
+	BuyerID   uuid.UUID       `json:"buyer_id" binding:"required"`
+	SellerID  uuid.UUID       `json:"seller_id" binding:"required"`
+	Amount    decimal.Decimal `json:"amount" binding:"required"`
+	Category  string          `json:"category"`
+	Reference string          `json:"reference" binding:"required"`
+	// Device of the buyer, forwarded for the risk rules. After a challenge
+	// MFAToken is the access token the buyer got from /customer/mfa/verify,
+	// it proves the buyer passed the second factor
+	DeviceID string `json:"device_id"`
+	MFAToken string `json:"mfa_token"`
+}
+
+type SettlementResponse struct {
+	ID             uint            `json:"id"`
+	BuyerID        uuid.UUID       `json:"buyer_id"`
+	SellerID       uuid.UUID       `json:"seller_id"`
+	Currency       string          `json:"currency"`
+	Amount         decimal.Decimal `json:"amount"`
+	PromoAmount    decimal.Decimal `json:"promo_amount"`
+	CommissionRate decimal.Decimal `json:"commission_rate"`
+	Commission     decimal.Decimal `json:"commission"`
+	SellerAmount   decimal.Decimal `json:"seller_amount"`
+	// What the seller wallet was credited, in the seller wallet currency
+	SellerCurrency     string          `json:"seller_currency"`
+	SellerCreditAmount decimal.Decimal `json:"seller_credit_amount"`
+	ExchangeRate       decimal.Decimal `json:"exchange_rate"`
+	RateAsOf           *time.Time      `json:"rate_as_of,omitempty"`
+	Category           string          `json:"category"`
+	Reference          string          `json:"reference"`
+}
+
+type CommissionRateInput struct {
+	// Leave empty to apply the rate to every seller
+	SellerID uuid.UUID `json:"seller_id"`
+	// Leave empty to apply the rate to every category
+	Category string          `json:"category"`
+	Rate     decimal.Decimal `json:"rate" binding:"required"`
+}
+
+type CommissionRateResponse struct {
+	ID       uint            `json:"id"`
+	SellerID uuid.UUID       `json:"seller_id"`
+	Category string          `json:"category"`
+	Rate     decimal.Decimal `json:"rate"`
+}
+
+type RefundInput struct {
+	BuyerID uuid.UUID `json:"buyer_id" binding:"required"`
+	// ID of the buyer purchase transaction being refunded
+	TransactionID uint `json:"transaction_id" binding:"required"`
+	// Leave empty to refund everything not refunded yet
+	Amount    decimal.Decimal `json:"amount"`
+	Reference string          `json:"reference" binding:"required"`
+}
+
+type RefundResponse struct {
+	Currency            string          `json:"currency"`
+	Amount              decimal.Decimal `json:"amount"`
+	PromoAmount         decimal.Decimal `json:"promo_amount"`
+	SellerCurrency      string          `json:"seller_currency,omitempty"`
+	SellerAmount        decimal.Decimal `json:"seller_amount"`
+	Commission          decimal.Decimal `json:"commission"`
+	BuyerTransactionID  uint            `json:"buyer_transaction_id"`
+	SellerTransactionID *uint           `json:"seller_transaction_id,omitempty"`
+	NewBuyerBalance     decimal.Decimal `json:"new_buyer_balance"`
+}
+
+type PromoCreditInput struct {
+	// ISO 4217 code of the buyer wallet to credit, defaults to THB
+	Currency  string          `json:"currency"`
+	BuyerID   uuid.UUID       `json:"buyer_id" binding:"required"`
+	Amount    decimal.Decimal `json:"amount" binding:"required"`
+	Campaign  string          `json:"campaign" binding:"required"`
+	ExpiresAt time.Time       `json:"expires_at" binding:"required"`
+}
+
+type PromoCreditResponse struct {
+	ID        uint            `json:"id"`
+	BuyerID   uuid.UUID       `json:"buyer_id"`
+	Currency  string          `json:"currency"`
+	Campaign  string          `json:"campaign"`
+	Amount    decimal.Decimal `json:"amount"`
+	Remaining decimal.Decimal `json:"remaining"`
+	ExpiresAt time.Time       `json:"expires_at"`
+}
+
+type PolicyViolationResponse struct {
+	ProblemResponse
+	Limit *decimal.Decimal `json:"limit,omitempty"`
+}
+
+type FrozenWalletResponse struct {
+	// customer or seller
+	Group        string          `json:"group"`
+	UserID       uuid.UUID       `json:"user_id"`
+	Currency     string          `json:"currency"`
+	Balance      decimal.Decimal `json:"balance"`
+	FrozenReason string          `json:"frozen_reason"`
+	FrozenAt     time.Time       `json:"frozen_at"`
+}
+
+type UnfreezeWalletInput struct {
+	Group    string    `json:"group" binding:"required,oneof=customer seller"`
+	UserID   uuid.UUID `json:"user_id" binding:"required"`
+	Currency string    `json:"currency" binding:"required,len=3"`
+}
+
+// RiskRejectionResponse is a problem with code mfa_required when the operation
+// may be retried after a second factor, operation_blocked otherwise
+type RiskRejectionResponse struct {
+	ProblemResponse
+	DecisionID uint     `json:"decision_id"`
+	Rules      []string `json:"rules"`
+}
+
+type RiskDecisionQuery struct {
+	Decision string `form:"decision" binding:"omitempty,oneof=allow challenge block"`
+	UserID   string `form:"user_id" binding:"omitempty,uuid"`
+	Limit    int    `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+type RiskDecisionResponse struct {
+	ID          uint            `json:"id"`
+	UserID      uuid.UUID       `json:"user_id"`
+	UserGroup   string          `json:"user_group"`
+	Operation   string          `json:"operation"`
+	Currency    string          `json:"currency"`
+	Amount      decimal.Decimal `json:"amount"`
+	DeviceID    string          `json:"device_id"`
+	IP          string          `json:"ip"`
+	MFAVerified bool            `json:"mfa_verified"`
+	Decision    string          `json:"decision"`
+	Rules       []string        `json:"rules"`
+	Reason      string          `json:"reason"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
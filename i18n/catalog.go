@@ -0,0 +1,522 @@
+package i18n
+
+import (
+	"fmt"
+	"user-service/enums"
+)
+
+// Codes clients can rely on, the wording of their messages may change
+const (
+	CodeBadRequest           = "bad_request"
+	CodeInvalidBody          = "invalid_body"
+	CodeValidationFailed     = "validation_failed"
+	CodeNotFound             = "not_found"
+	CodeInternalError        = "internal_error"
+	CodeInvalidToken         = "invalid_token"
+	CodeMissingBearerToken   = "missing_bearer_token"
+	CodeMissingPermission    = "missing_permission"
+	CodeAuthenticationFailed = "authentication_failed"
+	CodeUserExists           = "user_exists"
+	CodeInvalidID            = "invalid_id"
+	CodeInvalidTransaction   = "invalid_transaction_type"
+	CodeInvalidMonth         = "invalid_month"
+	CodeIfMatchRequired      = "if_match_required"
+	CodeIdempotencyKeyReused = "idempotency_key_reused"
+	CodeIdempotencyPending   = "idempotency_in_progress"
+	CodeFakePaymentDisabled  = "fake_payment_disabled"
+	CodeInvalidSignature     = "invalid_signature"
+	CodeTopupsDisabled       = "topups_disabled"
+	CodeRateNotFound         = "rate_not_found"
+	CodeUpstreamFailed       = "upstream_failed"
+	CodeAddressNotFound      = "address_not_found"
+	CodeWalletNotFound       = "wallet_not_found"
+	CodeSellerNotFound       = "seller_not_found"
+	CodeVerificationNotFound = "verification_not_found"
+	CodeOperationBlocked     = "operation_blocked"
+	CodeMFARequired          = "mfa_required"
+
+	CodeImageUnsupportedType = "image_unsupported_type"
+	CodeImageTooLarge        = "image_too_large"
+	CodeImageTooManyPixels   = "image_too_many_pixels"
+	CodeInvalidImageKind     = "invalid_image_kind"
+
+	CodeInvalidPhone        = "invalid_phone"
+	CodePhoneTaken          = "phone_taken"
+	CodeInvalidOTP          = "invalid_otp"
+	CodeOTPAttemptsExceeded = "otp_attempts_exceeded"
+	CodeOTPTooSoon          = "otp_too_soon"
+	CodeOTPLocked           = "otp_locked"
+	CodeOTPDailyLimit       = "otp_daily_limit"
+	CodePhoneNotVerified    = "phone_not_verified"
+
+	CodeInvalidSlug        = "invalid_slug"
+	CodeSlugTaken          = "slug_taken"
+	CodeInvalidBio         = "invalid_bio"
+	CodeInvalidSocialLink  = "invalid_social_link"
+	CodeInvalidCountry     = "invalid_country"
+	CodeEmptyProfileUpdate = "empty_profile_update"
+	CodeInvalidProfileName = "invalid_profile_name"
+	CodeProfileModified    = "profile_modified"
+	CodeSearchTermRequired = "search_term_required"
+
+	CodeInvalidPostalCode = "invalid_postal_code"
+	CodeTooManyAddresses  = "too_many_addresses"
+
+	CodeInvalidLocale          = "invalid_locale"
+	CodeInvalidTimezone        = "invalid_timezone"
+	CodeSecurityAlertsRequired = "security_alerts_required"
+
+	CodeInsufficientFunds     = "insufficient_funds"
+	CodeInvalidCurrency       = "invalid_currency"
+	CodeWalletExists          = "wallet_exists"
+	CodeWalletFrozen          = "wallet_frozen"
+	CodeInvalidCursor         = "invalid_cursor"
+	CodeStatementNotAvailable = "statement_not_available"
+	CodeHoldNotAuthorized     = "hold_not_authorized"
+	CodeHoldExpired           = "hold_expired"
+	CodeNotRefundable         = "not_refundable"
+	CodeRefundExceedsCharged  = "refund_exceeds_charged"
+	CodeUnknownTopupStatus    = "unknown_topup_status"
+	CodePaymentAmountMismatch = "payment_amount_mismatch"
+	CodePromoExpiryInPast     = "promo_expiry_in_past"
+	CodeInvalidReferralCode   = "invalid_referral_code"
+	CodeInvalidCommissionRate = "invalid_commission_rate"
+
+	CodePayoutBelowMinimum   = "payout_below_minimum"
+	CodePayoutStatusConflict = "payout_status_conflict"
+
+	CodeVerificationPending        = "verification_pending"
+	CodeAlreadyVerified            = "already_verified"
+	CodeVerificationStatusConflict = "verification_status_conflict"
+	CodeSellerNotVerified          = "seller_not_verified"
+	CodeMissingDocument            = "missing_document"
+	CodeInvalidDocument            = "invalid_document"
+	CodeDocumentTooLarge           = "document_too_large"
+)
+
+// catalog holds the message templates of every code by locale, templates take
+// fmt verbs for the arguments of Message
+var catalog = map[string]map[string]string{
+	CodeBadRequest: {
+		"en": "The request could not be processed.",
+		"th": "ไม่สามารถดำเนินการตามคำขอได้",
+	},
+	CodeInvalidBody: {
+		"en": "The request body is malformed or has values of the wrong type.",
+		"th": "ข้อมูลในคำขอมีรูปแบบไม่ถูกต้องหรือมีชนิดข้อมูลไม่ถูกต้อง",
+	},
+	CodeValidationFailed: {
+		"en": "Some fields are not valid.",
+		"th": "ข้อมูลบางช่องไม่ถูกต้อง",
+	},
+	CodeNotFound: {
+		"en": "The requested record was not found.",
+		"th": "ไม่พบข้อมูลที่ร้องขอ",
+	},
+	CodeInternalError: {
+		"en": "Something went wrong on our side, please try again later.",
+		"th": "เกิดข้อผิดพลาดในระบบ โปรดลองอีกครั้งภายหลัง",
+	},
+	CodeInvalidToken: {
+		"en": "The access token is invalid or has expired.",
+		"th": "โทเคนไม่ถูกต้องหรือหมดอายุแล้ว",
+	},
+	CodeMissingBearerToken: {
+		"en": "A bearer token is required.",
+		"th": "ต้องระบุโทเคนแบบ Bearer",
+	},
+	CodeMissingPermission: {
+		"en": "The client is not allowed to do this.",
+		"th": "ไคลเอนต์ไม่มีสิทธิ์ทำรายการนี้",
+	},
+	CodeAuthenticationFailed: {
+		"en": "The username or password is incorrect.",
+		"th": "ชื่อผู้ใช้หรือรหัสผ่านไม่ถูกต้อง",
+	},
+	CodeUserExists: {
+		"en": "This username is already registered.",
+		"th": "ชื่อผู้ใช้นี้ถูกลงทะเบียนแล้ว",
+	},
+	CodeInvalidID: {
+		"en": "The id in the path is not valid.",
+		"th": "รหัสอ้างอิงในเส้นทางไม่ถูกต้อง",
+	},
+	CodeInvalidTransaction: {
+		"en": "Unknown transaction type.",
+		"th": "ไม่รู้จักประเภทธุรกรรมนี้",
+	},
+	CodeInvalidMonth: {
+		"en": "The month must be in YYYY-MM format.",
+		"th": "เดือนต้องอยู่ในรูปแบบ YYYY-MM",
+	},
+	CodeIfMatchRequired: {
+		"en": "The If-Match header is required.",
+		"th": "ต้องระบุเฮดเดอร์ If-Match",
+	},
+	CodeIdempotencyKeyReused: {
+		"en": "The idempotency key was already used for a different request.",
+		"th": "คีย์ Idempotency นี้ถูกใช้กับคำขออื่นไปแล้ว",
+	},
+	CodeIdempotencyPending: {
+		"en": "A request with this idempotency key is still in progress.",
+		"th": "คำขอที่ใช้คีย์ Idempotency นี้ยังดำเนินการไม่เสร็จ",
+	},
+	CodeFakePaymentDisabled: {
+		"en": "The fake payment provider is not enabled.",
+		"th": "ไม่ได้เปิดใช้งานผู้ให้บริการชำระเงินจำลอง",
+	},
+	CodeInvalidSignature: {
+		"en": "The webhook signature is invalid.",
+		"th": "ลายเซ็นของ Webhook ไม่ถูกต้อง",
+	},
+	CodeTopupsDisabled: {
+		"en": "Top-ups are not available at the moment.",
+		"th": "ยังไม่เปิดให้เติมเงินในขณะนี้",
+	},
+	CodeRateNotFound: {
+		"en": "No exchange rate is available for this currency.",
+		"th": "ไม่มีอัตราแลกเปลี่ยนสำหรับสกุลเงินนี้",
+	},
+	CodeUpstreamFailed: {
+		"en": "A partner service failed to answer, please try again later.",
+		"th": "บริการภายนอกไม่ตอบสนอง โปรดลองอีกครั้งภายหลัง",
+	},
+	CodeAddressNotFound: {
+		"en": "The address was not found.",
+		"th": "ไม่พบที่อยู่",
+	},
+	CodeWalletNotFound: {
+		"en": "The wallet was not found.",
+		"th": "ไม่พบกระเป๋าเงิน",
+	},
+	CodeSellerNotFound: {
+		"en": "The seller was not found.",
+		"th": "ไม่พบผู้ขาย",
+	},
+	CodeVerificationNotFound: {
+		"en": "The verification was not found.",
+		"th": "ไม่พบคำขอยืนยันตัวตน",
+	},
+	CodeOperationBlocked: {
+		"en": "The operation was blocked by our risk checks.",
+		"th": "รายการนี้ถูกระงับโดยระบบตรวจสอบความเสี่ยง",
+	},
+	CodeMFARequired: {
+		"en": "Confirm with a second factor and try again.",
+		"th": "โปรดยืนยันตัวตนด้วยปัจจัยที่สองแล้วลองอีกครั้ง",
+	},
+	CodeImageUnsupportedType: {
+		"en": "The image must be a JPEG, PNG, GIF or WebP file.",
+		"th": "รูปภาพต้องเป็นไฟล์ JPEG, PNG, GIF หรือ WebP",
+	},
+	CodeImageTooLarge: {
+		"en": "The image file is too large.",
+		"th": "ไฟล์รูปภาพมีขนาดใหญ่เกินไป",
+	},
+	CodeImageTooManyPixels: {
+		"en": "The image dimensions are too large.",
+		"th": "รูปภาพมีขนาดความกว้างยาวเกินไป",
+	},
+	CodeInvalidImageKind: {
+		"en": "Unknown image kind.",
+		"th": "ไม่รู้จักประเภทรูปภาพนี้",
+	},
+	CodeInvalidPhone: {
+		"en": "The phone number is not valid.",
+		"th": "หมายเลขโทรศัพท์ไม่ถูกต้อง",
+	},
+	CodePhoneTaken: {
+		"en": "The phone number is already in use.",
+		"th": "หมายเลขโทรศัพท์นี้ถูกใช้งานแล้ว",
+	},
+	CodeInvalidOTP: {
+		"en": "The code is wrong or has expired.",
+		"th": "รหัสไม่ถูกต้องหรือหมดอายุแล้ว",
+	},
+	CodeOTPAttemptsExceeded: {
+		"en": "Too many wrong codes, request a new one.",
+		"th": "ใส่รหัสผิดหลายครั้งเกินไป โปรดขอรหัสใหม่",
+	},
+	CodeOTPTooSoon: {
+		"en": "Please wait before requesting another code.",
+		"th": "โปรดรอสักครู่ก่อนขอรหัสใหม่",
+	},
+	CodeOTPLocked: {
+		"en": "Too many wrong codes. Please try again later.",
+		"th": "กรอกรหัสผิดหลายครั้งเกินไป โปรดลองใหม่ภายหลัง",
+	},
+	CodeOTPDailyLimit: {
+		"en": "Too many codes were sent to this phone today.",
+		"th": "ส่งรหัสไปยังหมายเลขนี้ครบจำนวนสำหรับวันนี้แล้ว",
+	},
+	CodePhoneNotVerified: {
+		"en": "Verify a phone number before using this.",
+		"th": "โปรดยืนยันหมายเลขโทรศัพท์ก่อนใช้งานส่วนนี้",
+	},
+	CodeInvalidSlug: {
+		"en": "The slug must be 3 to 40 lowercase letters, digits or dashes.",
+		"th": "สลักต้องเป็นตัวอักษรพิมพ์เล็ก ตัวเลข หรือขีดกลาง ความยาว 3 ถึง 40 ตัวอักษร",
+	},
+	CodeSlugTaken: {
+		"en": "The slug is already taken.",
+		"th": "สลักนี้ถูกใช้งานแล้ว",
+	},
+	CodeInvalidBio: {
+		"en": "The bio must be at most 1000 characters.",
+		"th": "คำแนะนำตัวต้องมีความยาวไม่เกิน 1000 ตัวอักษร",
+	},
+	CodeInvalidSocialLink: {
+		"en": "Social links need a known platform and an http or https URL.",
+		"th": "ลิงก์โซเชียลต้องระบุแพลตฟอร์มที่รองรับและ URL แบบ http หรือ https",
+	},
+	CodeInvalidCountry: {
+		"en": "The country must be an ISO 3166-1 alpha-2 code.",
+		"th": "ประเทศต้องเป็นรหัส ISO 3166-1 alpha-2",
+	},
+	CodeEmptyProfileUpdate: {
+		"en": "There is nothing to update.",
+		"th": "ไม่มีข้อมูลที่ต้องแก้ไข",
+	},
+	CodeInvalidProfileName: {
+		"en": "Names must be 1 to 100 characters without control characters.",
+		"th": "ชื่อต้องมีความยาว 1 ถึง 100 ตัวอักษรและไม่มีอักขระควบคุม",
+	},
+	CodeProfileModified: {
+		"en": "The profile was changed by another request, reload it and try again.",
+		"th": "โปรไฟล์ถูกแก้ไขโดยคำขออื่น โปรดโหลดใหม่แล้วลองอีกครั้ง",
+	},
+	CodeSearchTermRequired: {
+		"en": "Sorting by relevance needs a search term.",
+		"th": "การเรียงตามความเกี่ยวข้องต้องระบุคำค้นหา",
+	},
+	CodeInvalidPostalCode: {
+		"en": "The postal code does not match the format of the country.",
+		"th": "รหัสไปรษณีย์ไม่ตรงกับรูปแบบของประเทศ",
+	},
+	CodeTooManyAddresses: {
+		"en": "The address book is full.",
+		"th": "สมุดที่อยู่เต็มแล้ว",
+	},
+	CodeInvalidLocale: {
+		"en": "The language is not supported.",
+		"th": "ไม่รองรับภาษานี้",
+	},
+	CodeInvalidTimezone: {
+		"en": "Unknown timezone.",
+		"th": "ไม่รู้จักเขตเวลานี้",
+	},
+	CodeSecurityAlertsRequired: {
+		"en": "Security alerts must stay on for at least one channel.",
+		"th": "ต้องเปิดการแจ้งเตือนด้านความปลอดภัยไว้อย่างน้อยหนึ่งช่องทาง",
+	},
+	CodeInsufficientFunds: {
+		"en": "The wallet balance is too low.",
+		"th": "ยอดเงินในกระเป๋าไม่เพียงพอ",
+	},
+	CodeInvalidCurrency: {
+		"en": "The currency is not supported.",
+		"th": "ไม่รองรับสกุลเงินนี้",
+	},
+	CodeWalletExists: {
+		"en": "A wallet in this currency already exists.",
+		"th": "มีกระเป๋าเงินสกุลนี้อยู่แล้ว",
+	},
+	CodeWalletFrozen: {
+		"en": "The wallet is frozen pending review.",
+		"th": "กระเป๋าเงินถูกระงับระหว่างรอการตรวจสอบ",
+	},
+	CodeInvalidCursor: {
+		"en": "The page cursor is not valid.",
+		"th": "ตัวชี้หน้าข้อมูลไม่ถูกต้อง",
+	},
+	CodeStatementNotAvailable: {
+		"en": "The statement month has not started yet.",
+		"th": "ยังไม่ถึงเดือนของรายการเดินบัญชีนี้",
+	},
+	CodeHoldNotAuthorized: {
+		"en": "The hold is no longer authorized.",
+		"th": "การกันวงเงินนี้ไม่อยู่ในสถานะอนุมัติแล้ว",
+	},
+	CodeHoldExpired: {
+		"en": "The hold has expired.",
+		"th": "การกันวงเงินนี้หมดอายุแล้ว",
+	},
+	CodeNotRefundable: {
+		"en": "Only purchase transactions can be refunded.",
+		"th": "คืนเงินได้เฉพาะรายการซื้อเท่านั้น",
+	},
+	CodeRefundExceedsCharged: {
+		"en": "The refund exceeds the amount charged.",
+		"th": "จำนวนเงินคืนเกินกว่ายอดที่เรียกเก็บ",
+	},
+	CodeUnknownTopupStatus: {
+		"en": "Unknown top-up status.",
+		"th": "ไม่รู้จักสถานะการเติมเงินนี้",
+	},
+	CodePaymentAmountMismatch: {
+		"en": "The paid amount does not match the top-up.",
+		"th": "ยอดที่ชำระไม่ตรงกับยอดการเติมเงิน",
+	},
+	CodePromoExpiryInPast: {
+		"en": "Promo credit must expire in the future.",
+		"th": "วันหมดอายุของเครดิตโปรโมชันต้องเป็นวันในอนาคต",
+	},
+	CodeInvalidReferralCode: {
+		"en": "The referral code is not valid.",
+		"th": "รหัสแนะนำไม่ถูกต้อง",
+	},
+	CodeInvalidCommissionRate: {
+		"en": "The commission rate must be between 0 and 1.",
+		"th": "อัตราค่าคอมมิชชันต้องอยู่ระหว่าง 0 ถึง 1",
+	},
+	enums.PolicyAmountNotPositive: {
+		"en": "The amount must be greater than zero.",
+		"th": "จำนวนเงินต้องมากกว่าศูนย์",
+	},
+	enums.PolicyAmountPrecision: {
+		"en": "The amount must have at most 2 decimal places.",
+		"th": "จำนวนเงินต้องมีทศนิยมไม่เกิน 2 ตำแหน่ง",
+	},
+	enums.PolicyAmountOverflow: {
+		"en": "The amount must be at most %s.",
+		"th": "จำนวนเงินต้องไม่เกิน %s",
+	},
+	enums.PolicyTopupBelowMinimum: {
+		"en": "A top-up must be at least %s.",
+		"th": "ยอดเติมเงินต้องไม่น้อยกว่า %s",
+	},
+	enums.PolicyTopupAboveMaximum: {
+		"en": "A top-up must be at most %s.",
+		"th": "ยอดเติมเงินต้องไม่เกิน %s",
+	},
+	enums.PolicyDailyLimitExceeded: {
+		"en": "The daily top-up limit of %s is reached.",
+		"th": "ยอดเติมเงินครบวงเงินรายวัน %s แล้ว",
+	},
+	enums.PolicyMonthlyLimitExceeded: {
+		"en": "The monthly top-up limit of %s is reached.",
+		"th": "ยอดเติมเงินครบวงเงินรายเดือน %s แล้ว",
+	},
+	enums.PolicyBalanceLimitExceeded: {
+		"en": "The wallet balance cannot exceed %s.",
+		"th": "ยอดเงินในกระเป๋าต้องไม่เกิน %s",
+	},
+	CodePayoutBelowMinimum: {
+		"en": "The payout amount is below the minimum.",
+		"th": "จำนวนเงินที่ถอนต่ำกว่าขั้นต่ำ",
+	},
+	CodePayoutStatusConflict: {
+		"en": "The payout request is not in the expected state.",
+		"th": "คำขอถอนเงินไม่อยู่ในสถานะที่ดำเนินการได้",
+	},
+	CodeVerificationPending: {
+		"en": "A verification is already under review.",
+		"th": "มีคำขอยืนยันตัวตนที่อยู่ระหว่างตรวจสอบแล้ว",
+	},
+	CodeAlreadyVerified: {
+		"en": "The seller is already verified.",
+		"th": "ผู้ขายได้รับการยืนยันแล้ว",
+	},
+	CodeVerificationStatusConflict: {
+		"en": "The verification is not pending review.",
+		"th": "คำขอยืนยันตัวตนไม่อยู่ระหว่างรอตรวจสอบ",
+	},
+	CodeSellerNotVerified: {
+		"en": "The seller must be verified before requesting payouts.",
+		"th": "ผู้ขายต้องได้รับการยืนยันก่อนขอถอนเงิน",
+	},
+	CodeMissingDocument: {
+		"en": "A business registration document is required.",
+		"th": "ต้องแนบเอกสารจดทะเบียนธุรกิจ",
+	},
+	CodeInvalidDocument: {
+		"en": "Documents must be PDF, JPEG or PNG files.",
+		"th": "เอกสารต้องเป็นไฟล์ PDF, JPEG หรือ PNG",
+	},
+	CodeDocumentTooLarge: {
+		"en": "The document file is too large.",
+		"th": "ไฟล์เอกสารมีขนาดใหญ่เกินไป",
+	},
+
+	CodeFieldRequired: {
+		"en": "This field is required.",
+		"th": "ต้องระบุข้อมูลช่องนี้",
+	},
+	CodeFieldMaxLength: {
+		"en": "Must be at most %s characters.",
+		"th": "ต้องมีความยาวไม่เกิน %s ตัวอักษร",
+	},
+	CodeFieldMaxItems: {
+		"en": "Must have at most %s items.",
+		"th": "ต้องมีไม่เกิน %s รายการ",
+	},
+	CodeFieldMax: {
+		"en": "Must be at most %s.",
+		"th": "ต้องไม่เกิน %s",
+	},
+	CodeFieldMinLength: {
+		"en": "Must be at least %s characters.",
+		"th": "ต้องมีความยาวอย่างน้อย %s ตัวอักษร",
+	},
+	CodeFieldMinItems: {
+		"en": "Must have at least %s items.",
+		"th": "ต้องมีอย่างน้อย %s รายการ",
+	},
+	CodeFieldMin: {
+		"en": "Must be at least %s.",
+		"th": "ต้องมีค่าอย่างน้อย %s",
+	},
+	CodeFieldLength: {
+		"en": "Must be exactly %s characters.",
+		"th": "ต้องมีความยาว %s ตัวอักษรพอดี",
+	},
+	CodeFieldOneOf: {
+		"en": "Must be one of: %s.",
+		"th": "ต้องเป็นค่าใดค่าหนึ่งต่อไปนี้: %s",
+	},
+	CodeFieldNumeric: {
+		"en": "Must contain only digits.",
+		"th": "ต้องเป็นตัวเลขเท่านั้น",
+	},
+	CodeFieldURL: {
+		"en": "Must be a valid URL.",
+		"th": "ต้องเป็น URL ที่ถูกต้อง",
+	},
+	CodeFieldUUID: {
+		"en": "Must be a valid UUID.",
+		"th": "ต้องเป็น UUID ที่ถูกต้อง",
+	},
+	CodeFieldCountry: {
+		"en": "Must be an ISO 3166-1 alpha-2 country code.",
+		"th": "ต้องเป็นรหัสประเทศ ISO 3166-1 alpha-2",
+	},
+	CodeFieldInvalid: {
+		"en": "This value is not valid.",
+		"th": "ค่านี้ไม่ถูกต้อง",
+	},
+}
+
+// Message formats the template of code in locale, falling back to English
+// and then to the code itself
+func Message(locale string, code string, args ...interface{}) string {
+	templates, ok := catalog[code]
+	if !ok {
+		return code
+	}
+	template, ok := templates[locale]
+	if !ok {
+		template = templates["en"]
+	}
+	if len(args) == 0 {
+		return template
+	}
+	return fmt.Sprintf(template, args...)
+}
+
+// Resolve returns locale when messages exist in it, the default otherwise
+func Resolve(locale string) string {
+	if enums.IsValidLocale(locale) {
+		return locale
+	}
+	return enums.DefaultLocale
+}
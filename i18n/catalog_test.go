@@ -0,0 +1,92 @@
+package i18n
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+	"testing"
+	"user-service/enums"
+)
+
+// messageCodes reads the Code constants declared in the package sources, the
+// test catches a code added without its messages
+func messageCodes(t *testing.T) map[string]string {
+	t.Helper()
+	files := token.NewFileSet()
+	packages, err := parser.ParseDir(files, ".", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	codes := map[string]string{}
+	for _, file := range packages["i18n"].Files {
+		for _, declaration := range file.Decls {
+			general, ok := declaration.(*ast.GenDecl)
+			if !ok || general.Tok != token.CONST {
+				continue
+			}
+			for _, spec := range general.Specs {
+				value := spec.(*ast.ValueSpec)
+				for i, name := range value.Names {
+					if !strings.HasPrefix(name.Name, "Code") || i >= len(value.Values) {
+						continue
+					}
+					literal, ok := value.Values[i].(*ast.BasicLit)
+					if !ok || literal.Kind != token.STRING {
+						continue
+					}
+					code, err := strconv.Unquote(literal.Value)
+					if err != nil {
+						t.Fatal(err)
+					}
+					codes[code] = name.Name
+				}
+			}
+		}
+	}
+	return codes
+}
+
+// policyCodes are declared with the wallet policy, the catalog translates them
+var policyCodes = []string{
+	enums.PolicyAmountNotPositive,
+	enums.PolicyAmountPrecision,
+	enums.PolicyAmountOverflow,
+	enums.PolicyTopupBelowMinimum,
+	enums.PolicyTopupAboveMaximum,
+	enums.PolicyDailyLimitExceeded,
+	enums.PolicyMonthlyLimitExceeded,
+	enums.PolicyBalanceLimitExceeded,
+}
+
+func TestCatalogHasEveryLocale(t *testing.T) {
+	codes := messageCodes(t)
+	if len(codes) == 0 {
+		t.Fatal("no codes found")
+	}
+	for _, code := range policyCodes {
+		codes[code] = "enums.Policy"
+	}
+	for code, name := range codes {
+		templates, ok := catalog[code]
+		if !ok {
+			t.Errorf("%s (%s) has no messages", name, code)
+			continue
+		}
+		for locale := range enums.Locales {
+			if templates[locale] == "" {
+				t.Errorf("%s (%s) has no %s message", name, code, locale)
+			}
+		}
+		// Message passes the same arguments to every locale
+		if en, th := strings.Count(templates["en"], "%"), strings.Count(templates["th"], "%"); en != th {
+			t.Errorf("%s (%s) takes %d arguments in en and %d in th", name, code, en, th)
+		}
+	}
+	for code := range catalog {
+		if _, ok := codes[code]; !ok {
+			t.Errorf("catalog has messages for %s, no constant declares it", code)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"user-service/enums"
+)
+
+// ParseAcceptLanguage returns the supported locale the client ranks highest
+// in an Accept-Language header, empty when it names none. Regional variants
+// match their language, th-TH is th
+func ParseAcceptLanguage(header string) string {
+	type ranked struct {
+		locale  string
+		quality float64
+	}
+	var candidates []ranked
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if tag == "" || tag == "*" {
+			continue
+		}
+		quality := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				value, err := strconv.ParseFloat(param[len("q="):], 64)
+				if err != nil {
+					value = 0
+				}
+				quality = value
+			}
+		}
+		if quality <= 0 {
+			continue
+		}
+		language := strings.SplitN(tag, "-", 2)[0]
+		if enums.IsValidLocale(language) {
+			candidates = append(candidates, ranked{locale: language, quality: quality})
+		}
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+	// Stable keeps the header order between equal qualities
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].quality > candidates[j].quality
+	})
+	return candidates[0].locale
+}
@@ -0,0 +1,94 @@
+package i18n
+
+import (
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"reflect"
+	"strings"
+)
+
+// Codes of messages for a single field that failed a binding rule
+const (
+	CodeFieldRequired  = "field_required"
+	CodeFieldMaxLength = "field_max_length"
+	CodeFieldMaxItems  = "field_max_items"
+	CodeFieldMax       = "field_max"
+	CodeFieldMinLength = "field_min_length"
+	CodeFieldMinItems  = "field_min_items"
+	CodeFieldMin       = "field_min"
+	CodeFieldLength    = "field_length"
+	CodeFieldOneOf     = "field_oneof"
+	CodeFieldNumeric   = "field_numeric"
+	CodeFieldURL       = "field_url"
+	CodeFieldUUID      = "field_uuid"
+	CodeFieldCountry   = "field_country"
+	CodeFieldInvalid   = "field_invalid"
+)
+
+// RegisterFieldNames makes binding errors name fields by their json or form
+// key, the names clients send, instead of the Go struct field
+func RegisterFieldNames() {
+	engine, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	engine.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "form"} {
+			name := strings.SplitN(field.Tag.Get(tag), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+		return field.Name
+	})
+}
+
+// FieldErrors translates every failed rule to a message keyed by the path of
+// its field, like line1 or social_links[0].url
+func FieldErrors(locale string, errs validator.ValidationErrors) map[string]string {
+	fields := make(map[string]string, len(errs))
+	for _, fieldError := range errs {
+		path := fieldError.Namespace()
+		// Drop the name of the bound struct
+		if i := strings.Index(path, "."); i >= 0 {
+			path = path[i+1:]
+		}
+		fields[path] = fieldMessage(locale, fieldError)
+	}
+	return fields
+}
+
+func fieldMessage(locale string, fieldError validator.FieldError) string {
+	maxCode, minCode := CodeFieldMax, CodeFieldMin
+	switch fieldError.Kind() {
+	case reflect.String:
+		maxCode, minCode = CodeFieldMaxLength, CodeFieldMinLength
+	case reflect.Slice, reflect.Map, reflect.Array:
+		maxCode, minCode = CodeFieldMaxItems, CodeFieldMinItems
+	}
+	switch fieldError.Tag() {
+	case "required":
+		return Message(locale, CodeFieldRequired)
+	case "max", "lte":
+		return Message(locale, maxCode, fieldError.Param())
+	case "min", "gte":
+		return Message(locale, minCode, fieldError.Param())
+	case "len":
+		return Message(locale, CodeFieldLength, fieldError.Param())
+	case "oneof":
+		return Message(locale, CodeFieldOneOf, strings.Join(strings.Fields(fieldError.Param()), ", "))
+	case "numeric":
+		return Message(locale, CodeFieldNumeric)
+	case "url":
+		return Message(locale, CodeFieldURL)
+	case "uuid":
+		return Message(locale, CodeFieldUUID)
+	case "iso3166_1_alpha2":
+		return Message(locale, CodeFieldCountry)
+	default:
+		return Message(locale, CodeFieldInvalid)
+	}
+}
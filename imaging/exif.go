@@ -0,0 +1,116 @@
+package imaging
+
+import (
+	"encoding/binary"
+	"image"
+)
+
+const exifOrientationTag = 0x0112
+
+// jpegOrientation reads the EXIF orientation of a JPEG file, 1 (upright) when
+// there is none or it cannot be parsed
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xff || data[1] != 0xd8 {
+		return 1
+	}
+	offset := 2
+	for offset+4 <= len(data) {
+		if data[offset] != 0xff {
+			return 1
+		}
+		marker := data[offset+1]
+		// Start of scan, the metadata segments are over
+		if marker == 0xda {
+			return 1
+		}
+		length := int(binary.BigEndian.Uint16(data[offset+2:]))
+		segment := offset + 4
+		end := offset + 2 + length
+		if length < 2 || end > len(data) {
+			return 1
+		}
+		if marker == 0xe1 && end-segment > 6 && string(data[segment:segment+6]) == "Exif\x00\x00" {
+			return tiffOrientation(data[segment+6 : end])
+		}
+		offset = end
+	}
+	return 1
+}
+
+// tiffOrientation looks for the orientation tag in the first IFD
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < entries; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == exifOrientationTag {
+			orientation := int(order.Uint16(tiff[entry+8:]))
+			if orientation < 1 || orientation > 8 {
+				return 1
+			}
+			return orientation
+		}
+	}
+	return 1
+}
+
+// orient turns an image stored with the given EXIF orientation upright. It
+// runs on the resized variants, pixels are copied straight between the
+// buffers
+func orient(img *image.RGBA, orientation int) *image.RGBA {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	// Orientations 5 to 8 swap width and height
+	swap := orientation >= 5
+	targetWidth, targetHeight := width, height
+	if swap {
+		targetWidth, targetHeight = height, width
+	}
+	target := image.NewRGBA(image.Rect(0, 0, targetWidth, targetHeight))
+	for y := 0; y < height; y++ {
+		from := img.PixOffset(bounds.Min.X, bounds.Min.Y+y)
+		for x := 0; x < width; x++ {
+			var tx, ty int
+			switch orientation {
+			case 2:
+				tx, ty = width-1-x, y
+			case 3:
+				tx, ty = width-1-x, height-1-y
+			case 4:
+				tx, ty = x, height-1-y
+			case 5:
+				tx, ty = y, x
+			case 6:
+				tx, ty = height-1-y, x
+			case 7:
+				tx, ty = height-1-y, width-1-x
+			case 8:
+				tx, ty = y, width-1-x
+			}
+			to := target.PixOffset(tx, ty)
+			copy(target.Pix[to:to+4], img.Pix[from+x*4:from+x*4+4])
+		}
+	}
+	return target
+}
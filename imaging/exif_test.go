@@ -0,0 +1,63 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// The stored top left pixel ends up where each orientation puts it once the
+// image is upright
+func TestOrient(t *testing.T) {
+	marker := color.RGBA{R: 255, A: 255}
+	stored := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	stored.SetRGBA(0, 0, marker)
+
+	tests := []struct {
+		orientation int
+		width       int
+		height      int
+		x           int
+		y           int
+	}{
+		{1, 3, 2, 0, 0},
+		{2, 3, 2, 2, 0},
+		{3, 3, 2, 2, 1},
+		{4, 3, 2, 0, 1},
+		{5, 2, 3, 0, 0},
+		{6, 2, 3, 1, 0},
+		{7, 2, 3, 1, 2},
+		{8, 2, 3, 0, 2},
+	}
+	for _, test := range tests {
+		upright := orient(stored, test.orientation)
+		if bounds := upright.Bounds(); bounds.Dx() != test.width || bounds.Dy() != test.height {
+			t.Errorf("orientation %d: %dx%d, want %dx%d", test.orientation, bounds.Dx(), bounds.Dy(), test.width, test.height)
+			continue
+		}
+		if got := upright.RGBAAt(test.x, test.y); got != marker {
+			t.Errorf("orientation %d: pixel at %d,%d = %v, want the marker", test.orientation, test.x, test.y, got)
+		}
+	}
+}
+
+// A variant width limits the upright width, also for images stored on their
+// side
+func TestResizeSideways(t *testing.T) {
+	stored := image.NewRGBA(image.Rect(0, 0, 400, 200))
+	tests := []struct {
+		orientation int
+		width       int
+		height      int
+	}{
+		{1, 100, 50},
+		{6, 100, 200},
+		{8, 100, 200},
+	}
+	for _, test := range tests {
+		bounds := resize(stored, Variant{Width: 100}, test.orientation).Bounds()
+		if bounds.Dx() != test.width || bounds.Dy() != test.height {
+			t.Errorf("orientation %d: %dx%d, want %dx%d", test.orientation, bounds.Dx(), bounds.Dy(), test.width, test.height)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package imaging
+
+import (
+	"bytes"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"runtime"
+	"user-service/errs"
+	"user-service/i18n"
+)
+
+const (
+	// maxPixels guards against decompression bombs, a small file can
+	// declare huge dimensions. Decoded as RGBA it is 100 MB per upload
+	maxPixels   = 25_000_000
+	jpegQuality = 85
+)
+
+var (
+	ErrUnsupportedType = errs.New(errs.ErrUnsupportedMedia, i18n.CodeImageUnsupportedType, "image must be a JPEG, PNG, GIF or WebP file")
+	ErrTooLarge        = errs.New(errs.ErrTooLarge, i18n.CodeImageTooLarge, "image file is too large")
+	ErrTooManyPixels   = errs.New(errs.ErrTooLarge, i18n.CodeImageTooManyPixels, "image dimensions are too large")
+)
+
+// decodeSlots bounds the uploads decoded at once, so concurrent large uploads
+// cannot add up to more memory than the service has
+var decodeSlots = make(chan struct{}, runtime.NumCPU())
+
+// allowedTypes are the sniffed content types accepted for upload
+var allowedTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// Variant is one size produced from an upload. Square variants are centre
+// cropped to Width x Width, the others keep the aspect ratio within Width
+type Variant struct {
+	Name   string
+	Width  int
+	Square bool
+}
+
+// Output is an encoded variant
+type Output struct {
+	Name        string
+	ContentType string
+	Extension   string
+	Data        []byte
+}
+
+// Process checks an upload and renders every variant. The declared content
+// type is ignored, the type is sniffed from the bytes. Images are decoded
+// and encoded again so EXIF and any other metadata is dropped, the EXIF
+// orientation of JPEG files is applied to every variant
+func Process(data []byte, maxBytes int64, variants []Variant) ([]Output, error) {
+	if int64(len(data)) > maxBytes {
+		return nil, ErrTooLarge
+	}
+	if !allowedTypes[http.DetectContentType(data)] {
+		return nil, ErrUnsupportedType
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedType
+	}
+	if config.Width*config.Height > maxPixels {
+		return nil, ErrTooManyPixels
+	}
+	decodeSlots <- struct{}{}
+	defer func() { <-decodeSlots }()
+	source, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedType
+	}
+	orientation := 1
+	if format == "jpeg" {
+		orientation = jpegOrientation(data)
+	}
+
+	// Keep transparency where the source has any, JPEG is smaller otherwise
+	opaque := isOpaque(source)
+	outputs := make([]Output, 0, len(variants))
+	for _, variant := range variants {
+		resized := resize(source, variant, orientation)
+		output := Output{Name: variant.Name}
+		var buffer bytes.Buffer
+		if opaque {
+			output.ContentType, output.Extension = "image/jpeg", "jpg"
+			err = jpeg.Encode(&buffer, resized, &jpeg.Options{Quality: jpegQuality})
+		} else {
+			output.ContentType, output.Extension = "image/png", "png"
+			err = png.Encode(&buffer, resized)
+		}
+		if err != nil {
+			return nil, err
+		}
+		output.Data = buffer.Bytes()
+		outputs = append(outputs, output)
+	}
+	return outputs, nil
+}
+
+// resize renders a variant and turns it upright. Variant widths are upright
+// widths, an image stored on its side is limited by its stored height
+func resize(source image.Image, variant Variant, orientation int) image.Image {
+	bounds := source.Bounds()
+	if variant.Square {
+		side := bounds.Dx()
+		if bounds.Dy() < side {
+			side = bounds.Dy()
+		}
+		x := bounds.Min.X + (bounds.Dx()-side)/2
+		y := bounds.Min.Y + (bounds.Dy()-side)/2
+		bounds = image.Rect(x, y, x+side, y+side)
+	}
+	width, height := bounds.Dx(), bounds.Dy()
+	uprightWidth := width
+	if orientation >= 5 && orientation <= 8 {
+		uprightWidth = height
+	}
+	// Never upscale
+	if uprightWidth > variant.Width {
+		width = width * variant.Width / uprightWidth
+		height = height * variant.Width / uprightWidth
+		if width < 1 {
+			width = 1
+		}
+		if height < 1 {
+			height = 1
+		}
+	}
+	target := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(target, target.Bounds(), source, bounds, draw.Src, nil)
+	return orient(target, orientation)
+}
+
+func isOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
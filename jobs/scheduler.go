@@ -0,0 +1,21 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Every runs job on a fixed interval in the background for the lifetime of the
+// process. Jobs must be safe to run concurrently on several replicas
+func Every(interval time.Duration, name string, job func(c context.Context) error) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := job(context.Background()); err != nil {
+				log.Printf("job %s failed: %v", name, err)
+			}
+		}
+	}()
+}
@@ -3,7 +3,6 @@ package main
 import (
 	"context"
 	"fmt"
-	jwt "github.com/appleboy/gin-jwt/v2"
 	"github.com/gin-gonic/gin"
 	"github.com/swaggo/files"
 	"github.com/swaggo/gin-swagger"
@@ -12,12 +11,24 @@ import (
 	"net/http"
 	"os"
 	"time"
+	// Timezone preferences are validated against the embedded database, the
+	// runtime image has none
+	_ "time/tzdata"
 	"user-service/controllers"
 	"user-service/db"
 	_ "user-service/docs"
+	"user-service/enums"
+	"user-service/i18n"
+	"user-service/jobs"
 	"user-service/middlewares"
 	"user-service/models"
+	"user-service/notify"
 	"user-service/otl"
+	"user-service/payment"
+	"user-service/payout"
+	"user-service/rates"
+	"user-service/sms"
+	"user-service/storage"
 )
 
 // @title           Buyer Service API
@@ -56,6 +67,32 @@ func main() {
 		&models.Buyer{},
 		&models.BuyerProfile{},
 		&models.BuyerWallet{},
+		&models.BuyerWalletTransaction{},
+		&models.SellerWalletTransaction{},
+		&models.IdempotencyKey{},
+		&models.BuyerWalletHold{},
+		&models.CommissionRate{},
+		&models.PlatformLedgerEntry{},
+		&models.Settlement{},
+		&models.PayoutDestination{},
+		&models.PayoutRequest{},
+		&models.PayoutBatch{},
+		&models.TopupIntent{},
+		&models.BuyerPromoCredit{},
+		&models.ReferralCode{},
+		&models.Referral{},
+		&models.RiskDecision{},
+		&models.KnownDevice{},
+		&models.SellerSlug{},
+		&models.SellerSocialLink{},
+		&models.SellerVerification{},
+		&models.SellerVerificationDocument{},
+		&models.BuyerAddress{},
+		&models.PhoneNumber{},
+		&models.OneTimePassword{},
+		&models.OTPFailure{},
+		&models.UserPreference{},
+		&models.NotificationSetting{},
 	)
 	if err != nil {
 		fmt.Println(err)
@@ -67,42 +104,198 @@ func main() {
 			log.Fatal(err)
 		}
 	}
+	// Wallets from before the ledger need their balance on it before anything
+	// reconciles or lists them
+	if posted, err := models.PostOpeningBalances(context.Background()); err != nil {
+		log.Fatal(err)
+	} else if posted > 0 {
+		log.Printf("posted %d opening balances", posted)
+	}
 
 	r := gin.Default()
+	// The client IP feeds the OTP lockout and the referral guards, only the
+	// ingress may set it through X-Forwarded-For
+	if err := r.SetTrustedProxies(middlewares.TrustedProxies()); err != nil {
+		log.Fatal(err)
+	}
 	r.Use(otelgin.Middleware("UserService"))
+	r.Use(middlewares.Locale())
+	r.Use(middlewares.DeviceID())
+	r.Use(middlewares.ErrorHandler())
+	i18n.RegisterFieldNames()
 	// the jwt middleware
 	middlewares.InitCustomerJWTMiddleware()
 	middlewares.InitSellerJWTMiddleware()
+	middlewares.InitServiceJWTMiddleware()
+	middlewares.InitDeviceID()
+	paymentProvider := payment.Init()
+	rates.Init()
+	if imageStore, isLocal := storage.Init().(*storage.LocalStore); isLocal {
+		r.Static("/api/user/images", imageStore.Dir)
+	}
+	storage.InitDocuments()
+	sms.Init()
+	notify.Register(&notify.SMSChannel{Gateway: sms.GetGateway()})
 	r.GET("/api/user/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-	r.GET("api/user/debug", getClaims)
 
 	customerRouter := r.Group("/api/user/customer")
 	customerRouter.POST("/login", controllers.BuyerLogin)
 	customerRouter.POST("/register", controllers.RegisterCustomer)
 	customerRouter.POST("/refresh_token", controllers.BuyerRefreshTokenHandler)
+	customerRouter.POST("/login/otp/request", controllers.RequestBuyerLoginOTP)
+	customerRouter.POST("/login/otp", controllers.BuyerOTPLogin)
+	customerRouter.POST("/phone", controllers.RequestBuyerPhoneVerification)
+	customerRouter.POST("/phone/verify", controllers.VerifyBuyerPhone)
+	customerRouter.POST("/mfa", controllers.RequestBuyerMFACode)
+	customerRouter.POST("/mfa/verify", controllers.VerifyBuyerMFACode)
 	customerRouter.GET("/profile", controllers.GetBuyerProfileHandler)
-	customerRouter.POST("/increase_balance", controllers.AddBuyerWalletBalance)
+	customerRouter.PATCH("/profile", controllers.UpdateBuyerProfile)
+	customerRouter.PUT("/profile/avatar", controllers.UploadBuyerAvatar)
+	customerRouter.DELETE("/profile/avatar", controllers.DeleteBuyerAvatar)
+	customerRouter.POST(
+		"/increase_balance",
+		middlewares.Idempotency(middlewares.CustomerIdempotencyScope),
+		controllers.AddBuyerWalletBalance,
+	)
+	customerRouter.GET("/wallet/transactions", controllers.GetBuyerWalletTransactions)
+	customerRouter.GET("/wallet/statements", controllers.GetBuyerWalletStatement)
+	customerRouter.POST("/wallets", controllers.OpenBuyerWallet)
+	customerRouter.GET("/referral", controllers.GetBuyerReferral)
+	customerRouter.POST("/addresses", controllers.CreateBuyerAddress)
+	customerRouter.GET("/addresses", controllers.ListBuyerAddresses)
+	customerRouter.GET("/addresses/:id", controllers.GetBuyerAddress)
+	customerRouter.PUT("/addresses/:id", controllers.UpdateBuyerAddress)
+	customerRouter.DELETE("/addresses/:id", controllers.DeleteBuyerAddress)
+	customerRouter.GET("/preferences", controllers.GetBuyerPreferences)
+	customerRouter.PUT("/preferences", controllers.UpdateBuyerPreferences)
+
+	paymentRouter := r.Group("/api/user/payments")
+	paymentRouter.POST("/webhook", controllers.PaymentWebhook)
+	if _, isFake := paymentProvider.(*payment.FakeProvider); isFake {
+		paymentRouter.POST("/fake/:reference", controllers.SimulateFakePayment)
+	}
 
 	sellerRouter := r.Group("/api/user/seller")
 	sellerRouter.POST("/login", controllers.SellerLogin)
 	sellerRouter.POST("/register", controllers.SellerRegister)
 	sellerRouter.POST("/refresh_token", controllers.SellerRefreshToken)
+	sellerRouter.POST("/login/otp/request", controllers.RequestSellerLoginOTP)
+	sellerRouter.POST("/login/otp", controllers.SellerOTPLogin)
+	sellerRouter.POST("/phone", controllers.RequestSellerPhoneVerification)
+	sellerRouter.POST("/phone/verify", controllers.VerifySellerPhone)
+	sellerRouter.POST("/mfa", controllers.RequestSellerMFACode)
+	sellerRouter.POST("/mfa/verify", controllers.VerifySellerMFACode)
 	sellerRouter.GET("/profile", controllers.GetSellerProfile)
+	sellerRouter.PATCH("/profile", controllers.UpdateSellerProfile)
+	sellerRouter.PUT("/profile/:kind", controllers.UploadSellerImage)
+	sellerRouter.DELETE("/profile/:kind", controllers.DeleteSellerImage)
+	sellerRouter.GET("/wallet/transactions", controllers.GetSellerWalletTransactions)
+	sellerRouter.GET("/wallet/statements", controllers.GetSellerWalletStatement)
+	sellerRouter.POST("/wallets", controllers.OpenSellerWallet)
+	sellerRouter.GET("/referral", controllers.GetSellerReferral)
+	sellerRouter.POST("/payout_destinations", controllers.CreatePayoutDestination)
+	sellerRouter.GET("/payout_destinations", controllers.ListPayoutDestinations)
+	sellerRouter.DELETE("/payout_destinations/:id", controllers.DeletePayoutDestination)
+	sellerRouter.POST(
+		"/payouts",
+		middlewares.Idempotency(middlewares.SellerIdempotencyScope),
+		controllers.RequestPayout,
+	)
+	sellerRouter.GET("/payouts", controllers.ListSellerPayouts)
+	sellerRouter.POST("/verification", controllers.SubmitSellerVerification)
+	sellerRouter.GET("/verification", controllers.GetSellerVerification)
+	sellerRouter.GET("/preferences", controllers.GetSellerPreferences)
+	sellerRouter.PUT("/preferences", controllers.UpdateSellerPreferences)
+
+	storefrontRouter := r.Group("/api/user/sellers")
+	storefrontRouter.GET("", controllers.ListSellers)
+	storefrontRouter.GET("/:id", controllers.GetSellerStorefront)
+
+	serviceRouter := r.Group("/api/user/service")
+	serviceRouter.POST(
+		"/wallet/debit",
+		middlewares.RequireServicePermission(enums.PermissionWalletDebit),
+		middlewares.Idempotency(middlewares.ServiceIdempotencyScope),
+		controllers.DebitBuyerWallet,
+	)
+	holdRouter := serviceRouter.Group(
+		"/wallet/holds",
+		middlewares.RequireServicePermission(enums.PermissionWalletHold),
+		middlewares.Idempotency(middlewares.ServiceIdempotencyScope),
+	)
+	holdRouter.POST("", controllers.AuthorizeWalletHold)
+	holdRouter.POST("/:id/capture", controllers.CaptureWalletHold)
+	holdRouter.POST("/:id/void", controllers.VoidWalletHold)
+	serviceRouter.POST(
+		"/wallet/settlements",
+		middlewares.RequireServicePermission(enums.PermissionWalletSettle),
+		middlewares.Idempotency(middlewares.ServiceIdempotencyScope),
+		controllers.SettleWallet,
+	)
+	serviceRouter.POST(
+		"/wallet/refunds",
+		middlewares.RequireServicePermission(enums.PermissionWalletRefund),
+		middlewares.Idempotency(middlewares.ServiceIdempotencyScope),
+		controllers.RefundWallet,
+	)
+	serviceRouter.POST(
+		"/wallet/promo_credits",
+		middlewares.RequireServicePermission(enums.PermissionPromoGrant),
+		middlewares.Idempotency(middlewares.ServiceIdempotencyScope),
+		controllers.GrantPromoCredit,
+	)
+	commissionRouter := serviceRouter.Group(
+		"/commission_rates",
+		middlewares.RequireServicePermission(enums.PermissionCommissionWrite),
+	)
+	commissionRouter.GET("", controllers.ListCommissionRates)
+	commissionRouter.PUT("", controllers.SaveCommissionRate)
+	payoutRouter := serviceRouter.Group(
+		"/payouts",
+		middlewares.RequireServicePermission(enums.PermissionPayoutApprove),
+	)
+	payoutRouter.GET("", controllers.ListPayoutsForReview)
+	payoutRouter.POST("/:id/approve", controllers.ApprovePayout)
+	payoutRouter.POST("/:id/reject", controllers.RejectPayout)
+	reviewRouter := serviceRouter.Group(
+		"/wallets",
+		middlewares.RequireServicePermission(enums.PermissionWalletReview),
+	)
+	reviewRouter.GET("/frozen", controllers.ListFrozenWallets)
+	reviewRouter.POST("/unfreeze", controllers.UnfreezeWallet)
+	verificationRouter := serviceRouter.Group(
+		"/verifications",
+		middlewares.RequireServicePermission(enums.PermissionSellerVerify),
+	)
+	verificationRouter.GET("", controllers.ListSellerVerifications)
+	verificationRouter.GET("/:seller_id", controllers.GetSellerVerificationForReview)
+	verificationRouter.GET("/:seller_id/documents/:document_id", controllers.DownloadVerificationDocument)
+	verificationRouter.POST("/:seller_id/approve", controllers.ApproveSellerVerification)
+	verificationRouter.POST("/:seller_id/reject", controllers.RejectSellerVerification)
+	serviceRouter.GET(
+		"/risk/decisions",
+		middlewares.RequireServicePermission(enums.PermissionWalletReview),
+		controllers.ListRiskDecisions,
+	)
+
+	jobs.Every(time.Minute, "release expired wallet holds", models.ReleaseExpiredHolds)
+	jobs.Every(time.Minute, "expire top-up intents", models.ExpireTopupIntents)
+	jobs.Every(time.Minute, "expire promo credits", models.ExpirePromoCredits)
+	jobs.Every(time.Minute, "reward referrals", models.RewardReferrals)
+	payoutProvider := payout.NewProviderFromEnv()
+	jobs.Every(payoutBatchInterval(), "process payout batch", func(c context.Context) error {
+		return models.ProcessPayoutBatch(c, payoutProvider)
+	})
 
 	if err := http.ListenAndServe(":"+port, r); err != nil {
 		log.Fatal(err)
 	}
 }
 
-func getClaims(c *gin.Context) {
-	claims := jwt.ExtractClaims(c)
-	for k, vals := range c.Request.Header {
-		fmt.Println("%s", k)
-		for _, v := range vals {
-			fmt.Println("\t%s", v)
-		}
+func payoutBatchInterval() time.Duration {
+	interval, err := time.ParseDuration(os.Getenv("PAYOUT_BATCH_INTERVAL"))
+	if err != nil || interval <= 0 {
+		return time.Hour
 	}
-	fmt.Println(claims)
-	c.Status(http.StatusNoContent)
-	return
+	return interval
 }
@@ -0,0 +1,88 @@
+package middlewares
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"log"
+	"os"
+	"strings"
+)
+
+// DeviceIDHeader carries the device ID the service issued to the client, in
+// responses when a new one is issued and in requests afterwards
+const DeviceIDHeader = "X-Device-ID"
+
+const (
+	deviceIDKey = "device_id"
+	// minDeviceSecretLength is the shortest key accepted for signing device
+	// IDs, the referral and risk guards trust them
+	minDeviceSecretLength = 32
+)
+
+var deviceSecret []byte
+
+// InitDeviceID refuses to start with a missing or short secret, anyone could
+// sign the device ID of somebody else with it
+func InitDeviceID() {
+	secret := os.Getenv("DEVICE_ID_SECRET")
+	if len(secret) < minDeviceSecretLength {
+		log.Fatalf("DEVICE_ID_SECRET must be at least %d bytes", minDeviceSecretLength)
+	}
+	deviceSecret = []byte(secret)
+}
+
+// DeviceID identifies the client device by an ID the service signed, so a
+// client cannot pick one to look like another device. A client sending none,
+// or one the service did not sign, gets a new ID in the response header and
+// should send it back from then on
+func DeviceID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := verifyDeviceID(c.GetHeader(DeviceIDHeader))
+		if !ok {
+			id = uuid.New().String()
+			c.Header(DeviceIDHeader, signDeviceID(id))
+		}
+		c.Set(deviceIDKey, id)
+		c.Next()
+	}
+}
+
+func signDeviceID(id string) string {
+	mac := hmac.New(sha256.New, deviceSecret)
+	mac.Write([]byte(id))
+	return id + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// verifyDeviceID returns the ID in a signed device ID
+func verifyDeviceID(signed string) (string, bool) {
+	dot := strings.LastIndex(signed, ".")
+	if dot <= 0 {
+		return "", false
+	}
+	id := signed[:dot]
+	if !hmac.Equal([]byte(signed), []byte(signDeviceID(id))) {
+		return "", false
+	}
+	return id, true
+}
+
+// GetDeviceID is the device ID checked by DeviceID, empty on routes without it
+func GetDeviceID(c *gin.Context) string {
+	return c.GetString(deviceIDKey)
+}
+
+// TrustedProxies are the networks of the ingress in front of the service,
+// from the comma separated TRUSTED_PROXIES. Only these may set the client IP
+// through X-Forwarded-For, with none the client IP is the peer address
+func TrustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
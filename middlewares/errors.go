@@ -0,0 +1,159 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"user-service/errs"
+	"user-service/forms"
+	"user-service/i18n"
+)
+
+const (
+	problemContentType = "application/problem+json"
+	problemTypePrefix  = "urn:problem:user-service:"
+)
+
+var (
+	errMissingBearerToken = errs.New(errs.ErrUnauthorized, i18n.CodeMissingBearerToken, "missing bearer token")
+	errMissingPermission  = errs.New(errs.ErrForbidden, i18n.CodeMissingPermission, "missing permission")
+	errIdempotencyReused  = errs.New(errs.ErrValidation, i18n.CodeIdempotencyKeyReused, "idempotency key was already used for a different request")
+	errIdempotencyPending = errs.New(errs.ErrConflict, i18n.CodeIdempotencyPending, "a request with this idempotency key is still in progress")
+)
+
+// kindStatuses is the HTTP status of every kind of domain error
+var kindStatuses = []struct {
+	kind   error
+	status int
+}{
+	{errs.ErrBadRequest, http.StatusBadRequest},
+	{errs.ErrUnauthorized, http.StatusUnauthorized},
+	{errs.ErrPaymentRequired, http.StatusPaymentRequired},
+	{errs.ErrForbidden, http.StatusForbidden},
+	{errs.ErrNotFound, http.StatusNotFound},
+	{errs.ErrConflict, http.StatusConflict},
+	{errs.ErrPreconditionFailed, http.StatusPreconditionFailed},
+	{errs.ErrTooLarge, http.StatusRequestEntityTooLarge},
+	{errs.ErrUnsupportedMedia, http.StatusUnsupportedMediaType},
+	{errs.ErrValidation, http.StatusUnprocessableEntity},
+	{errs.ErrLocked, http.StatusLocked},
+	{errs.ErrPreconditionRequired, http.StatusPreconditionRequired},
+	{errs.ErrTooManyRequests, http.StatusTooManyRequests},
+	{errs.ErrUpstream, http.StatusBadGateway},
+	{errs.ErrUnavailable, http.StatusServiceUnavailable},
+}
+
+// ErrorHandler answers the last error a handler recorded with c.Error as a
+// problem, unless the handler already wrote a response
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		writeProblem(c)
+	}
+}
+
+// classify finds the status and catalog code of err. Errors that are not
+// domain errors and not caused by the request are internal errors
+func classify(err error) (int, string) {
+	var domainError *errs.Error
+	if errors.As(err, &domainError) {
+		for _, kind := range kindStatuses {
+			if errors.Is(domainError.Kind, kind.kind) {
+				return kind.status, domainError.Code
+			}
+		}
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return http.StatusNotFound, i18n.CodeNotFound
+	}
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return http.StatusUnauthorized, i18n.CodeAuthenticationFailed
+	}
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		return http.StatusUnprocessableEntity, i18n.CodeValidationFailed
+	}
+	var jwtError *jwt.ValidationError
+	if errors.As(err, &jwtError) {
+		return http.StatusUnauthorized, i18n.CodeInvalidToken
+	}
+	var syntaxError *json.SyntaxError
+	var typeError *json.UnmarshalTypeError
+	var numError *strconv.NumError
+	var timeError *time.ParseError
+	if errors.As(err, &syntaxError) || errors.As(err, &typeError) ||
+		errors.As(err, &numError) || errors.As(err, &timeError) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return http.StatusBadRequest, i18n.CodeInvalidBody
+	}
+	return http.StatusInternalServerError, i18n.CodeInternalError
+}
+
+// writeProblem answers the last recorded error, it does nothing when there is
+// none or a response was already written. The text of the error only goes to
+// the log
+func writeProblem(c *gin.Context) {
+	if len(c.Errors) == 0 || c.Writer.Written() {
+		return
+	}
+	err := c.Errors.Last().Err
+	status, code := classify(err)
+	problem := NewProblem(c, status, code)
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		problem.Fields = i18n.FieldErrors(GetLocale(c), validationErrors)
+	}
+	if status >= http.StatusInternalServerError {
+		log.Printf("trace %s: %s %s: %v", problem.TraceID, c.Request.Method, c.Request.URL.Path, err)
+	}
+	WriteProblem(c, status, problem)
+}
+
+// NewProblem builds the problem for a catalog code in the locale of the
+// request, args fill in the template of the code
+func NewProblem(c *gin.Context, status int, code string, args ...interface{}) forms.ProblemResponse {
+	return forms.ProblemResponse{
+		Type:     problemTypePrefix + code,
+		Title:    i18n.Message(GetLocale(c), code, args...),
+		Status:   status,
+		Instance: c.Request.URL.Path,
+		Code:     code,
+		TraceID:  traceID(c),
+	}
+}
+
+// WriteProblem aborts with body as application/problem+json, body is a
+// ProblemResponse or a response embedding one
+func WriteProblem(c *gin.Context, status int, body interface{}) {
+	c.Header("Content-Type", problemContentType)
+	c.Header("Content-Language", GetLocale(c))
+	c.AbortWithStatusJSON(status, body)
+}
+
+// traceID is the id of the request trace, a random id of the same shape when
+// the request is not traced
+func traceID(c *gin.Context) string {
+	spanContext := trace.SpanContextFromContext(c.Request.Context())
+	if spanContext.HasTraceID() {
+		return spanContext.TraceID().String()
+	}
+	return strings.ReplaceAll(uuid.New().String(), "-", "")
+}
+
+// abortWithError records err for ErrorHandler and stops the chain
+func abortWithError(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
@@ -0,0 +1,176 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/golang-jwt/jwt/v4"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"user-service/enums"
+	"user-service/errs"
+	"user-service/forms"
+	"user-service/i18n"
+)
+
+func TestClassify(t *testing.T) {
+	type tested struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+	}
+	var tests []tested
+	// Every kind of domain error, also when wrapped on the way up
+	for _, kind := range kindStatuses {
+		err := errs.New(kind.kind, i18n.CodeBadRequest, "domain error")
+		tests = append(tests,
+			tested{kind.kind.Error(), err, kind.status, i18n.CodeBadRequest},
+			tested{"wrapped " + kind.kind.Error(), fmt.Errorf("handler: %w", err), kind.status, i18n.CodeBadRequest},
+		)
+	}
+	var validationErrors validator.ValidationErrors
+	errors.As(validator.New().Struct(struct {
+		Name string `validate:"required"`
+	}{}), &validationErrors)
+	_, numError := strconv.Atoi("x")
+	tests = append(tests,
+		tested{"record not found", fmt.Errorf("retrieve: %w", gorm.ErrRecordNotFound), http.StatusNotFound, i18n.CodeNotFound},
+		tested{"wrong password", bcrypt.ErrMismatchedHashAndPassword, http.StatusUnauthorized, i18n.CodeAuthenticationFailed},
+		tested{"validation", validationErrors, http.StatusUnprocessableEntity, i18n.CodeValidationFailed},
+		tested{"invalid token", &jwt.ValidationError{Errors: jwt.ValidationErrorExpired}, http.StatusUnauthorized, i18n.CodeInvalidToken},
+		tested{"malformed json", json.Unmarshal([]byte("{"), &struct{}{}), http.StatusBadRequest, i18n.CodeInvalidBody},
+		tested{"wrong json type", json.Unmarshal([]byte(`{"a":1}`), &struct{ A string }{}), http.StatusBadRequest, i18n.CodeInvalidBody},
+		tested{"empty body", io.EOF, http.StatusBadRequest, i18n.CodeInvalidBody},
+		tested{"not a number", numError, http.StatusBadRequest, i18n.CodeInvalidBody},
+		tested{"database error", gorm.ErrInvalidTransaction, http.StatusInternalServerError, i18n.CodeInternalError},
+		tested{"unknown error", errors.New("boom"), http.StatusInternalServerError, i18n.CodeInternalError},
+	)
+	for _, test := range tests {
+		status, code := classify(test.err)
+		if status != test.wantStatus || code != test.wantCode {
+			t.Errorf("%s: classify = %d %s, want %d %s", test.name, status, code, test.wantStatus, test.wantCode)
+		}
+	}
+}
+
+var traceIDPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// newProblemRouter answers GET /problem with handler behind ErrorHandler
+func newProblemRouter(handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ErrorHandler())
+	router.GET("/problem", handler)
+	return router
+}
+
+func TestWriteProblem(t *testing.T) {
+	internalText := `relation "buyer_wallets" does not exist`
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{"domain error", errs.New(errs.ErrConflict, i18n.CodeWalletExists, "wallet already exists"), http.StatusConflict, i18n.CodeWalletExists},
+		{"internal error", fmt.Errorf("%s: %w", internalText, gorm.ErrInvalidDB), http.StatusInternalServerError, i18n.CodeInternalError},
+	}
+	for _, test := range tests {
+		router := newProblemRouter(func(c *gin.Context) {
+			_ = c.Error(test.err)
+		})
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/problem", nil))
+
+		if recorder.Code != test.wantStatus {
+			t.Errorf("%s: status %d, want %d", test.name, recorder.Code, test.wantStatus)
+		}
+		if contentType := recorder.Header().Get("Content-Type"); contentType != problemContentType {
+			t.Errorf("%s: Content-Type %q, want %q", test.name, contentType, problemContentType)
+		}
+		if language := recorder.Header().Get("Content-Language"); language != enums.DefaultLocale {
+			t.Errorf("%s: Content-Language %q, want %q", test.name, language, enums.DefaultLocale)
+		}
+		if strings.Contains(recorder.Body.String(), internalText) {
+			t.Errorf("%s: body leaks the error: %s", test.name, recorder.Body)
+		}
+		var problem forms.ProblemResponse
+		if err := json.Unmarshal(recorder.Body.Bytes(), &problem); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		want := forms.ProblemResponse{
+			Type:     problemTypePrefix + test.wantCode,
+			Title:    i18n.Message(enums.DefaultLocale, test.wantCode),
+			Status:   test.wantStatus,
+			Instance: "/problem",
+			Code:     test.wantCode,
+			TraceID:  problem.TraceID,
+		}
+		if !reflect.DeepEqual(problem, want) {
+			t.Errorf("%s: problem %+v, want %+v", test.name, problem, want)
+		}
+		if !traceIDPattern.MatchString(problem.TraceID) {
+			t.Errorf("%s: trace id %q", test.name, problem.TraceID)
+		}
+	}
+}
+
+// A traced request quotes the id of its trace, support finds it in the logs
+func TestWriteProblemTraceID(t *testing.T) {
+	traceID := trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36}
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: trace.FlagsSampled,
+	})
+	router := newProblemRouter(func(c *gin.Context) {
+		_ = c.Error(errs.New(errs.ErrNotFound, i18n.CodeNotFound, "not found"))
+	})
+	request := httptest.NewRequest(http.MethodGet, "/problem", nil)
+	request = request.WithContext(trace.ContextWithSpanContext(request.Context(), spanContext))
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	var problem forms.ProblemResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &problem); err != nil {
+		t.Fatal(err)
+	}
+	if problem.TraceID != traceID.String() {
+		t.Errorf("trace id %q, want %q", problem.TraceID, traceID)
+	}
+}
+
+// ErrorHandler leaves alone a response the handler wrote itself
+func TestWriteProblemKeepsWrittenResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler gin.HandlerFunc
+	}{
+		{"no error", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"ok": true})
+		}},
+		{"error after writing", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"ok": true})
+			_ = c.Error(errors.New("late"))
+		}},
+	}
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		newProblemRouter(test.handler).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/problem", nil))
+		if recorder.Code != http.StatusOK || recorder.Body.String() != `{"ok":true}` {
+			t.Errorf("%s: got %d %s", test.name, recorder.Code, recorder.Body)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"user-service/enums"
+	"user-service/errs"
+	"user-service/i18n"
+	"user-service/models"
+)
+
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+const (
+	idempotencyCommittedKey = "idempotency_committed"
+	idempotencyRetryableKey = "idempotency_retryable"
+	// idempotencyStoreTimeout bounds recording the outcome of a request, it
+	// runs after the client may have gone
+	idempotencyStoreTimeout = 5 * time.Second
+)
+
+type IdempotencyScopeFunc func(c *gin.Context) (string, error)
+
+type responseRecorder struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency replays the stored response when a request is retried with the
+// same Idempotency-Key header. Reusing a key with a different request returns 422.
+// Requests without the header are passed through untouched. A server error
+// frees the key for a retry unless the handler called MarkCommitted first, as
+// does a response the handler marked with MarkRetryable
+func Idempotency(scope IdempotencyScopeFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		scopeName, err := scope(c)
+		if err != nil {
+			// Let the handler reject the unauthenticated request
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			abortWithError(c, errs.Wrap(errs.ErrBadRequest, i18n.CodeBadRequest, err))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.New()
+		hash.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n"))
+		hash.Write(body)
+
+		record := models.IdempotencyKey{
+			Key:         key,
+			Scope:       scopeName,
+			RequestHash: hex.EncodeToString(hash.Sum(nil)),
+		}
+		requestHash := record.RequestHash
+		isNew, err := record.Reserve(c.Request.Context())
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+		if !isNew {
+			if record.RequestHash != requestHash {
+				abortWithError(c, errIdempotencyReused)
+				return
+			}
+			if !record.Completed {
+				abortWithError(c, errIdempotencyPending)
+				return
+			}
+			c.Header("Idempotent-Replayed", "true")
+			if record.ResponseContentLanguage != "" {
+				c.Header("Content-Language", record.ResponseContentLanguage)
+			}
+			contentType := record.ResponseContentType
+			if contentType == "" {
+				// Stored before content types were kept, only JSON was
+				contentType = "application/json; charset=utf-8"
+			}
+			c.Data(record.ResponseStatus, contentType, record.ResponseBody)
+			c.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = recorder
+		// Deferred so a panicking handler releases the key too, it would stay
+		// pending and block every retry otherwise. Recovery answers the panic
+		// with a 500 like any other server error
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				if !IsCommitted(c) {
+					releaseIdempotencyKey(record)
+				}
+				panic(recovered)
+			}
+			if c.GetBool(idempotencyRetryableKey) || recorder.Status() >= http.StatusInternalServerError && !IsCommitted(c) {
+				releaseIdempotencyKey(record)
+				return
+			}
+			completeIdempotencyKey(record, recorder)
+		}()
+		c.Next()
+		// Answer errors now, the status decides whether the key is kept
+		writeProblem(c)
+	}
+}
+
+// MarkCommitted tells Idempotency the handler committed its change. A server
+// error after that is stored and replayed like any other response, releasing
+// the key would let a retry apply the change twice
+func MarkCommitted(c *gin.Context) {
+	c.Set(idempotencyCommittedKey, true)
+}
+
+func IsCommitted(c *gin.Context) bool {
+	return c.GetBool(idempotencyCommittedKey)
+}
+
+// MarkRetryable tells Idempotency to free the key instead of storing the
+// response, the handler committed nothing and the same request may pass when
+// retried, like a risk rejection the client answers with a second factor
+func MarkRetryable(c *gin.Context) {
+	c.Set(idempotencyRetryableKey, true)
+}
+
+// The outcome is stored with a context of its own, the request context is
+// cancelled when the client times out and that is when it retries
+func releaseIdempotencyKey(record models.IdempotencyKey) {
+	c, cancel := context.WithTimeout(context.Background(), idempotencyStoreTimeout)
+	defer cancel()
+	if err := record.Release(c); err != nil {
+		log.Printf("failed to release idempotency key %s of %s: %v", record.Key, record.Scope, err)
+	}
+}
+
+func completeIdempotencyKey(record models.IdempotencyKey, recorder *responseRecorder) {
+	c, cancel := context.WithTimeout(context.Background(), idempotencyStoreTimeout)
+	defer cancel()
+	header := recorder.Header()
+	if err := record.Complete(c, recorder.Status(), header.Get("Content-Type"), header.Get("Content-Language"), recorder.body.Bytes()); err != nil {
+		log.Printf("failed to complete idempotency key %s of %s: %v", record.Key, record.Scope, err)
+	}
+}
+
+func CustomerIdempotencyScope(c *gin.Context) (string, error) {
+	authHeader := c.GetHeader("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		return "", errors.New("missing bearer token")
+	}
+	userID, err := GetCustomerJwtMiddleware().GetUserIDFromToken(authHeader[len("Bearer "):])
+	if err != nil {
+		return "", err
+	}
+	return enums.Buyer + ":" + userID.String(), nil
+}
+
+// ServiceIdempotencyScope must run after RequireServicePermission
+func ServiceIdempotencyScope(c *gin.Context) (string, error) {
+	claims, ok := GetServiceClaims(c)
+	if !ok {
+		return "", errors.New("missing service claims")
+	}
+	return "service:" + claims.Name, nil
+}
+
+func SellerIdempotencyScope(c *gin.Context) (string, error) {
+	authHeader := c.GetHeader("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		return "", errors.New("missing bearer token")
+	}
+	userID, err := GetSellerJwtMiddleware().GetUserIDFromToken(authHeader[len("Bearer "):])
+	if err != nil {
+		return "", err
+	}
+	return enums.Seller + ":" + userID.String(), nil
+}
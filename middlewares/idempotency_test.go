@@ -0,0 +1,73 @@
+package middlewares
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"user-service/errs"
+	"user-service/i18n"
+)
+
+// Idempotency answers errors itself, the status decides whether the key is
+// kept. A client error is stored and replayed as the same problem, a server
+// error frees the key
+func TestIdempotencyAnswersProblems(t *testing.T) {
+	requireDB(t)
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantReplay bool
+	}{
+		{"client error", errs.New(errs.ErrPaymentRequired, i18n.CodeInsufficientFunds, "insufficient funds"), http.StatusPaymentRequired, true},
+		{"server error", errs.New(errs.ErrUnavailable, i18n.CodeTopupsDisabled, "top-ups are disabled"), http.StatusServiceUnavailable, false},
+	}
+	for _, test := range tests {
+		calls := 0
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.Use(ErrorHandler())
+		scope := "test-" + uuid.New().String()
+		router.POST("/problem", Idempotency(func(c *gin.Context) (string, error) {
+			return scope, nil
+		}), func(c *gin.Context) {
+			calls++
+			_ = c.Error(test.err)
+		})
+
+		var first *httptest.ResponseRecorder
+		for attempt := 0; attempt < 2; attempt++ {
+			request := httptest.NewRequest(http.MethodPost, "/problem", nil)
+			request.Header.Set(IdempotencyKeyHeader, "key")
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			if recorder.Code != test.wantStatus {
+				t.Errorf("%s: attempt %d status %d, want %d", test.name, attempt, recorder.Code, test.wantStatus)
+			}
+			if contentType := recorder.Header().Get("Content-Type"); contentType != problemContentType {
+				t.Errorf("%s: attempt %d Content-Type %q, want %q", test.name, attempt, contentType, problemContentType)
+			}
+			if first == nil {
+				first = recorder
+				continue
+			}
+			replayed := recorder.Header().Get("Idempotent-Replayed") == "true"
+			if replayed != test.wantReplay {
+				t.Errorf("%s: replayed %t, want %t", test.name, replayed, test.wantReplay)
+			}
+			if replayed && recorder.Body.String() != first.Body.String() {
+				t.Errorf("%s: replayed %s, want %s", test.name, recorder.Body, first.Body)
+			}
+		}
+		wantCalls := 2
+		if test.wantReplay {
+			wantCalls = 1
+		}
+		if calls != wantCalls {
+			t.Errorf("%s: handler ran %d times, want %d", test.name, calls, wantCalls)
+		}
+	}
+}
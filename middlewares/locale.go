@@ -0,0 +1,51 @@
+package middlewares
+
+import (
+	"github.com/gin-gonic/gin"
+	"strings"
+	"user-service/enums"
+	"user-service/i18n"
+)
+
+const localeKey = "locale"
+
+// Locale picks the language of the messages in a response. The locale saved
+// in the user preferences as carried by the access token wins, a user picks
+// it once for every device. Requests without one use a supported language in
+// Accept-Language, then the default
+func Locale() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		locale := tokenLocale(c.GetHeader("Authorization"))
+		if locale == "" {
+			locale = i18n.ParseAcceptLanguage(c.GetHeader("Accept-Language"))
+		}
+		// Caches must not serve a response in one language to a request in
+		// another
+		c.Writer.Header().Add("Vary", "Accept-Language")
+		c.Set(localeKey, i18n.Resolve(locale))
+		c.Next()
+	}
+}
+
+// tokenLocale reads the locale of a buyer or seller access token
+func tokenLocale(authHeader string) string {
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		return ""
+	}
+	tokenString := authHeader[len("Bearer "):]
+	if locale, ok := GetCustomerJwtMiddleware().GetLocaleFromToken(tokenString); ok {
+		return locale
+	}
+	if locale, ok := GetSellerJwtMiddleware().GetLocaleFromToken(tokenString); ok {
+		return locale
+	}
+	return ""
+}
+
+// GetLocale is the locale chosen by Locale, the default on routes without it
+func GetLocale(c *gin.Context) string {
+	if locale, ok := c.Get(localeKey); ok {
+		return locale.(string)
+	}
+	return enums.DefaultLocale
+}
@@ -0,0 +1,31 @@
+package middlewares
+
+import (
+	"log"
+	"os"
+	"testing"
+	"user-service/db"
+	"user-service/models"
+)
+
+// hasTestDB is set when TEST_DATABASE_DSN points at a Postgres database the
+// tests may write to. Tests that need it call requireDB and are skipped
+// otherwise
+var hasTestDB bool
+
+func TestMain(m *testing.M) {
+	if dsn := os.Getenv("TEST_DATABASE_DSN"); dsn != "" {
+		if err := db.Open(dsn).AutoMigrate(&models.IdempotencyKey{}); err != nil {
+			log.Fatal(err)
+		}
+		hasTestDB = true
+	}
+	os.Exit(m.Run())
+}
+
+func requireDB(t *testing.T) {
+	t.Helper()
+	if !hasTestDB {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+}
@@ -0,0 +1,71 @@
+package middlewares
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"log"
+	"os"
+	"strings"
+	"time"
+	"user-service/service"
+)
+
+const serviceClaimsKey = "service_claims"
+
+// minServiceSecretLength is the shortest HS256 key accepted for service
+// tokens, they grant wallet debits
+const minServiceSecretLength = 32
+
+var serviceTokenService *service.TokenService
+
+// InitServiceJWTMiddleware refuses to start with a missing or short secret,
+// anyone could mint service tokens with it
+func InitServiceJWTMiddleware() *service.TokenService {
+	secretKey := os.Getenv("JWT_SERVICE_SECRET_KEY")
+	if len(secretKey) < minServiceSecretLength {
+		log.Fatalf("JWT_SERVICE_SECRET_KEY must be at least %d bytes", minServiceSecretLength)
+	}
+	iss := os.Getenv("JWT_SERVICE_ISS")
+	serviceTokenService = &service.TokenService{
+		ISS:              iss,
+		SecretKey:        []byte(secretKey),
+		AccessExpireTime: time.Minute * 15,
+	}
+	return serviceTokenService
+}
+
+func GetServiceJwtMiddleware() *service.TokenService {
+	return serviceTokenService
+}
+
+// RequireServicePermission only lets through service clients whose token grants
+// the given permission
+func RequireServicePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		if !strings.HasPrefix(authHeader, "Bearer ") {
+			abortWithError(c, errMissingBearerToken)
+			return
+		}
+		claims, err := GetServiceJwtMiddleware().ValidateServiceToken(authHeader[len("Bearer "):])
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+		if !claims.HasPermission(permission) {
+			abortWithError(c, fmt.Errorf("%w %s", errMissingPermission, permission))
+			return
+		}
+		c.Set(serviceClaimsKey, claims)
+		c.Next()
+	}
+}
+
+func GetServiceClaims(c *gin.Context) (service.ServiceClaims, bool) {
+	value, ok := c.Get(serviceClaimsKey)
+	if !ok {
+		return service.ServiceClaims{}, false
+	}
+	claims, ok := value.(service.ServiceClaims)
+	return claims, ok
+}
@@ -0,0 +1,260 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"regexp"
+	"strings"
+	"time"
+	"user-service/db"
+	"user-service/errs"
+	"user-service/i18n"
+)
+
+const maxBuyerAddresses = 20
+
+var (
+	ErrInvalidPostalCode = errs.New(errs.ErrValidation, i18n.CodeInvalidPostalCode, "postal code does not match the country format")
+	ErrTooManyAddresses  = errs.New(errs.ErrConflict, i18n.CodeTooManyAddresses, "address book is full")
+)
+
+// postalCodePatterns are the postal code formats of the countries we know,
+// codes are upper cased before matching
+var postalCodePatterns = map[string]*regexp.Regexp{
+	"AU": regexp.MustCompile(`^\d{4}$`),
+	"CA": regexp.MustCompile(`^[A-Z]\d[A-Z] ?\d[A-Z]\d$`),
+	"CN": regexp.MustCompile(`^\d{6}$`),
+	"DE": regexp.MustCompile(`^\d{5}$`),
+	"FR": regexp.MustCompile(`^\d{5}$`),
+	"GB": regexp.MustCompile(`^[A-Z]{1,2}\d[A-Z\d]? ?\d[A-Z]{2}$`),
+	"ID": regexp.MustCompile(`^\d{5}$`),
+	"IN": regexp.MustCompile(`^\d{6}$`),
+	"JP": regexp.MustCompile(`^\d{3}-?\d{4}$`),
+	"KH": regexp.MustCompile(`^\d{5,6}$`),
+	"KR": regexp.MustCompile(`^\d{5}$`),
+	"LA": regexp.MustCompile(`^\d{5}$`),
+	"MM": regexp.MustCompile(`^\d{5}$`),
+	"MY": regexp.MustCompile(`^\d{5}$`),
+	"NL": regexp.MustCompile(`^\d{4} ?[A-Z]{2}$`),
+	"PH": regexp.MustCompile(`^\d{4}$`),
+	"SG": regexp.MustCompile(`^\d{6}$`),
+	"TH": regexp.MustCompile(`^\d{5}$`),
+	"TW": regexp.MustCompile(`^\d{3}(\d{2,3})?$`),
+	"US": regexp.MustCompile(`^\d{5}(-\d{4})?$`),
+	"VN": regexp.MustCompile(`^\d{6}$`),
+}
+
+// noPostalCode lists countries without postal codes, the field must be empty
+var noPostalCode = map[string]bool{
+	"AE": true,
+	"HK": true,
+	"MO": true,
+	"QA": true,
+}
+
+// genericPostalCode accepts any plausible code for countries not listed above
+var genericPostalCode = regexp.MustCompile(`^[A-Z\d][A-Z\d -]{1,8}[A-Z\d]$`)
+
+type BuyerAddress struct {
+	gorm.Model
+	// The partial unique index allows a single default address of each type
+	BuyerID       uuid.UUID `gorm:"type:uuid;primaryKey;uniqueIndex:buyer_address_default,where:is_default AND deleted_at IS NULL"`
+	Type          string    `gorm:"uniqueIndex:buyer_address_default"`
+	Label         string
+	RecipientName string
+	Phone         string
+	Line1         string
+	Line2         string
+	City          string
+	State         string
+	PostalCode    string
+	Country       string `gorm:"size:2"`
+	IsDefault     bool   `gorm:"default:false;not null"`
+}
+
+// ValidPostalCode tells whether code is a postal code of country, both upper
+// cased
+func ValidPostalCode(country string, code string) bool {
+	if noPostalCode[country] {
+		return code == ""
+	}
+	if pattern, ok := postalCodePatterns[country]; ok {
+		return pattern.MatchString(code)
+	}
+	return genericPostalCode.MatchString(code)
+}
+
+// normalize trims the fields and checks the postal code against the country
+func (a *BuyerAddress) normalize() error {
+	for _, field := range []*string{&a.Label, &a.RecipientName, &a.Phone, &a.Line1, &a.Line2, &a.City, &a.State} {
+		*field = strings.TrimSpace(*field)
+	}
+	a.Country = strings.ToUpper(strings.TrimSpace(a.Country))
+	a.PostalCode = strings.ToUpper(strings.TrimSpace(a.PostalCode))
+	if !ValidPostalCode(a.Country, a.PostalCode) {
+		return ErrInvalidPostalCode
+	}
+	return nil
+}
+
+func (u *Buyer) ListAddresses(c context.Context, addressType string) ([]BuyerAddress, error) {
+	tx := db.GetDB(c).Where("buyer_id = ? AND deleted_at IS NULL", u.ID)
+	if addressType != "" {
+		tx = tx.Where("type = ?", addressType)
+	}
+	var addresses []BuyerAddress
+	if err := tx.Order("is_default DESC").Order("id").Find(&addresses).Error; err != nil {
+		return nil, err
+	}
+	return addresses, nil
+}
+
+func (u *Buyer) RetrieveAddress(c context.Context, id uint) (*BuyerAddress, error) {
+	address := BuyerAddress{}
+	if err := db.GetDB(c).
+		Where("id = ? AND buyer_id = ? AND deleted_at IS NULL", id, u.ID).
+		First(&address).Error; err != nil {
+		return nil, err
+	}
+	return &address, nil
+}
+
+// CreateAddress adds an address to the book, the first address of a type
+// becomes its default
+func (u *Buyer) CreateAddress(c context.Context, address *BuyerAddress) error {
+	address.BuyerID = u.ID
+	if err := address.normalize(); err != nil {
+		return err
+	}
+	return db.GetDB(c).Transaction(func(tx *gorm.DB) error {
+		var addresses []BuyerAddress
+		if err := tx.
+			Where("buyer_id = ? AND deleted_at IS NULL", u.ID).
+			Find(&addresses).Error; err != nil {
+			return err
+		}
+		if len(addresses) >= maxBuyerAddresses {
+			return ErrTooManyAddresses
+		}
+		hasDefault := false
+		for _, existing := range addresses {
+			if existing.Type == address.Type && existing.IsDefault {
+				hasDefault = true
+			}
+		}
+		if !hasDefault {
+			address.IsDefault = true
+		}
+		if address.IsDefault {
+			if err := clearDefaultAddress(tx, u.ID, address.Type, 0); err != nil {
+				return err
+			}
+		}
+		return tx.Create(address).Error
+	})
+}
+
+// UpdateAddress replaces every field of the address with the ones of update.
+// Every type keeps a default: when the default is unset or moves to another
+// type the most recent address left takes over, and an address becomes the
+// default of its type when there is no other
+func (u *Buyer) UpdateAddress(c context.Context, id uint, update BuyerAddress) (*BuyerAddress, error) {
+	if err := update.normalize(); err != nil {
+		return nil, err
+	}
+	address, err := u.RetrieveAddress(c, id)
+	if err != nil {
+		return nil, err
+	}
+	err = db.GetDB(c).Transaction(func(tx *gorm.DB) error {
+		if address.IsDefault && (!update.IsDefault || update.Type != address.Type) {
+			// Unset first, the unique index allows one default per type
+			if err := clearDefaultAddress(tx, u.ID, address.Type, 0); err != nil {
+				return err
+			}
+			promoted, err := promoteDefaultAddress(tx, u.ID, address.Type, id)
+			if err != nil {
+				return err
+			}
+			if !promoted && update.Type == address.Type {
+				update.IsDefault = true
+			}
+		}
+		if !update.IsDefault {
+			var defaults int64
+			if err := tx.
+				Model(&BuyerAddress{}).
+				Where("buyer_id = ? AND type = ? AND is_default AND deleted_at IS NULL AND id <> ?", u.ID, update.Type, id).
+				Count(&defaults).Error; err != nil {
+				return err
+			}
+			update.IsDefault = defaults == 0
+		}
+		if update.IsDefault {
+			if err := clearDefaultAddress(tx, u.ID, update.Type, id); err != nil {
+				return err
+			}
+		}
+		return tx.
+			Model(address).
+			Where("buyer_id = ?", u.ID).
+			Select("Type", "Label", "RecipientName", "Phone", "Line1", "Line2", "City", "State", "PostalCode", "Country", "IsDefault").
+			Updates(update).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return u.RetrieveAddress(c, id)
+}
+
+// DeleteAddress soft deletes the address, when it was the default the most
+// recent address of the same type takes over
+func (u *Buyer) DeleteAddress(c context.Context, id uint) error {
+	address, err := u.RetrieveAddress(c, id)
+	if err != nil {
+		return err
+	}
+	return db.GetDB(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Model(&BuyerAddress{}).
+			Where("id = ? AND buyer_id = ?", id, u.ID).
+			Updates(map[string]interface{}{"deleted_at": time.Now(), "is_default": false}).Error; err != nil {
+			return err
+		}
+		if !address.IsDefault {
+			return nil
+		}
+		_, err := promoteDefaultAddress(tx, u.ID, address.Type, id)
+		return err
+	})
+}
+
+// promoteDefaultAddress makes the most recent address of a type other than
+// except its default. It reports false when there is none
+func promoteDefaultAddress(tx *gorm.DB, buyerID uuid.UUID, addressType string, except uint) (bool, error) {
+	var next BuyerAddress
+	err := tx.
+		Where("buyer_id = ? AND type = ? AND deleted_at IS NULL AND id <> ?", buyerID, addressType, except).
+		Order("id DESC").
+		First(&next).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, tx.
+		Model(&next).
+		Where("buyer_id = ?", buyerID).
+		Update("is_default", true).Error
+}
+
+// clearDefaultAddress unsets the default address of a type, except keep
+func clearDefaultAddress(tx *gorm.DB, buyerID uuid.UUID, addressType string, keep uint) error {
+	return tx.
+		Model(&BuyerAddress{}).
+		Where("buyer_id = ? AND type = ? AND is_default AND id <> ?", buyerID, addressType, keep).
+		Update("is_default", false).Error
+}
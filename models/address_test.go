@@ -0,0 +1,80 @@
+package models
+
+import (
+	"context"
+	"testing"
+	"user-service/enums"
+)
+
+func testAddress(addressType string) *BuyerAddress {
+	return &BuyerAddress{
+		Type:          addressType,
+		RecipientName: "Test Buyer",
+		Line1:         "1 Sukhumvit Road",
+		City:          "Bangkok",
+		PostalCode:    "10110",
+		Country:       "TH",
+	}
+}
+
+// Every type with addresses keeps exactly one default through updates
+func TestUpdateAddressKeepsDefault(t *testing.T) {
+	requireDB(t)
+	tests := []struct {
+		name string
+		// shipping addresses to create, the first one is the default
+		shipping int
+		// update of the first address
+		updateType    string
+		updateDefault bool
+		// defaults expected afterwards, by type
+		wantShipping bool
+		wantBilling  bool
+		// whether the first address is still a default
+		wantFirstDefault bool
+	}{
+		{"unset the only default", 1, enums.AddressShipping, false, true, false, true},
+		{"unset hands the default over", 2, enums.AddressShipping, false, true, false, false},
+		{"type change hands the default over", 2, enums.AddressBilling, false, true, true, true},
+		{"type change of the only address", 1, enums.AddressBilling, false, false, true, true},
+		{"type change keeping the default flag", 2, enums.AddressBilling, true, true, true, true},
+	}
+	c := context.Background()
+	for _, test := range tests {
+		buyer := newTestBuyer(t, "0")
+		var first *BuyerAddress
+		for i := 0; i < test.shipping; i++ {
+			address := testAddress(enums.AddressShipping)
+			if err := buyer.CreateAddress(c, address); err != nil {
+				t.Fatal(err)
+			}
+			if first == nil {
+				first = address
+			}
+		}
+		update := *testAddress(test.updateType)
+		update.IsDefault = test.updateDefault
+		updated, err := buyer.UpdateAddress(c, first.ID, update)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if updated.IsDefault != test.wantFirstDefault {
+			t.Errorf("%s: updated address default = %v, want %v", test.name, updated.IsDefault, test.wantFirstDefault)
+		}
+		for addressType, want := range map[string]bool{enums.AddressShipping: test.wantShipping, enums.AddressBilling: test.wantBilling} {
+			addresses, err := buyer.ListAddresses(c, addressType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defaults := 0
+			for _, address := range addresses {
+				if address.IsDefault {
+					defaults++
+				}
+			}
+			if want && defaults != 1 || !want && defaults != 0 {
+				t.Errorf("%s: %d %s defaults, want one: %v", test.name, defaults, addressType, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"os"
+	"user-service/db"
+	"user-service/errs"
+	"user-service/i18n"
+)
+
+var ErrInvalidCommissionRate = errs.New(errs.ErrValidation, i18n.CodeInvalidCommissionRate, "commission rate must be between 0 and 1")
+
+// CommissionRate overrides the default platform commission for a seller, a
+// category or a seller within a category. A nil SellerID or an empty Category
+// matches any. It is a Citus reference table so the lookup is local to every shard
+type CommissionRate struct {
+	gorm.Model
+	SellerID uuid.UUID       `gorm:"type:uuid;uniqueIndex:commission_rate_scope"`
+	Category string          `gorm:"uniqueIndex:commission_rate_scope"`
+	Rate     decimal.Decimal `gorm:"type:decimal(5,4);"`
+}
+
+// defaultCommissionRate is the share of a sale kept by the platform, e.g. 0.05
+func defaultCommissionRate() decimal.Decimal {
+	rate, err := decimal.NewFromString(os.Getenv("PLATFORM_FEE_RATE"))
+	if err != nil || rate.IsNegative() {
+		return decimal.Zero
+	}
+	return rate
+}
+
+// resolveCommissionRate picks the most specific rate: seller and category,
+// then seller, then category, then the platform default
+func resolveCommissionRate(tx *gorm.DB, sellerID uuid.UUID, category string) (decimal.Decimal, error) {
+	var rates []CommissionRate
+	if err := tx.
+		Where("seller_id IN (?, ?) AND category IN (?, '')", sellerID, uuid.Nil, category).
+		Find(&rates).Error; err != nil {
+		return decimal.Zero, err
+	}
+	best := -1
+	bestScore := -1
+	for i, rate := range rates {
+		score := 0
+		if rate.SellerID != uuid.Nil {
+			score += 2
+		}
+		if rate.Category != "" {
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	if best < 0 {
+		return defaultCommissionRate(), nil
+	}
+	return rates[best].Rate, nil
+}
+
+// Save inserts the rate or replaces the existing one for the same scope
+func (r *CommissionRate) Save(c context.Context) error {
+	if r.Rate.IsNegative() || r.Rate.GreaterThan(decimal.NewFromInt(1)) {
+		return ErrInvalidCommissionRate
+	}
+	return db.GetDB(c).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "seller_id"}, {Name: "category"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "updated_at"}),
+	}).Create(r).Error
+}
+
+func ListCommissionRates(c context.Context) ([]CommissionRate, error) {
+	var rates []CommissionRate
+	if err := db.GetDB(c).Order("id").Find(&rates).Error; err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
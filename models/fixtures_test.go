@@ -0,0 +1,128 @@
+package models
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"net/http"
+	"testing"
+	"user-service/db"
+	"user-service/enums"
+	"user-service/payment"
+)
+
+// Fixtures shared by the model tests. Every factory creates its rows with a
+// fresh uuid so tests never see each other's data, and fails the test when
+// the database refuses them
+
+func newTestBuyer(t *testing.T, balance string) *Buyer {
+	t.Helper()
+	buyer := Buyer{
+		Username:     "buyer-" + uuid.New().String(),
+		Password:     "password",
+		BuyerProfile: BuyerProfile{FirstName: "Test", LastName: "Buyer"},
+		BuyerWallet: BuyerWallet{
+			Balance:  decimal.RequireFromString(balance),
+			Currency: enums.DefaultCurrency,
+		},
+	}
+	if err := db.GetDB(context.Background()).Create(&buyer).Error; err != nil {
+		t.Fatal(err)
+	}
+	return &buyer
+}
+
+// newTestSeller creates a verified seller with a payout destination
+func newTestSeller(t *testing.T, balance string) (*Seller, *PayoutDestination) {
+	t.Helper()
+	seller := Seller{
+		Username: "seller-" + uuid.New().String(),
+		Password: "password",
+		SellerProfile: SellerProfile{
+			FirstName:          "Test",
+			LastName:           "Seller",
+			VerificationStatus: enums.VerificationApproved,
+			Verified:           true,
+		},
+		SellerWallet: SellerWallet{
+			Balance:  decimal.RequireFromString(balance),
+			Currency: enums.DefaultCurrency,
+		},
+	}
+	if err := db.GetDB(context.Background()).Create(&seller).Error; err != nil {
+		t.Fatal(err)
+	}
+	destination := PayoutDestination{
+		SellerID:      seller.ID,
+		AccountName:   "Test Seller",
+		AccountNumber: "1234567890",
+		BankCode:      "004",
+	}
+	if err := destination.Create(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return &seller, &destination
+}
+
+// newTestPhone gives buyer a verified phone number
+func newTestPhone(t *testing.T, buyer *Buyer) string {
+	t.Helper()
+	phone := testPhone()
+	if err := db.GetDB(context.Background()).
+		Create(&PhoneNumber{UserGroup: enums.Buyer, Phone: phone, UserID: buyer.ID}).Error; err != nil {
+		t.Fatal(err)
+	}
+	return phone
+}
+
+func testBuyerWallet(t *testing.T, buyerID uuid.UUID) BuyerWallet {
+	t.Helper()
+	var wallet BuyerWallet
+	if err := db.GetDB(context.Background()).Where("buyer_id = ? AND currency = ?", buyerID, enums.DefaultCurrency).First(&wallet).Error; err != nil {
+		t.Fatal(err)
+	}
+	return wallet
+}
+
+func testSellerWallet(t *testing.T, sellerID uuid.UUID) SellerWallet {
+	t.Helper()
+	var wallet SellerWallet
+	if err := db.GetDB(context.Background()).Where("seller_id = ? AND currency = ?", sellerID, enums.DefaultCurrency).First(&wallet).Error; err != nil {
+		t.Fatal(err)
+	}
+	return wallet
+}
+
+// platformTotal adds up what the platform booked under references
+func platformTotal(t *testing.T, references ...string) decimal.Decimal {
+	t.Helper()
+	var entries []PlatformLedgerEntry
+	if err := db.GetDB(context.Background()).Where("reference IN ?", references).Find(&entries).Error; err != nil {
+		t.Fatal(err)
+	}
+	total := decimal.Zero
+	for _, entry := range entries {
+		total = total.Add(entry.Amount)
+	}
+	return total
+}
+
+// setTestCommissionRate makes the platform keep rate of every sale of seller
+func setTestCommissionRate(t *testing.T, sellerID uuid.UUID, rate string) {
+	t.Helper()
+	commission := CommissionRate{SellerID: sellerID, Rate: decimal.RequireFromString(rate)}
+	if err := commission.Save(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// stubProvider opens intents without talking to anyone
+type stubProvider struct{}
+
+func (stubProvider) CreateIntent(c context.Context, intent payment.Intent) (payment.ProviderIntent, error) {
+	return payment.ProviderIntent{Reference: "stub_" + uuid.New().String()}, nil
+}
+
+func (stubProvider) VerifyWebhook(header http.Header, payload []byte) (payment.Event, error) {
+	return payment.Event{}, payment.ErrInvalidSignature
+}
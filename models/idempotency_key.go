@@ -0,0 +1,62 @@
+package models
+
+import (
+	"context"
+	"gorm.io/gorm/clause"
+	"time"
+	"user-service/db"
+)
+
+type IdempotencyKey struct {
+	Key            string `gorm:"primaryKey"`
+	Scope          string `gorm:"primaryKey"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	RequestHash    string
+	Completed      bool
+	ResponseStatus int
+	// The content headers are replayed with the body, errors are problem
+	// documents in the language of the first request
+	ResponseContentType     string
+	ResponseContentLanguage string
+	ResponseBody            []byte
+}
+
+// Reserve claims the key for the current request. It returns false when the key
+// was already used, in which case the stored record is loaded into k
+func (k *IdempotencyKey) Reserve(c context.Context) (bool, error) {
+	result := db.GetDB(c).Clauses(clause.OnConflict{DoNothing: true}).Create(k)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return true, nil
+	}
+	if err := db.GetDB(c).
+		Where("key = ? AND scope = ?", k.Key, k.Scope).
+		First(k).Error; err != nil {
+		return false, err
+	}
+	return false, nil
+}
+
+func (k *IdempotencyKey) Complete(c context.Context, status int, contentType string, contentLanguage string, body []byte) error {
+	return db.GetDB(c).
+		Model(&IdempotencyKey{}).
+		Where("key = ? AND scope = ?", k.Key, k.Scope).
+		Updates(map[string]interface{}{
+			"completed":                 true,
+			"response_status":           status,
+			"response_content_type":     contentType,
+			"response_content_language": contentLanguage,
+			"response_body":             body,
+		}).Error
+}
+
+// Release forgets the key so the client can retry, used when the request failed
+// before anything was committed
+func (k *IdempotencyKey) Release(c context.Context) error {
+	return db.GetDB(c).
+		Where("key = ? AND scope = ?", k.Key, k.Scope).
+		Delete(&IdempotencyKey{}).Error
+}
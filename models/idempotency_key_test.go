@@ -0,0 +1,53 @@
+package models
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"net/http"
+	"testing"
+)
+
+// A key is served once, replayed with the stored response once completed and
+// free again after a release
+func TestIdempotencyKeyReplay(t *testing.T) {
+	requireDB(t)
+	c := context.Background()
+	key := uuid.New().String()
+	steps := []struct {
+		name          string
+		scope         string
+		do            func(k *IdempotencyKey) error
+		wantReserved  bool
+		wantCompleted bool
+		wantStatus    int
+	}{
+		{"first request", "buyer:debit", nil, true, false, 0},
+		{"retry while in flight", "buyer:debit", nil, false, false, 0},
+		{"same key in another scope", "buyer:topup", nil, true, false, 0},
+		{"retry after completion", "buyer:debit", func(k *IdempotencyKey) error {
+			return k.Complete(c, http.StatusCreated, "application/json; charset=utf-8", "th", []byte(`{"id":1}`))
+		}, false, true, http.StatusCreated},
+		{"retry after release", "buyer:topup", func(k *IdempotencyKey) error {
+			return k.Release(c)
+		}, true, false, 0},
+	}
+	for _, step := range steps {
+		if step.do != nil {
+			if err := step.do(&IdempotencyKey{Key: key, Scope: step.scope}); err != nil {
+				t.Fatalf("%s: %v", step.name, err)
+			}
+		}
+		k := IdempotencyKey{Key: key, Scope: step.scope, RequestHash: "hash"}
+		reserved, err := k.Reserve(c)
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if reserved != step.wantReserved || k.Completed != step.wantCompleted || k.ResponseStatus != step.wantStatus {
+			t.Errorf("%s: reserved %t completed %t status %d, want %t %t %d",
+				step.name, reserved, k.Completed, k.ResponseStatus, step.wantReserved, step.wantCompleted, step.wantStatus)
+		}
+		if step.wantCompleted && (string(k.ResponseBody) != `{"id":1}` || k.ResponseContentType != "application/json; charset=utf-8" || k.ResponseContentLanguage != "th") {
+			t.Errorf("%s: replayed %q %q %q", step.name, k.ResponseContentType, k.ResponseContentLanguage, k.ResponseBody)
+		}
+	}
+}
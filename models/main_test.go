@@ -0,0 +1,67 @@
+package models
+
+import (
+	"log"
+	"os"
+	"testing"
+	"user-service/db"
+	"user-service/rates"
+)
+
+// hasTestDB is set when TEST_DATABASE_DSN points at a Postgres database the
+// tests may write to. Tests that need it call requireDB and are skipped
+// otherwise
+var hasTestDB bool
+
+func TestMain(m *testing.M) {
+	os.Setenv("EXCHANGE_RATES_FILE", "../config/exchange_rates.json")
+	rates.Init()
+	if dsn := os.Getenv("TEST_DATABASE_DSN"); dsn != "" {
+		err := db.Open(dsn).AutoMigrate(
+			&Seller{},
+			&SellerProfile{},
+			&SellerWallet{},
+			&Buyer{},
+			&BuyerProfile{},
+			&BuyerWallet{},
+			&BuyerWalletTransaction{},
+			&SellerWalletTransaction{},
+			&IdempotencyKey{},
+			&BuyerWalletHold{},
+			&CommissionRate{},
+			&PlatformLedgerEntry{},
+			&Settlement{},
+			&PayoutDestination{},
+			&PayoutRequest{},
+			&PayoutBatch{},
+			&TopupIntent{},
+			&BuyerPromoCredit{},
+			&ReferralCode{},
+			&Referral{},
+			&RiskDecision{},
+			&KnownDevice{},
+			&SellerSlug{},
+			&SellerSocialLink{},
+			&SellerVerification{},
+			&SellerVerificationDocument{},
+			&BuyerAddress{},
+			&PhoneNumber{},
+			&OneTimePassword{},
+			&OTPFailure{},
+			&UserPreference{},
+			&NotificationSetting{},
+		)
+		if err != nil {
+			log.Fatal(err)
+		}
+		hasTestDB = true
+	}
+	os.Exit(m.Run())
+}
+
+func requireDB(t *testing.T) {
+	t.Helper()
+	if !hasTestDB {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"user-service/db"
+	"user-service/enums"
+	"user-service/errs"
+	"user-service/i18n"
+)
+
+var ErrPhoneNotVerified = errs.New(errs.ErrPreconditionFailed, i18n.CodePhoneNotVerified, "a verified phone is required")
+
+// verifiedPhone returns the phone the user confirmed by OTP
+func verifiedPhone(c context.Context, group string, userID uuid.UUID) (string, error) {
+	var number PhoneNumber
+	err := db.GetDB(c).
+		Where("user_group = ? AND user_id = ?", group, userID).
+		First(&number).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", ErrPhoneNotVerified
+	}
+	if err != nil {
+		return "", err
+	}
+	return number.Phone, nil
+}
+
+// requestMFACode texts a second factor code to the verified phone of the user
+func requestMFACode(c context.Context, group string, userID uuid.UUID) (string, error) {
+	phone, err := verifiedPhone(c, group, userID)
+	if err != nil {
+		return "", err
+	}
+	return phone, issueOTP(c, group, userID, phone, enums.OTPPurposeMFA)
+}
+
+func verifyMFACode(c context.Context, group string, userID uuid.UUID, code string, ip string) error {
+	phone, err := verifiedPhone(c, group, userID)
+	if err != nil {
+		return err
+	}
+	return verifyOTP(c, group, userID, phone, enums.OTPPurposeMFA, code, ip)
+}
+
+// RequestMFACode sends a second factor code to the buyer phone. It returns
+// the phone the code was sent to
+func (u *Buyer) RequestMFACode(c context.Context) (string, error) {
+	return requestMFACode(c, enums.Buyer, u.ID)
+}
+
+// VerifyMFACode checks the second factor code typed in from ip and loads the
+// buyer
+func (u *Buyer) VerifyMFACode(c context.Context, code string, ip string) error {
+	if err := verifyMFACode(c, enums.Buyer, u.ID, code, ip); err != nil {
+		return err
+	}
+	return u.RetrieveByUserIDWithProfile(c, u.ID)
+}
+
+// RequestMFACode sends a second factor code to the seller phone. It returns
+// the phone the code was sent to
+func (u *Seller) RequestMFACode(c context.Context) (string, error) {
+	return requestMFACode(c, enums.Seller, u.ID)
+}
+
+// VerifyMFACode checks the second factor code typed in from ip and loads the
+// seller
+func (u *Seller) VerifyMFACode(c context.Context, code string, ip string) error {
+	if err := verifyMFACode(c, enums.Seller, u.ID, code, ip); err != nil {
+		return err
+	}
+	return u.RetrieveByUserIDWithProfile(c, u.ID)
+}
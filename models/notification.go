@@ -0,0 +1,128 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"log"
+	"user-service/db"
+	"user-service/enums"
+	"user-service/notify"
+)
+
+// Notify sends message to a user on every channel they enabled for its
+// event. Promotions also need the marketing opt-in of the channel. Delivery
+// problems are only logged, a notification never fails the operation that
+// triggered it
+func Notify(c context.Context, group string, userID uuid.UUID, message notify.Message) {
+	table := "buyers"
+	if group == enums.Seller {
+		table = "sellers"
+	}
+	var phones []string
+	if err := db.GetDB(c).
+		Table(table).
+		Where("id = ?", userID).
+		Pluck("phone", &phones).Error; err != nil {
+		log.Printf("failed to load phone of %s %s: %v", group, userID, err)
+	}
+	phone := ""
+	if len(phones) > 0 {
+		phone = phones[0]
+	}
+	notifyAt(c, group, userID, phone, message)
+}
+
+// notifyAt is Notify with SMS going to phone rather than the phone on the
+// account
+func notifyAt(c context.Context, group string, userID uuid.UUID, phone string, message notify.Message) {
+	preference, err := GetPreferences(c, group, userID)
+	if err != nil {
+		log.Printf("failed to load preferences of %s %s: %v", group, userID, err)
+		return
+	}
+	recipient := notify.Recipient{UserID: userID, Group: group, Phone: phone, Locale: preference.Locale}
+	for _, name := range enums.NotificationChannels {
+		if !preference.Notifications[message.Event][name] {
+			continue
+		}
+		if message.Event == enums.NotificationPromotions && !marketingConsent(preference, name) {
+			continue
+		}
+		channel, ok := notify.GetChannel(name)
+		if !ok {
+			continue
+		}
+		err := channel.Send(c, recipient, message)
+		if err != nil && !errors.Is(err, notify.ErrNoAddress) {
+			log.Printf("failed to send %s %s notification to %s %s: %v", message.Event, name, group, userID, err)
+		}
+	}
+}
+
+func marketingConsent(preference UserPreference, channel string) bool {
+	switch channel {
+	case enums.ChannelEmail:
+		return preference.MarketingEmail
+	case enums.ChannelSMS:
+		return preference.MarketingSMS
+	default:
+		// Push prompts are opted into on the device itself
+		return true
+	}
+}
+
+func notifyPayout(c context.Context, request *PayoutRequest) {
+	amount := request.Amount.StringFixed(2) + " " + request.Currency
+	message := notify.Message{Event: enums.NotificationPayouts}
+	switch request.Status {
+	case enums.PayoutPaid:
+		message.Text = map[string]string{
+			"en": fmt.Sprintf("Your payout #%d of %s has been paid.", request.ID, amount),
+			"th": fmt.Sprintf("การถอนเงิน #%d จำนวน %s โอนเรียบร้อยแล้ว", request.ID, amount),
+		}
+	case enums.PayoutFailed:
+		message.Text = map[string]string{
+			"en": fmt.Sprintf("Your payout #%d of %s did not go through and the amount is back in your wallet: %s", request.ID, amount, request.FailureReason),
+			"th": fmt.Sprintf("การถอนเงิน #%d จำนวน %s ไม่สำเร็จ ยอดเงินถูกคืนเข้ากระเป๋าเงินของคุณแล้ว: %s", request.ID, amount, request.FailureReason),
+		}
+	default:
+		return
+	}
+	Notify(c, enums.Seller, request.SellerID, message)
+}
+
+// notifyPhoneChanged alerts the previous number, whoever took the account over
+// already holds the new one
+func notifyPhoneChanged(c context.Context, group string, userID uuid.UUID, previous string, phone string) {
+	notifyAt(c, group, userID, previous, notify.Message{
+		Event: enums.NotificationSecurityAlerts,
+		Text: map[string]string{
+			"en": fmt.Sprintf("The phone number of your account was changed to %s. If this was not you, contact support.", phone),
+			"th": fmt.Sprintf("หมายเลขโทรศัพท์ของบัญชีคุณถูกเปลี่ยนเป็น %s หากคุณไม่ได้ทำรายการนี้ โปรดติดต่อฝ่ายบริการลูกค้า", phone),
+		},
+	})
+}
+
+func notifyRiskBlocked(c context.Context, request RiskRequest) {
+	amount := request.Amount.StringFixed(2) + " " + request.Currency
+	Notify(c, request.Group, request.UserID, notify.Message{
+		Event: enums.NotificationSecurityAlerts,
+		Text: map[string]string{
+			"en": fmt.Sprintf("We blocked an unusual transaction of %s on your account. If this was not you, change your password.", amount),
+			"th": fmt.Sprintf("เราระงับรายการที่ผิดปกติจำนวน %s ในบัญชีของคุณ หากคุณไม่ได้ทำรายการนี้ โปรดเปลี่ยนรหัสผ่าน", amount),
+		},
+	})
+}
+
+func notifyPromoCredit(c context.Context, credit *BuyerPromoCredit) {
+	amount := credit.Amount.StringFixed(2) + " " + credit.Currency
+	Notify(c, enums.Buyer, credit.BuyerID, notify.Message{
+		Event: enums.NotificationPromotions,
+		Text: map[string]string{
+			"en": fmt.Sprintf("You received %s of promo credit, use it before it expires.", amount),
+			"th": fmt.Sprintf("คุณได้รับเครดิตโปรโมชัน %s ใช้ได้ก่อนหมดอายุ", amount),
+		},
+	})
+}
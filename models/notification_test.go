@@ -0,0 +1,85 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"user-service/enums"
+	"user-service/notify"
+)
+
+// recordingChannel stands in for a channel and keeps what it was asked to send
+type recordingChannel struct {
+	name string
+	sent []notify.Recipient
+}
+
+func (r *recordingChannel) Name() string {
+	return r.name
+}
+
+func (r *recordingChannel) Send(c context.Context, recipient notify.Recipient, message notify.Message) error {
+	if recipient.Phone == "" {
+		return notify.ErrNoAddress
+	}
+	r.sent = append(r.sent, recipient)
+	return nil
+}
+
+func TestDeliversOnAnyChannel(t *testing.T) {
+	notify.Register(&recordingChannel{name: enums.ChannelSMS})
+	tests := []struct {
+		name     string
+		channels map[string]bool
+		want     bool
+	}{
+		{"registered channel on", map[string]bool{enums.ChannelSMS: true, enums.ChannelEmail: false}, true},
+		{"every channel off", map[string]bool{enums.ChannelSMS: false, enums.ChannelEmail: false, enums.ChannelPush: false}, false},
+		// Email is not registered, nothing would be sent
+		{"only unregistered channels on", map[string]bool{enums.ChannelSMS: false, enums.ChannelEmail: true, enums.ChannelPush: true}, false},
+	}
+	for _, test := range tests {
+		if got := deliversOnAnyChannel(test.channels); got != test.want {
+			t.Errorf("%s: deliversOnAnyChannel = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestDefaultSecurityAlertsDeliver(t *testing.T) {
+	notify.Register(&recordingChannel{name: enums.ChannelSMS})
+	if !deliversOnAnyChannel(defaultNotifications[enums.NotificationSecurityAlerts]) {
+		t.Error("default security alerts are not sent on any registered channel")
+	}
+}
+
+func TestSavePreferencesKeepsSecurityAlerts(t *testing.T) {
+	requireDB(t)
+	notify.Register(&recordingChannel{name: enums.ChannelSMS})
+	buyer := newTestBuyer(t, "0")
+	preference := DefaultPreferences(enums.Buyer, buyer.ID)
+	preference.Notifications[enums.NotificationSecurityAlerts][enums.ChannelSMS] = false
+	if err := SavePreferences(context.Background(), &preference); !errors.Is(err, ErrSecurityAlertsRequired) {
+		t.Fatalf("SavePreferences = %v, want ErrSecurityAlertsRequired", err)
+	}
+}
+
+func TestPhoneChangeAlertsPreviousNumber(t *testing.T) {
+	requireDB(t)
+	lastCode := useTestSMS(t)
+	channel := &recordingChannel{name: enums.ChannelSMS}
+	notify.Register(channel)
+	c := context.Background()
+	buyer := newTestBuyer(t, "0")
+	previous := newTestPhone(t, buyer)
+	phone := testPhone()
+
+	if _, err := buyer.RequestPhoneVerification(c, phone); err != nil {
+		t.Fatal(err)
+	}
+	if err := buyer.VerifyPhone(c, phone, lastCode(), testIP()); err != nil {
+		t.Fatal(err)
+	}
+	if len(channel.sent) != 1 || channel.sent[0].Phone != previous {
+		t.Fatalf("alert sent to %+v, want only %s", channel.sent, previous)
+	}
+}
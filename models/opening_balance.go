@@ -0,0 +1,56 @@
+package models
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"user-service/db"
+	"user-service/enums"
+)
+
+// openingBalanceLock keeps two instances starting at once from posting the
+// opening balances twice
+const openingBalanceLock = 26026
+
+// A wallet with a balance and no ledger entry at all predates the ledger,
+// every later change of a balance books an entry with it. Wallets and their
+// ledger share the distribution column so Citus pushes the inserts down to
+// the workers
+const (
+	buyerOpeningBalanceQuery = `
+INSERT INTO buyer_wallet_transactions (created_at, updated_at, buyer_id, currency, type, amount, promo_amount, balance_after, reference)
+SELECT now(), now(), w.buyer_id, w.currency, ?, w.balance, 0, w.balance, ?
+FROM buyer_wallets w
+WHERE w.deleted_at IS NULL AND w.balance <> 0
+AND NOT EXISTS (SELECT 1 FROM buyer_wallet_transactions t WHERE t.buyer_id = w.buyer_id AND t.currency = w.currency)`
+	sellerOpeningBalanceQuery = `
+INSERT INTO seller_wallet_transactions (created_at, updated_at, seller_id, currency, type, amount, balance_after, reference)
+SELECT now(), now(), w.seller_id, w.currency, ?, w.balance, w.balance, ?
+FROM seller_wallets w
+WHERE w.deleted_at IS NULL AND w.balance <> 0
+AND NOT EXISTS (SELECT 1 FROM seller_wallet_transactions t WHERE t.seller_id = w.seller_id AND t.currency = w.currency)`
+)
+
+// PostOpeningBalances books one opening balance entry for every wallet that
+// holds money from before the ledger, so statements open at the real balance
+// and reconciliation agrees with the wallet. It only touches wallets without
+// any entry and is safe to run on every start
+func PostOpeningBalances(c context.Context) (int64, error) {
+	var posted int64
+	err := db.GetDB(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", openingBalanceLock).Error; err != nil {
+			return err
+		}
+		for _, query := range []string{buyerOpeningBalanceQuery, sellerOpeningBalanceQuery} {
+			result := tx.Exec(query, enums.TransactionOpeningBalance, enums.TransactionOpeningBalance)
+			if result.Error != nil {
+				return result.Error
+			}
+			posted += result.RowsAffected
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return posted, nil
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"context"
+	"testing"
+	"user-service/db"
+	"user-service/enums"
+)
+
+// A wallet from before the ledger gets exactly one opening entry, a wallet
+// already on the ledger is left alone
+func TestPostOpeningBalances(t *testing.T) {
+	requireDB(t)
+	c := context.Background()
+	legacyBuyer := newTestBuyer(t, "100.00")
+	legacySeller, _ := newTestSeller(t, "40.00")
+	buyer := newTestBuyer(t, "10.00")
+	topup := BuyerWalletTransaction{
+		BuyerID:      buyer.ID,
+		Currency:     enums.DefaultCurrency,
+		Type:         enums.TransactionTopup,
+		Amount:       buyer.BuyerWallet.Balance,
+		BalanceAfter: buyer.BuyerWallet.Balance,
+	}
+	if err := db.GetDB(c).Create(&topup).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	for run := 0; run < 2; run++ {
+		if _, err := PostOpeningBalances(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buyerEntries []BuyerWalletTransaction
+	if err := db.GetDB(c).Where("buyer_id = ?", legacyBuyer.ID).Find(&buyerEntries).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(buyerEntries) != 1 || buyerEntries[0].Type != enums.TransactionOpeningBalance ||
+		!buyerEntries[0].Amount.Equal(legacyBuyer.BuyerWallet.Balance) || !buyerEntries[0].BalanceAfter.Equal(legacyBuyer.BuyerWallet.Balance) {
+		t.Errorf("legacy buyer ledger = %+v, want one opening entry of %s", buyerEntries, legacyBuyer.BuyerWallet.Balance)
+	}
+	var sellerEntries []SellerWalletTransaction
+	if err := db.GetDB(c).Where("seller_id = ?", legacySeller.ID).Find(&sellerEntries).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(sellerEntries) != 1 || sellerEntries[0].Type != enums.TransactionOpeningBalance || !sellerEntries[0].Amount.Equal(legacySeller.SellerWallet.Balance) {
+		t.Errorf("legacy seller ledger = %+v, want one opening entry of %s", sellerEntries, legacySeller.SellerWallet.Balance)
+	}
+	var openings int64
+	if err := db.GetDB(c).Model(&BuyerWalletTransaction{}).Where("buyer_id = ? AND type = ?", buyer.ID, enums.TransactionOpeningBalance).Count(&openings).Error; err != nil {
+		t.Fatal(err)
+	}
+	if openings != 0 {
+		t.Errorf("wallet on the ledger got %d opening entries", openings)
+	}
+}
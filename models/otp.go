@@ -0,0 +1,226 @@
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"math/big"
+	"time"
+	"user-service/db"
+	"user-service/errs"
+	"user-service/i18n"
+	"user-service/sms"
+)
+
+const (
+	defaultOTPTTL              = 5 * time.Minute
+	defaultOTPMaxAttempts      = 5
+	defaultOTPResendInterval   = time.Minute
+	defaultOTPMaxFailures      = 10
+	defaultOTPMaxFailuresPerIP = 30
+	defaultOTPLockoutWindow    = time.Hour
+	defaultOTPDailyLimit       = 10
+	otpDailyLimitWindow        = 24 * time.Hour
+)
+
+var (
+	ErrInvalidOTP          = errs.New(errs.ErrUnauthorized, i18n.CodeInvalidOTP, "invalid or expired code")
+	ErrOTPAttemptsExceeded = errs.New(errs.ErrTooManyRequests, i18n.CodeOTPAttemptsExceeded, "too many wrong codes, request a new one")
+	ErrOTPTooSoon          = errs.New(errs.ErrTooManyRequests, i18n.CodeOTPTooSoon, "wait before requesting another code")
+	ErrOTPLocked           = errs.New(errs.ErrTooManyRequests, i18n.CodeOTPLocked, "too many wrong codes, try again later")
+	ErrOTPDailyLimit       = errs.New(errs.ErrTooManyRequests, i18n.CodeOTPDailyLimit, "too many codes sent to this phone today")
+)
+
+// OneTimePassword is a 6-digit code sent by SMS, only its hash is kept.
+// Codes are distributed by phone since logins look them up by phone alone
+type OneTimePassword struct {
+	gorm.Model
+	Phone      string    `gorm:"primaryKey;index:otp_lookup"`
+	UserGroup  string    `gorm:"index:otp_lookup"`
+	UserID     uuid.UUID `gorm:"type:uuid;index:otp_lookup"`
+	Purpose    string    `gorm:"index:otp_lookup"`
+	CodeHash   string
+	ExpiresAt  time.Time
+	Attempts   int `gorm:"default:0;not null"`
+	ConsumedAt *time.Time
+}
+
+// OTPFailure records a wrong or unusable code. Failures are counted per phone
+// and per IP over a rolling window, so asking for a new code does not give
+// a fresh set of guesses. They live next to the codes of the phone
+type OTPFailure struct {
+	ID        uint   `gorm:"primarykey"`
+	Phone     string `gorm:"primaryKey;index:otp_failure_phone"`
+	IP        string `gorm:"index"`
+	CreatedAt time.Time
+}
+
+// OTPTTL is how long a code stays valid, OTP_TTL or 5 minutes
+func OTPTTL() time.Duration {
+	return durationFromEnv("OTP_TTL", defaultOTPTTL)
+}
+
+// issueOTP texts a new code to phone, any earlier code for the same purpose
+// stops working
+func issueOTP(c context.Context, group string, userID uuid.UUID, phone string, purpose string) error {
+	var last OneTimePassword
+	err := db.GetDB(c).
+		Where("phone = ? AND user_group = ? AND user_id = ? AND purpose = ? AND deleted_at IS NULL", phone, group, userID, purpose).
+		Order("id DESC").
+		First(&last).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if err == nil && time.Since(last.CreatedAt) < durationFromEnv("OTP_RESEND_INTERVAL", defaultOTPResendInterval) {
+		return ErrOTPTooSoon
+	}
+	// Capped per phone whoever asks, texting a number over and over costs money
+	var sentToday int64
+	if err := db.GetDB(c).
+		Model(&OneTimePassword{}).
+		Where("phone = ? AND created_at >= ? AND deleted_at IS NULL", phone, time.Now().Add(-otpDailyLimitWindow)).
+		Count(&sentToday).Error; err != nil {
+		return err
+	}
+	if sentToday >= int64(intFromEnv("OTP_DAILY_LIMIT", defaultOTPDailyLimit)) {
+		return ErrOTPDailyLimit
+	}
+
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return err
+	}
+	code := fmt.Sprintf("%06d", n.Int64())
+	hash, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	ttl := OTPTTL()
+	otp := OneTimePassword{
+		Phone:     phone,
+		UserGroup: group,
+		UserID:    userID,
+		Purpose:   purpose,
+		CodeHash:  string(hash),
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	err = db.GetDB(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Model(&OneTimePassword{}).
+			Where("phone = ? AND user_group = ? AND user_id = ? AND purpose = ? AND consumed_at IS NULL", phone, group, userID, purpose).
+			Update("consumed_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Create(&otp).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("Your verification code is %s. It expires in %d minutes. Never share it with anyone.", code, int(ttl.Minutes()))
+	if err := sms.GetGateway().Send(c, phone, message); err != nil {
+		// Let the user ask again straight away
+		db.GetDB(c).
+			Model(&otp).
+			Where("phone = ?", phone).
+			Update("deleted_at", time.Now())
+		return err
+	}
+	return nil
+}
+
+// verifyOTP checks code against the latest code sent to the user for
+// purpose, a code works once and only until it expires or is guessed wrong
+// too many times. The phone and the IP the guess comes from are locked out
+// for a while after too many failures, whichever code they were against
+func verifyOTP(c context.Context, group string, userID uuid.UUID, phone string, purpose string, code string, ip string) error {
+	var result error
+	err := db.GetDB(c).Transaction(func(tx *gorm.DB) error {
+		if err := checkOTPLockout(tx, phone, ip); err != nil {
+			if errors.Is(err, ErrOTPLocked) {
+				result = err
+				return nil
+			}
+			return err
+		}
+		var otp OneTimePassword
+		err := tx.
+			Where("phone = ? AND user_group = ? AND user_id = ? AND purpose = ? AND consumed_at IS NULL AND deleted_at IS NULL", phone, group, userID, purpose).
+			Order("id DESC").
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&otp).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			result = ErrInvalidOTP
+			return recordOTPFailure(tx, phone, ip)
+		}
+		if err != nil {
+			return err
+		}
+		if time.Now().After(otp.ExpiresAt) {
+			result = ErrInvalidOTP
+			return recordOTPFailure(tx, phone, ip)
+		}
+		if otp.Attempts >= intFromEnv("OTP_MAX_ATTEMPTS", defaultOTPMaxAttempts) {
+			result = ErrOTPAttemptsExceeded
+			return nil
+		}
+		if bcrypt.CompareHashAndPassword([]byte(otp.CodeHash), []byte(code)) != nil {
+			// Counted in the committed transaction, a wrong guess is not rolled back
+			result = ErrInvalidOTP
+			if err := tx.
+				Model(&otp).
+				Where("phone = ?", phone).
+				Update("attempts", gorm.Expr("attempts + 1")).Error; err != nil {
+				return err
+			}
+			return recordOTPFailure(tx, phone, ip)
+		}
+		return tx.
+			Model(&otp).
+			Where("phone = ?", phone).
+			Update("consumed_at", time.Now()).Error
+	})
+	if err != nil {
+		return err
+	}
+	return result
+}
+
+// checkOTPLockout returns ErrOTPLocked while the phone or the IP has too many
+// failures within OTP_LOCKOUT_WINDOW
+func checkOTPLockout(tx *gorm.DB, phone string, ip string) error {
+	since := time.Now().Add(-durationFromEnv("OTP_LOCKOUT_WINDOW", defaultOTPLockoutWindow))
+	var phoneFailures int64
+	if err := tx.
+		Model(&OTPFailure{}).
+		Where("phone = ? AND created_at >= ?", phone, since).
+		Count(&phoneFailures).Error; err != nil {
+		return err
+	}
+	if phoneFailures >= int64(intFromEnv("OTP_MAX_FAILURES", defaultOTPMaxFailures)) {
+		return ErrOTPLocked
+	}
+	if ip == "" {
+		return nil
+	}
+	var ipFailures int64
+	if err := tx.
+		Model(&OTPFailure{}).
+		Where("ip = ? AND created_at >= ?", ip, since).
+		Count(&ipFailures).Error; err != nil {
+		return err
+	}
+	if ipFailures >= int64(intFromEnv("OTP_MAX_FAILURES_PER_IP", defaultOTPMaxFailuresPerIP)) {
+		return ErrOTPLocked
+	}
+	return nil
+}
+
+func recordOTPFailure(tx *gorm.DB, phone string, ip string) error {
+	return tx.Create(&OTPFailure{Phone: phone, IP: ip}).Error
+}
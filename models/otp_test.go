@@ -0,0 +1,135 @@
+package models
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"user-service/enums"
+	"user-service/sms"
+)
+
+var sentCodePattern = regexp.MustCompile(`\b(\d{6})\b`)
+
+// useTestSMS sends codes to a file and returns a function reading the last
+// code sent
+func useTestSMS(t *testing.T) func() string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "messages.log")
+	t.Setenv("SMS_GATEWAY", "file")
+	t.Setenv("SMS_FILE_PATH", path)
+	sms.Init()
+	return func() string {
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		code := ""
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			if match := sentCodePattern.FindStringSubmatch(scanner.Text()); match != nil {
+				code = match[1]
+			}
+		}
+		return code
+	}
+}
+
+func wrongCode(code string) string {
+	if code == "000000" {
+		return "111111"
+	}
+	return "000000"
+}
+
+// testIP is a client address no earlier run has failed codes from
+func testIP() string {
+	return "test-" + uuid.New().String()
+}
+
+func testPhone() string {
+	return fmt.Sprintf("+6681%07d", uuid.New().ID()%10000000)
+}
+
+func TestOTPLockoutSurvivesNewCodes(t *testing.T) {
+	requireDB(t)
+	lastCode := useTestSMS(t)
+	t.Setenv("OTP_RESEND_INTERVAL", "1ns")
+	t.Setenv("OTP_MAX_ATTEMPTS", "5")
+	t.Setenv("OTP_MAX_FAILURES", "8")
+	c := context.Background()
+	buyer := newTestBuyer(t, "0")
+	phone := newTestPhone(t, buyer)
+	ip := testIP()
+
+	for round := 0; round < 2; round++ {
+		if _, err := RequestLoginOTP(c, enums.Buyer, phone); err != nil {
+			t.Fatal(err)
+		}
+		for guess := 0; guess < 4; guess++ {
+			err := buyer.LoginWithOTP(c, phone, wrongCode(lastCode()), ip)
+			if !errors.Is(err, ErrInvalidOTP) {
+				t.Fatalf("round %d guess %d: got %v, want ErrInvalidOTP", round, guess, err)
+			}
+		}
+	}
+
+	// A fresh code and a new IP do not reset the phone failures
+	if _, err := RequestLoginOTP(c, enums.Buyer, phone); err != nil {
+		t.Fatal(err)
+	}
+	if err := buyer.LoginWithOTP(c, phone, lastCode(), testIP()); !errors.Is(err, ErrOTPLocked) {
+		t.Fatalf("right code after 8 failures: got %v, want ErrOTPLocked", err)
+	}
+}
+
+func TestOTPLockoutPerIP(t *testing.T) {
+	requireDB(t)
+	t.Setenv("OTP_MAX_FAILURES_PER_IP", "3")
+	c := context.Background()
+	ip := testIP()
+	// Unknown phones count too, the attacker does not learn which exist
+	for i := 0; i < 3; i++ {
+		err := (&Buyer{}).LoginWithOTP(c, testPhone(), "123456", ip)
+		if !errors.Is(err, ErrInvalidOTP) {
+			t.Fatalf("guess %d: got %v, want ErrInvalidOTP", i, err)
+		}
+	}
+	err := (&Buyer{}).LoginWithOTP(c, testPhone(), "123456", ip)
+	if !errors.Is(err, ErrOTPLocked) {
+		t.Fatalf("got %v, want ErrOTPLocked", err)
+	}
+}
+
+func TestOTPDailyLimit(t *testing.T) {
+	requireDB(t)
+	useTestSMS(t)
+	t.Setenv("OTP_RESEND_INTERVAL", "1ns")
+	t.Setenv("OTP_DAILY_LIMIT", "3")
+	c := context.Background()
+	buyer := newTestBuyer(t, "0")
+	phone := newTestPhone(t, buyer)
+
+	tests := []struct {
+		name    string
+		purpose string
+		want    error
+	}{
+		{"first login code", enums.OTPPurposeLogin, nil},
+		{"second login code", enums.OTPPurposeLogin, nil},
+		{"code for another purpose", enums.OTPPurposeMFA, nil},
+		{"over the daily limit", enums.OTPPurposeLogin, ErrOTPDailyLimit},
+	}
+	for _, test := range tests {
+		err := issueOTP(c, enums.Buyer, buyer.ID, phone, test.purpose)
+		if !errors.Is(err, test.want) {
+			t.Fatalf("%s: got %v, want %v", test.name, err, test.want)
+		}
+	}
+}
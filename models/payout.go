@@ -0,0 +1,414 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"log"
+	"os"
+	"time"
+	"user-service/db"
+	"user-service/enums"
+	"user-service/errs"
+	"user-service/i18n"
+	"user-service/payout"
+)
+
+const (
+	defaultPayoutMinimum   = 100
+	defaultPayoutBatchSize = 500
+	// payoutDestinationRemovedReason fails approved requests whose destination
+	// the seller deleted before the batch ran
+	payoutDestinationRemovedReason = "payout destination was removed"
+)
+
+var (
+	ErrPayoutBelowMinimum   = errs.New(errs.ErrValidation, i18n.CodePayoutBelowMinimum, "payout amount is below the minimum")
+	ErrPayoutStatusConflict = errs.New(errs.ErrConflict, i18n.CodePayoutStatusConflict, "payout request is not in the expected state")
+)
+
+type PayoutDestination struct {
+	gorm.Model
+	SellerID      uuid.UUID `gorm:"type:uuid;primaryKey"`
+	AccountName   string
+	AccountNumber string
+	BankCode      string
+}
+
+type PayoutRequest struct {
+	gorm.Model
+	SellerID      uuid.UUID `gorm:"type:uuid;primaryKey"`
+	DestinationID uint
+	Currency      string          `gorm:"size:3;default:THB;not null"`
+	Amount        decimal.Decimal `gorm:"type:decimal(12,2);"`
+	Fee           decimal.Decimal `gorm:"type:decimal(12,2);"`
+	NetAmount     decimal.Decimal `gorm:"type:decimal(12,2);"`
+	Status        string          `gorm:"index"`
+	BatchID       *uint
+	FailureReason string
+}
+
+// PayoutBatch is one submission to the payout provider, every request in it is
+// in Currency so Total is a sum the bank can check
+type PayoutBatch struct {
+	gorm.Model
+	Currency  string `gorm:"size:3;default:THB;not null"`
+	ItemCount int
+	Total     decimal.Decimal `gorm:"type:decimal(14,2);"`
+}
+
+// PayoutMinimum is the smallest amount a seller may withdraw
+func PayoutMinimum() decimal.Decimal {
+	minimum, err := decimal.NewFromString(os.Getenv("PAYOUT_MINIMUM_AMOUNT"))
+	if err != nil {
+		return decimal.NewFromInt(defaultPayoutMinimum)
+	}
+	return minimum
+}
+
+// PayoutFee is the flat fee deducted from every payout
+func PayoutFee() decimal.Decimal {
+	fee, err := decimal.NewFromString(os.Getenv("PAYOUT_FEE"))
+	if err != nil || fee.IsNegative() {
+		return decimal.Zero
+	}
+	return fee
+}
+
+func (d *PayoutDestination) Create(c context.Context) error {
+	return db.GetDB(c).Create(d).Error
+}
+
+func (u *Seller) ListPayoutDestinations(c context.Context) ([]PayoutDestination, error) {
+	var destinations []PayoutDestination
+	if err := db.GetDB(c).
+		Where("seller_id = ? AND deleted_at IS NULL", u.ID).
+		Order("id").
+		Find(&destinations).Error; err != nil {
+		return nil, err
+	}
+	return destinations, nil
+}
+
+func (u *Seller) RetrievePayoutDestination(c context.Context, id uint) (*PayoutDestination, error) {
+	destination := PayoutDestination{}
+	if err := db.GetDB(c).
+		Where("id = ? AND seller_id = ? AND deleted_at IS NULL", id, u.ID).
+		First(&destination).Error; err != nil {
+		return nil, err
+	}
+	return &destination, nil
+}
+
+// DeletePayoutDestination soft deletes the destination so past payout requests
+// keep a valid reference
+func (u *Seller) DeletePayoutDestination(c context.Context, id uint) error {
+	return db.GetDB(c).
+		Model(&PayoutDestination{}).
+		Where("id = ? AND seller_id = ?", id, u.ID).
+		Update("deleted_at", time.Now()).Error
+}
+
+// RequestPayout withdraws amount from the seller wallet in currency straight
+// away and books the fee to the platform, both are reversed if the payout is
+// rejected or fails
+func (u *Seller) RequestPayout(c context.Context, currency string, destinationID uint, amount decimal.Decimal) (*PayoutRequest, error) {
+	if err := ValidateAmount(amount); err != nil {
+		return nil, err
+	}
+	// The minimum and the fee are configured in the default currency
+	rate, err := defaultCurrencyRate(c, currency)
+	if err != nil {
+		return nil, err
+	}
+	if amount.LessThan(PayoutMinimum().Mul(rate).Round(2)) {
+		return nil, ErrPayoutBelowMinimum
+	}
+	fee := PayoutFee().Mul(rate).Round(2)
+	if !amount.GreaterThan(fee) {
+		return nil, ErrPayoutBelowMinimum
+	}
+	if err := requireVerified(c, u.ID); err != nil {
+		return nil, err
+	}
+	if _, err := u.RetrievePayoutDestination(c, destinationID); err != nil {
+		return nil, err
+	}
+
+	request := PayoutRequest{
+		SellerID:      u.ID,
+		DestinationID: destinationID,
+		Currency:      currency,
+		Amount:        amount,
+		Fee:           fee,
+		NetAmount:     amount.Sub(fee),
+		Status:        enums.PayoutPending,
+	}
+	err = db.GetDB(c).Transaction(func(tx *gorm.DB) error {
+		wallet, err := lockSellerWallet(tx, u.ID, currency)
+		if err != nil {
+			return err
+		}
+		if wallet.Frozen {
+			return ErrWalletFrozen
+		}
+		if wallet.Balance.LessThan(amount) {
+			return ErrInsufficientFunds
+		}
+		if err := tx.Create(&request).Error; err != nil {
+			return err
+		}
+		updatedBalance := wallet.Balance.Sub(amount)
+		if err := tx.
+			Model(&wallet).
+			Where("seller_id = ?", u.ID).
+			Update("Balance", updatedBalance).Error; err != nil {
+			return err
+		}
+		if _, err := createSellerTransaction(tx, wallet, enums.TransactionPayout, amount.Neg(), updatedBalance, request.reference()); err != nil {
+			return err
+		}
+		if !fee.IsPositive() {
+			return nil
+		}
+		return creditPlatformWallet(tx, currency, enums.TransactionPayoutFee, fee, request.reference())
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+func (u *Seller) ListPayoutRequests(c context.Context) ([]PayoutRequest, error) {
+	var requests []PayoutRequest
+	if err := db.GetDB(c).
+		Where("seller_id = ?", u.ID).
+		Order("id DESC").
+		Find(&requests).Error; err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+func ListPayoutRequestsByStatus(c context.Context, status string) ([]PayoutRequest, error) {
+	var requests []PayoutRequest
+	if err := db.GetDB(c).
+		Where("status = ?", status).
+		Order("id").
+		Find(&requests).Error; err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+func (p *PayoutRequest) RetrieveByID(c context.Context, id uint) error {
+	return db.GetDB(c).Where("id = ?", id).First(p).Error
+}
+
+func (p *PayoutRequest) reference() string {
+	return fmt.Sprintf("payout:%d", p.ID)
+}
+
+func (p *PayoutRequest) Approve(c context.Context) error {
+	return db.GetDB(c).Transaction(func(tx *gorm.DB) error {
+		return p.transition(tx, enums.PayoutPending, enums.PayoutApproved, nil)
+	})
+}
+
+// Reject fails a pending request and returns the money to the seller wallet
+func (p *PayoutRequest) Reject(c context.Context, reason string) error {
+	err := db.GetDB(c).Transaction(func(tx *gorm.DB) error {
+		return p.fail(tx, enums.PayoutPending, reason)
+	})
+	if err != nil {
+		return err
+	}
+	notifyPayout(c, p)
+	return nil
+}
+
+// transition moves the request from one state to the next, it fails when
+// another worker already moved it
+func (p *PayoutRequest) transition(tx *gorm.DB, from string, to string, updates map[string]interface{}) error {
+	if updates == nil {
+		updates = map[string]interface{}{}
+	}
+	updates["status"] = to
+	result := tx.
+		Model(&PayoutRequest{}).
+		Where("id = ? AND seller_id = ? AND status = ?", p.ID, p.SellerID, from).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrPayoutStatusConflict
+	}
+	p.Status = to
+	return nil
+}
+
+func (p *PayoutRequest) fail(tx *gorm.DB, from string, reason string) error {
+	wallet, err := lockSellerWallet(tx, p.SellerID, p.Currency)
+	if err != nil {
+		return err
+	}
+	if err := p.transition(tx, from, enums.PayoutFailed, map[string]interface{}{"failure_reason": reason}); err != nil {
+		return err
+	}
+	p.FailureReason = reason
+	updatedBalance := wallet.Balance.Add(p.Amount)
+	if err := tx.
+		Model(&wallet).
+		Where("seller_id = ?", p.SellerID).
+		Update("Balance", updatedBalance).Error; err != nil {
+		return err
+	}
+	if _, err := createSellerTransaction(tx, wallet, enums.TransactionPayoutReversal, p.Amount, updatedBalance, p.reference()); err != nil {
+		return err
+	}
+	if !p.Fee.IsPositive() {
+		return nil
+	}
+	return creditPlatformWallet(tx, p.Currency, enums.TransactionPayoutFee, p.Fee.Neg(), p.reference())
+}
+
+// failUnpayable returns an approved request that cannot be sent to the seller
+// wallet, unless another replica already took it
+func (p *PayoutRequest) failUnpayable(c context.Context, reason string) error {
+	err := db.GetDB(c).Transaction(func(tx *gorm.DB) error {
+		return p.fail(tx, enums.PayoutApproved, reason)
+	})
+	if errors.Is(err, ErrPayoutStatusConflict) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	notifyPayout(c, p)
+	return nil
+}
+
+// ProcessPayoutBatch claims approved payout requests and sends them to the
+// provider, one batch per currency, and records the outcome of every item
+func ProcessPayoutBatch(c context.Context, provider payout.Provider) error {
+	var approved []PayoutRequest
+	if err := db.GetDB(c).
+		Where("status = ?", enums.PayoutApproved).
+		Order("id").
+		Limit(defaultPayoutBatchSize).
+		Find(&approved).Error; err != nil {
+		return err
+	}
+	var currencies []string
+	byCurrency := map[string][]PayoutRequest{}
+	for _, request := range approved {
+		if _, ok := byCurrency[request.Currency]; !ok {
+			currencies = append(currencies, request.Currency)
+		}
+		byCurrency[request.Currency] = append(byCurrency[request.Currency], request)
+	}
+	for _, currency := range currencies {
+		if err := submitPayoutBatch(c, provider, currency, byCurrency[currency]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// submitPayoutBatch sends the approved requests of one currency as a batch
+func submitPayoutBatch(c context.Context, provider payout.Provider, currency string, approved []PayoutRequest) error {
+	// Destinations are loaded before anything is claimed, a failed lookup
+	// would strand the requests claimed so far in processing
+	destinations := map[uint]PayoutDestination{}
+	var payable []*PayoutRequest
+	for i := range approved {
+		request := &approved[i]
+		destination := PayoutDestination{}
+		err := db.GetDB(c).
+			Where("id = ? AND seller_id = ? AND deleted_at IS NULL", request.DestinationID, request.SellerID).
+			First(&destination).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if err := request.failUnpayable(c, payoutDestinationRemovedReason); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		destinations[request.ID] = destination
+		payable = append(payable, request)
+	}
+	if len(payable) == 0 {
+		return nil
+	}
+
+	batch := PayoutBatch{Currency: currency}
+	if err := db.GetDB(c).Create(&batch).Error; err != nil {
+		return err
+	}
+	claimed := map[uint]*PayoutRequest{}
+	payoutBatch := payout.Batch{ID: batch.ID, CreatedAt: batch.CreatedAt, Currency: currency}
+	for _, request := range payable {
+		err := db.GetDB(c).Transaction(func(tx *gorm.DB) error {
+			return request.transition(tx, enums.PayoutApproved, enums.PayoutProcessing, map[string]interface{}{"batch_id": batch.ID})
+		})
+		if errors.Is(err, ErrPayoutStatusConflict) {
+			// Claimed by another replica
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		destination := destinations[request.ID]
+		claimed[request.ID] = request
+		payoutBatch.Items = append(payoutBatch.Items, payout.Item{
+			RequestID:     request.ID,
+			SellerID:      request.SellerID,
+			AccountName:   destination.AccountName,
+			AccountNumber: destination.AccountNumber,
+			BankCode:      destination.BankCode,
+			Amount:        request.NetAmount,
+			Currency:      request.Currency,
+			Reference:     request.reference(),
+		})
+	}
+	if len(payoutBatch.Items) == 0 {
+		return db.GetDB(c).Delete(&batch).Error
+	}
+	if err := db.GetDB(c).Model(&batch).Updates(map[string]interface{}{
+		"item_count": len(payoutBatch.Items),
+		"total":      payoutBatch.Total(),
+	}).Error; err != nil {
+		return err
+	}
+
+	// When the submission itself fails the requests stay in processing for an
+	// operator to check with the provider, resending could pay twice
+	results, err := provider.Submit(c, payoutBatch)
+	if err != nil {
+		return err
+	}
+	for _, result := range results {
+		request, ok := claimed[result.RequestID]
+		if !ok {
+			continue
+		}
+		err := db.GetDB(c).Transaction(func(tx *gorm.DB) error {
+			if result.Paid {
+				return request.transition(tx, enums.PayoutProcessing, enums.PayoutPaid, nil)
+			}
+			return request.fail(tx, enums.PayoutProcessing, result.FailureReason)
+		})
+		if err != nil {
+			return err
+		}
+		notifyPayout(c, request)
+	}
+	log.Printf("submitted payout batch %d with %d %s items", batch.ID, len(payoutBatch.Items), currency)
+	return nil
+}
@@ -0,0 +1,163 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"github.com/shopspring/decimal"
+	"testing"
+	"user-service/db"
+	"user-service/enums"
+	"user-service/payout"
+)
+
+// The fee goes to the platform when the payout is requested, a rejected payout
+// returns the whole amount to the seller as a payout reversal and takes the
+// fee back
+func TestPayoutFee(t *testing.T) {
+	requireDB(t)
+	t.Setenv("PAYOUT_FEE", "15")
+	tests := []struct {
+		name         string
+		reject       bool
+		wantStatus   string
+		wantSeller   string
+		wantPlatform string
+	}{
+		{"approved", false, enums.PayoutApproved, "300.00", "15.00"},
+		{"rejected", true, enums.PayoutFailed, "500.00", "0"},
+	}
+	c := context.Background()
+	for _, test := range tests {
+		seller, destination := newTestSeller(t, "500.00")
+		request, err := seller.RequestPayout(c, enums.DefaultCurrency, destination.ID, decimal.NewFromInt(200))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !request.NetAmount.Equal(decimal.NewFromInt(185)) {
+			t.Errorf("%s: net amount %s, want 185", test.name, request.NetAmount)
+		}
+		if test.reject {
+			err = request.Reject(c, "test")
+		} else {
+			err = request.Approve(c)
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		if request.Status != test.wantStatus {
+			t.Errorf("%s: status %s, want %s", test.name, request.Status, test.wantStatus)
+		}
+		if balance := testSellerWallet(t, seller.ID).Balance; !balance.Equal(decimal.RequireFromString(test.wantSeller)) {
+			t.Errorf("%s: seller balance %s, want %s", test.name, balance, test.wantSeller)
+		}
+		if total := platformTotal(t, request.reference()); !total.Equal(decimal.RequireFromString(test.wantPlatform)) {
+			t.Errorf("%s: platform booked %s, want %s", test.name, total, test.wantPlatform)
+		}
+		var reversals int64
+		if err := db.GetDB(c).
+			Model(&SellerWalletTransaction{}).
+			Where("seller_id = ? AND type = ? AND reference = ?", seller.ID, enums.TransactionPayoutReversal, request.reference()).
+			Count(&reversals).Error; err != nil {
+			t.Fatal(err)
+		}
+		if test.reject != (reversals == 1) {
+			t.Errorf("%s: %d payout reversals", test.name, reversals)
+		}
+	}
+}
+
+func TestRequestPayoutRejects(t *testing.T) {
+	requireDB(t)
+	t.Setenv("PAYOUT_FEE", "15")
+	tests := []struct {
+		name    string
+		balance string
+		frozen  bool
+		amount  string
+		want    error
+	}{
+		{"below the minimum", "500.00", false, "99.99", ErrPayoutBelowMinimum},
+		{"more than the balance", "150.00", false, "150.01", ErrInsufficientFunds},
+		{"frozen wallet", "500.00", true, "200.00", ErrWalletFrozen},
+	}
+	c := context.Background()
+	for _, test := range tests {
+		seller, destination := newTestSeller(t, test.balance)
+		if test.frozen {
+			if err := freezeWallet(db.GetDB(c), enums.Seller, seller.ID, enums.DefaultCurrency, "test"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := seller.RequestPayout(c, enums.DefaultCurrency, destination.ID, decimal.RequireFromString(test.amount)); !errors.Is(err, test.want) {
+			t.Errorf("%s: RequestPayout = %v, want %v", test.name, err, test.want)
+		}
+		if balance := testSellerWallet(t, seller.ID).Balance; !balance.Equal(decimal.RequireFromString(test.balance)) {
+			t.Errorf("%s: seller balance %s, want %s", test.name, balance, test.balance)
+		}
+	}
+}
+
+// recordingPayoutProvider pays every item and remembers the batches
+type recordingPayoutProvider struct {
+	batches []payout.Batch
+}
+
+func (p *recordingPayoutProvider) Submit(c context.Context, batch payout.Batch) ([]payout.Result, error) {
+	p.batches = append(p.batches, batch)
+	results := make([]payout.Result, 0, len(batch.Items))
+	for _, item := range batch.Items {
+		results = append(results, payout.Result{RequestID: item.RequestID, Paid: true})
+	}
+	return results, nil
+}
+
+// A request whose destination was removed goes back to the seller, the other
+// requests of the batch are still paid
+func TestProcessPayoutBatchFailsRemovedDestinations(t *testing.T) {
+	requireDB(t)
+	c := context.Background()
+	tests := []struct {
+		name        string
+		removed     bool
+		wantStatus  string
+		wantBalance string
+	}{
+		{"destination removed", true, enums.PayoutFailed, "500.00"},
+		{"destination kept", false, enums.PayoutPaid, "300.00"},
+	}
+	requests := make([]*PayoutRequest, len(tests))
+	sellers := make([]*Seller, len(tests))
+	for i, test := range tests {
+		seller, destination := newTestSeller(t, "500.00")
+		request, err := seller.RequestPayout(c, enums.DefaultCurrency, destination.ID, decimal.NewFromInt(200))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := request.Approve(c); err != nil {
+			t.Fatal(err)
+		}
+		if test.removed {
+			if err := seller.DeletePayoutDestination(c, destination.ID); err != nil {
+				t.Fatal(err)
+			}
+		}
+		requests[i], sellers[i] = request, seller
+	}
+
+	if err := ProcessPayoutBatch(c, &recordingPayoutProvider{}); err != nil {
+		t.Fatal(err)
+	}
+	for i, test := range tests {
+		request := PayoutRequest{}
+		if err := request.RetrieveByID(c, requests[i].ID); err != nil {
+			t.Fatal(err)
+		}
+		if request.Status != test.wantStatus {
+			t.Errorf("%s: status %s, want %s", test.name, request.Status, test.wantStatus)
+		}
+		if balance := testSellerWallet(t, sellers[i].ID).Balance; !balance.Equal(decimal.RequireFromString(test.wantBalance)) {
+			t.Errorf("%s: seller balance %s, want %s", test.name, balance, test.wantBalance)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+	"user-service/db"
+	"user-service/enums"
+	"user-service/errs"
+	"user-service/i18n"
+)
+
+const defaultCallingCode = "66"
+
+var (
+	ErrInvalidPhone = errs.New(errs.ErrValidation, i18n.CodeInvalidPhone, "phone number must be in E.164 format")
+	ErrPhoneTaken   = errs.New(errs.ErrConflict, i18n.CodePhoneTaken, "phone number is already in use")
+)
+
+var e164Pattern = regexp.MustCompile(`^\+[1-9]\d{7,14}$`)
+
+// PhoneNumber is a reference table so a phone can be looked up without
+// knowing the user, and stays unique within a group across shards
+type PhoneNumber struct {
+	UserGroup string    `gorm:"primaryKey"`
+	Phone     string    `gorm:"primaryKey"`
+	UserID    uuid.UUID `gorm:"type:uuid;index"`
+	CreatedAt time.Time
+}
+
+// NormalizePhone turns a phone number as typed into E.164. Numbers in
+// national format, with a leading 0, get PHONE_DEFAULT_CALLING_CODE or +66
+func NormalizePhone(raw string) (string, error) {
+	phone := strings.NewReplacer(" ", "", "-", "", "(", "", ")", "", ".", "").Replace(strings.TrimSpace(raw))
+	switch {
+	case strings.HasPrefix(phone, "+"):
+	case strings.HasPrefix(phone, "00"):
+		phone = "+" + phone[2:]
+	case strings.HasPrefix(phone, "0"):
+		callingCode := os.Getenv("PHONE_DEFAULT_CALLING_CODE")
+		if callingCode == "" {
+			callingCode = defaultCallingCode
+		}
+		phone = "+" + strings.TrimPrefix(callingCode, "+") + phone[1:]
+	default:
+		return "", ErrInvalidPhone
+	}
+	if !e164Pattern.MatchString(phone) {
+		return "", ErrInvalidPhone
+	}
+	return phone, nil
+}
+
+// phoneOwner returns the user of group the phone belongs to
+func phoneOwner(c context.Context, group string, phone string) (uuid.UUID, error) {
+	var number PhoneNumber
+	if err := db.GetDB(c).
+		Where("user_group = ? AND phone = ?", group, phone).
+		First(&number).Error; err != nil {
+		return uuid.Nil, err
+	}
+	return number.UserID, nil
+}
+
+// requestPhoneVerification sends a code proving the user owns phone
+func requestPhoneVerification(c context.Context, group string, userID uuid.UUID, raw string) (string, error) {
+	phone, err := NormalizePhone(raw)
+	if err != nil {
+		return "", err
+	}
+	owner, err := phoneOwner(c, group, phone)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+	if err == nil && owner != userID {
+		return "", ErrPhoneTaken
+	}
+	return phone, issueOTP(c, group, userID, phone, enums.OTPPurposeVerifyPhone)
+}
+
+// verifyPhone checks the code and moves the user to the new phone
+func verifyPhone(c context.Context, group string, user interface{}, userID uuid.UUID, raw string, code string, ip string) error {
+	phone, err := NormalizePhone(raw)
+	if err != nil {
+		return err
+	}
+	if err := verifyOTP(c, group, userID, phone, enums.OTPPurposeVerifyPhone, code, ip); err != nil {
+		return err
+	}
+	var previous PhoneNumber
+	err = db.GetDB(c).Transaction(func(tx *gorm.DB) error {
+		err := tx.
+			Where("user_group = ? AND user_id = ?", group, userID).
+			First(&previous).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if previous.Phone != phone {
+			if previous.Phone != "" {
+				if err := tx.
+					Where("user_group = ? AND phone = ?", group, previous.Phone).
+					Delete(&PhoneNumber{}).Error; err != nil {
+					return err
+				}
+			}
+			result := tx.
+				Clauses(clause.OnConflict{DoNothing: true}).
+				Create(&PhoneNumber{UserGroup: group, Phone: phone, UserID: userID})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return ErrPhoneTaken
+			}
+		}
+		return tx.
+			Model(user).
+			Where("id = ?", userID).
+			Updates(map[string]interface{}{"phone": phone, "phone_verified_at": time.Now()}).Error
+	})
+	if err != nil {
+		return err
+	}
+	if previous.Phone != "" && previous.Phone != phone {
+		notifyPhoneChanged(c, group, userID, previous.Phone, phone)
+	}
+	return nil
+}
+
+// phoneLoginUser checks a login code coming from ip and returns the user it
+// was sent to. Guesses against unknown phones count toward the IP lockout
+func phoneLoginUser(c context.Context, group string, raw string, code string, ip string) (uuid.UUID, error) {
+	phone, err := NormalizePhone(raw)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	userID, err := phoneOwner(c, group, phone)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return uuid.Nil, rejectUnknownPhoneLogin(c, phone, ip)
+	}
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if err := verifyOTP(c, group, userID, phone, enums.OTPPurposeLogin, code, ip); err != nil {
+		return uuid.Nil, err
+	}
+	return userID, nil
+}
+
+// rejectUnknownPhoneLogin answers like a wrong code would, lockout included
+func rejectUnknownPhoneLogin(c context.Context, phone string, ip string) error {
+	err := db.GetDB(c).Transaction(func(tx *gorm.DB) error {
+		if err := checkOTPLockout(tx, phone, ip); err != nil {
+			return err
+		}
+		return recordOTPFailure(tx, phone, ip)
+	})
+	if err != nil {
+		return err
+	}
+	return ErrInvalidOTP
+}
+
+// RequestLoginOTP sends a login code when phone belongs to a user of group.
+// Unknown numbers and numbers asked for too often get no message and no
+// error, so callers cannot tell which numbers are registered
+func RequestLoginOTP(c context.Context, group string, raw string) (string, error) {
+	phone, err := NormalizePhone(raw)
+	if err != nil {
+		return "", err
+	}
+	userID, err := phoneOwner(c, group, phone)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return phone, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	err = issueOTP(c, group, userID, phone, enums.OTPPurposeLogin)
+	if errors.Is(err, ErrOTPTooSoon) || errors.Is(err, ErrOTPDailyLimit) {
+		return phone, nil
+	}
+	return phone, err
+}
+
+// RequestPhoneVerification sends a code to phone, the buyer phone changes
+// once the code is confirmed. It returns the normalized phone
+func (u *Buyer) RequestPhoneVerification(c context.Context, phone string) (string, error) {
+	return requestPhoneVerification(c, enums.Buyer, u.ID, phone)
+}
+
+// VerifyPhone confirms the code sent to phone from ip and makes it the buyer
+// phone
+func (u *Buyer) VerifyPhone(c context.Context, phone string, code string, ip string) error {
+	if err := verifyPhone(c, enums.Buyer, &Buyer{}, u.ID, phone, code, ip); err != nil {
+		return err
+	}
+	return u.RetrieveByUserIDWithProfile(c, u.ID)
+}
+
+// LoginWithOTP loads the buyer a login code was sent to, ip is where the code
+// was typed in
+func (u *Buyer) LoginWithOTP(c context.Context, phone string, code string, ip string) error {
+	userID, err := phoneLoginUser(c, enums.Buyer, phone, code, ip)
+	if err != nil {
+		return err
+	}
+	return u.RetrieveByUserIDWithProfile(c, userID)
+}
+
+// RequestPhoneVerification sends a code to phone, the seller phone changes
+// once the code is confirmed. It returns the normalized phone
+func (u *Seller) RequestPhoneVerification(c context.Context, phone string) (string, error) {
+	return requestPhoneVerification(c, enums.Seller, u.ID, phone)
+}
+
+// VerifyPhone confirms the code sent to phone from ip and makes it the seller
+// phone
+func (u *Seller) VerifyPhone(c context.Context, phone string, code string, ip string) error {
+	if err := verifyPhone(c, enums.Seller, &Seller{}, u.ID, phone, code, ip); err != nil {
+		return err
+	}
+	return u.RetrieveByUserIDWithProfile(c, u.ID)
+}
+
+// LoginWithOTP loads the seller a login code was sent to, ip is where the code
+// was typed in
+func (u *Seller) LoginWithOTP(c context.Context, phone string, code string, ip string) error {
+	userID, err := phoneLoginUser(c, enums.Seller, phone, code, ip)
+	if err != nil {
+		return err
+	}
+	return u.RetrieveByUserIDWithProfile(c, userID)
+}
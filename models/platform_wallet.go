@@ -0,0 +1,33 @@
+package models
+
+import (
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// PlatformLedgerEntry books money the marketplace earns or spends: commission
+// in, promo funding and referral rewards out.
+//
+// The platform wallet has no stored balance to lock, every booking appends an
+// entry and the balance of a currency is the sum of its entries. Settlements
+// running in parallel therefore never wait on each other for the platform
+// side. Entries are distributed by reference so they spread over the workers
+// and land on the same shard as other bookings of that reference
+type PlatformLedgerEntry struct {
+	gorm.Model
+	Reference string `gorm:"primaryKey"`
+	Currency  string `gorm:"size:3;default:THB;not null;index"`
+	Type      string
+	Amount    decimal.Decimal `gorm:"type:decimal(14,2);"`
+}
+
+// creditPlatformWallet books amount on the platform wallet of currency, amount
+// may be negative when a commission is reversed
+func creditPlatformWallet(tx *gorm.DB, currency string, transactionType string, amount decimal.Decimal, reference string) error {
+	return tx.Create(&PlatformLedgerEntry{
+		Reference: reference,
+		Currency:  currency,
+		Type:      transactionType,
+		Amount:    amount,
+	}).Error
+}
@@ -0,0 +1,181 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+	"user-service/db"
+	"user-service/enums"
+	"user-service/errs"
+	"user-service/i18n"
+	"user-service/notify"
+)
+
+var (
+	ErrInvalidLocale   = errs.New(errs.ErrValidation, i18n.CodeInvalidLocale, "unsupported locale")
+	ErrInvalidTimezone = errs.New(errs.ErrValidation, i18n.CodeInvalidTimezone, "unknown timezone")
+	// ErrSecurityAlertsRequired keeps account takeover alerts from being muted
+	ErrSecurityAlertsRequired = errs.New(errs.ErrValidation, i18n.CodeSecurityAlertsRequired, "security alerts need at least one channel")
+)
+
+// defaultNotifications are the channels of every event until the user
+// changes them. Promotions also need the marketing opt-in of the channel.
+// Only SMS is delivered today, email and push stay off until they are
+// registered so the defaults do not promise alerts nobody sends
+var defaultNotifications = map[string]map[string]bool{
+	enums.NotificationSecurityAlerts: {enums.ChannelEmail: false, enums.ChannelSMS: true, enums.ChannelPush: false},
+	enums.NotificationPayouts:        {enums.ChannelEmail: false, enums.ChannelSMS: true, enums.ChannelPush: false},
+	enums.NotificationPromotions:     {enums.ChannelEmail: false, enums.ChannelSMS: true, enums.ChannelPush: false},
+}
+
+// UserPreference holds the settings of a buyer or seller, users without a
+// row get DefaultPreferences
+type UserPreference struct {
+	UserID    uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserGroup string
+	Locale    string
+	Timezone  string
+	// Currency amounts are displayed in, wallets keep their own
+	Currency       string `gorm:"size:3"`
+	MarketingEmail bool   `gorm:"default:false;not null"`
+	MarketingSMS   bool   `gorm:"default:false;not null"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	// Notifications maps event to channel to whether it is enabled
+	Notifications map[string]map[string]bool `gorm:"-"`
+}
+
+// NotificationSetting is one event and channel pair of a user
+type NotificationSetting struct {
+	UserID  uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Event   string    `gorm:"primaryKey"`
+	Channel string    `gorm:"primaryKey"`
+	Enabled bool      `gorm:"not null"`
+}
+
+func DefaultPreferences(group string, userID uuid.UUID) UserPreference {
+	return UserPreference{
+		UserID:        userID,
+		UserGroup:     group,
+		Locale:        enums.DefaultLocale,
+		Timezone:      enums.DefaultTimezone,
+		Currency:      enums.DefaultCurrency,
+		Notifications: copyNotifications(defaultNotifications),
+	}
+}
+
+func copyNotifications(source map[string]map[string]bool) map[string]map[string]bool {
+	notifications := make(map[string]map[string]bool, len(source))
+	for event, channels := range source {
+		notifications[event] = make(map[string]bool, len(channels))
+		for channel, enabled := range channels {
+			notifications[event][channel] = enabled
+		}
+	}
+	return notifications
+}
+
+// GetPreferences loads the preferences of a user, filling in defaults for
+// anything never saved
+func GetPreferences(c context.Context, group string, userID uuid.UUID) (UserPreference, error) {
+	preference := DefaultPreferences(group, userID)
+	err := db.GetDB(c).
+		Where("user_id = ? AND user_group = ?", userID, group).
+		First(&preference).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return preference, err
+	}
+	var settings []NotificationSetting
+	if err := db.GetDB(c).Where("user_id = ?", userID).Find(&settings).Error; err != nil {
+		return preference, err
+	}
+	for _, setting := range settings {
+		if channels, ok := preference.Notifications[setting.Event]; ok {
+			if _, ok := channels[setting.Channel]; ok {
+				channels[setting.Channel] = setting.Enabled
+			}
+		}
+	}
+	return preference, nil
+}
+
+// PreferredLocale is the locale of a user, the default when it cannot be
+// loaded
+func PreferredLocale(c context.Context, group string, userID uuid.UUID) string {
+	preference, err := GetPreferences(c, group, userID)
+	if err != nil {
+		return enums.DefaultLocale
+	}
+	return preference.Locale
+}
+
+// SavePreferences replaces every preference of the user, events and channels
+// missing from Notifications get their default. Security alerts must stay on
+// for at least one channel that delivers
+func SavePreferences(c context.Context, preference *UserPreference) error {
+	if !enums.IsValidLocale(preference.Locale) {
+		return ErrInvalidLocale
+	}
+	if _, err := time.LoadLocation(preference.Timezone); err != nil || preference.Timezone == "" {
+		return ErrInvalidTimezone
+	}
+	if !IsSupportedCurrency(c, preference.Currency) {
+		return ErrInvalidCurrency
+	}
+	notifications := copyNotifications(defaultNotifications)
+	for event, channels := range preference.Notifications {
+		for channel, enabled := range channels {
+			if _, ok := notifications[event][channel]; ok {
+				notifications[event][channel] = enabled
+			}
+		}
+	}
+	if !deliversOnAnyChannel(notifications[enums.NotificationSecurityAlerts]) {
+		return ErrSecurityAlertsRequired
+	}
+	preference.Notifications = notifications
+
+	return db.GetDB(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "user_id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"locale", "timezone", "currency", "marketing_email", "marketing_sms", "updated_at"}),
+			}).
+			Create(preference).Error; err != nil {
+			return err
+		}
+		for _, event := range enums.NotificationEvents {
+			for _, channel := range enums.NotificationChannels {
+				setting := NotificationSetting{
+					UserID:  preference.UserID,
+					Event:   event,
+					Channel: channel,
+					Enabled: notifications[event][channel],
+				}
+				if err := tx.
+					Clauses(clause.OnConflict{
+						Columns:   []clause.Column{{Name: "user_id"}, {Name: "event"}, {Name: "channel"}},
+						DoUpdates: clause.AssignmentColumns([]string{"enabled"}),
+					}).
+					Create(&setting).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// deliversOnAnyChannel reports whether one of the enabled channels is
+// registered
+func deliversOnAnyChannel(channels map[string]bool) bool {
+	for name, enabled := range channels {
+		if _, ok := notify.GetChannel(name); enabled && ok {
+			return true
+		}
+	}
+	return false
+}
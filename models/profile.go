@@ -0,0 +1,127 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+	"user-service/db"
+	"user-service/errs"
+	"user-service/i18n"
+)
+
+const maxProfileNameLength = 100
+
+var (
+	ErrEmptyProfileUpdate = errs.New(errs.ErrValidation, i18n.CodeEmptyProfileUpdate, "nothing to update")
+	ErrInvalidProfileName = errs.New(errs.ErrValidation, i18n.CodeInvalidProfileName, "names must be 1 to 100 characters without control characters")
+	// ErrProfileModified means the profile changed since the version the
+	// client based its update on
+	ErrProfileModified = errs.New(errs.ErrPreconditionFailed, i18n.CodeProfileModified, "profile was modified by another request")
+)
+
+// ProfileUpdate holds the fields of a partial profile update, nil fields are
+// left unchanged
+type ProfileUpdate struct {
+	FirstName *string
+	LastName  *string
+}
+
+// columns validates the update and returns the columns to write, empty when
+// no field is set
+func (p ProfileUpdate) columns() (map[string]interface{}, error) {
+	columns := map[string]interface{}{}
+	for column, value := range map[string]*string{"first_name": p.FirstName, "last_name": p.LastName} {
+		if value == nil {
+			continue
+		}
+		name, err := normalizeProfileName(*value)
+		if err != nil {
+			return nil, err
+		}
+		columns[column] = name
+	}
+	return columns, nil
+}
+
+func normalizeProfileName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxProfileNameLength {
+		return "", ErrInvalidProfileName
+	}
+	for _, r := range name {
+		if unicode.IsControl(r) {
+			return "", ErrInvalidProfileName
+		}
+	}
+	return name, nil
+}
+
+// UpdateProfile applies update to the buyer profile as long as it was last
+// changed at version, then reloads the buyer with its profile
+func (u *Buyer) UpdateProfile(c context.Context, update ProfileUpdate, version time.Time) error {
+	columns, err := update.columns()
+	if err != nil {
+		return err
+	}
+	if len(columns) == 0 {
+		return ErrEmptyProfileUpdate
+	}
+	columns["updated_at"] = time.Now()
+	result := db.GetDB(c).
+		Model(&BuyerProfile{}).
+		Where("buyer_id = ? AND updated_at = ? AND deleted_at IS NULL", u.ID, version).
+		Updates(columns)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrProfileModified
+	}
+	return u.RetrieveByUserIDWithProfile(c, u.ID)
+}
+
+// UpdateProfile applies update to the seller profile as long as it was last
+// changed at version, then reloads the seller with its profile. Slug and
+// social links change in the same transaction
+func (u *Seller) UpdateProfile(c context.Context, update SellerProfileUpdate, version time.Time) error {
+	columns, err := update.columns()
+	if err != nil {
+		return err
+	}
+	columns["updated_at"] = time.Now()
+	err = db.GetDB(c).Transaction(func(tx *gorm.DB) error {
+		var profile SellerProfile
+		if err := tx.
+			Where("seller_id = ? AND updated_at = ? AND deleted_at IS NULL", u.ID, version).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&profile).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrProfileModified
+			}
+			return err
+		}
+		if slug, ok := columns["slug"].(string); ok {
+			if err := claimSlug(tx, u.ID, profile.Slug, slug); err != nil {
+				return err
+			}
+		}
+		if update.SocialLinks != nil {
+			if err := replaceSocialLinks(tx, u.ID, *update.SocialLinks); err != nil {
+				return err
+			}
+		}
+		return tx.
+			Model(&profile).
+			Where("seller_id = ?", u.ID).
+			Updates(columns).Error
+	})
+	if err != nil {
+		return err
+	}
+	return u.RetrieveByUserIDWithProfile(c, u.ID)
+}
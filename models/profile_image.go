@@ -0,0 +1,202 @@
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"log"
+	"path"
+	"strings"
+	"user-service/db"
+	"user-service/enums"
+	"user-service/errs"
+	"user-service/i18n"
+	"user-service/imaging"
+	"user-service/storage"
+)
+
+const defaultImageMaxBytes = 5 << 20
+
+var ErrInvalidImageKind = errs.New(errs.ErrNotFound, i18n.CodeInvalidImageKind, "invalid image kind")
+
+var squareVariants = []imaging.Variant{
+	{Name: "small", Width: 64, Square: true},
+	{Name: "medium", Width: 256, Square: true},
+	{Name: "large", Width: 512, Square: true},
+}
+
+// imageVariants lists the sizes rendered for every kind of image
+var imageVariants = map[string][]imaging.Variant{
+	enums.ImageAvatar: squareVariants,
+	enums.ImageLogo:   squareVariants,
+	enums.ImageBanner: {
+		{Name: "small", Width: 640},
+		{Name: "medium", Width: 1280},
+		{Name: "large", Width: 1920},
+	},
+}
+
+// ImageMaxBytes is the largest accepted upload, IMAGE_MAX_BYTES or 5 MiB
+func ImageMaxBytes() int64 {
+	return int64(intFromEnv("IMAGE_MAX_BYTES", defaultImageMaxBytes))
+}
+
+// ImageURLs maps the variant names of a stored image to their URLs, nil when
+// there is no image
+func ImageURLs(kind string, key string) map[string]string {
+	if key == "" {
+		return nil
+	}
+	urls := make(map[string]string, len(imageVariants[kind]))
+	for _, variant := range imageVariants[kind] {
+		urls[variant.Name] = storage.GetImageStore().URL(imageVariantKey(key, variant.Name))
+	}
+	return urls
+}
+
+// imageVariantKey derives the key of a variant from the image key, an image
+// stored as avatars/<user>/<id>.jpg has its medium variant at
+// avatars/<user>/<id>_medium.jpg
+func imageVariantKey(key string, variant string) string {
+	extension := path.Ext(key)
+	return strings.TrimSuffix(key, extension) + "_" + variant + extension
+}
+
+// storeImage renders and uploads every variant of an upload and returns the
+// image key
+func storeImage(c context.Context, kind string, userID uuid.UUID, data []byte) (string, error) {
+	variants, ok := imageVariants[kind]
+	if !ok {
+		return "", ErrInvalidImageKind
+	}
+	outputs, err := imaging.Process(data, ImageMaxBytes(), variants)
+	if err != nil {
+		return "", err
+	}
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	// Every variant of an upload is encoded the same way
+	key := kind + "s/" + userID.String() + "/" + hex.EncodeToString(id) + "." + outputs[0].Extension
+	store := storage.GetImageStore()
+	for i, output := range outputs {
+		if err := store.Put(c, imageVariantKey(key, output.Name), output.ContentType, output.Data); err != nil {
+			deleteImageVariants(c, key, outputs[:i])
+			return "", err
+		}
+	}
+	return key, nil
+}
+
+// deleteImage removes every variant of an image, failures are only logged
+// since the profile no longer points to it
+func deleteImage(c context.Context, kind string, key string) {
+	if key == "" {
+		return
+	}
+	var outputs []imaging.Output
+	for _, variant := range imageVariants[kind] {
+		outputs = append(outputs, imaging.Output{Name: variant.Name})
+	}
+	deleteImageVariants(c, key, outputs)
+}
+
+func deleteImageVariants(c context.Context, key string, outputs []imaging.Output) {
+	for _, output := range outputs {
+		variantKey := imageVariantKey(key, output.Name)
+		if err := storage.GetImageStore().Delete(c, variantKey); err != nil {
+			log.Printf("failed to delete image %s: %v", variantKey, err)
+		}
+	}
+}
+
+// SetAvatar replaces the buyer avatar with an upload, or removes it when data
+// is nil. The profile row is locked while the key is swapped, so of two
+// uploads at once each deletes exactly the image it replaced
+func (u *Buyer) SetAvatar(c context.Context, data []byte) error {
+	if err := u.RetrieveByUserIDWithProfile(c, u.ID); err != nil {
+		return err
+	}
+	key := ""
+	if data != nil {
+		var err error
+		if key, err = storeImage(c, enums.ImageAvatar, u.ID, data); err != nil {
+			return err
+		}
+	}
+	var previous string
+	err := db.GetDB(c).Transaction(func(tx *gorm.DB) error {
+		var profile BuyerProfile
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("buyer_id = ? AND deleted_at IS NULL", u.ID).
+			First(&profile).Error; err != nil {
+			return err
+		}
+		previous = profile.Avatar
+		return tx.Model(&profile).
+			Where("buyer_id = ?", u.ID).
+			Update("Avatar", key).Error
+	})
+	if err != nil {
+		deleteImage(c, enums.ImageAvatar, key)
+		return err
+	}
+	u.BuyerProfile.Avatar = key
+	deleteImage(c, enums.ImageAvatar, previous)
+	return nil
+}
+
+// SetImage replaces the seller logo or banner with an upload, or removes it
+// when data is nil. The profile row is locked like in SetAvatar
+func (u *Seller) SetImage(c context.Context, kind string, data []byte) error {
+	var column string
+	switch kind {
+	case enums.ImageLogo:
+		column = "Logo"
+	case enums.ImageBanner:
+		column = "Banner"
+	default:
+		return ErrInvalidImageKind
+	}
+	if err := u.RetrieveByUserIDWithProfile(c, u.ID); err != nil {
+		return err
+	}
+	key := ""
+	if data != nil {
+		var err error
+		if key, err = storeImage(c, kind, u.ID, data); err != nil {
+			return err
+		}
+	}
+	var previous string
+	err := db.GetDB(c).Transaction(func(tx *gorm.DB) error {
+		var profile SellerProfile
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("seller_id = ? AND deleted_at IS NULL", u.ID).
+			First(&profile).Error; err != nil {
+			return err
+		}
+		previous = profile.Logo
+		if kind == enums.ImageBanner {
+			previous = profile.Banner
+		}
+		return tx.Model(&profile).
+			Where("seller_id = ?", u.ID).
+			Update(column, key).Error
+	})
+	if err != nil {
+		deleteImage(c, kind, key)
+		return err
+	}
+	if kind == enums.ImageBanner {
+		u.SellerProfile.Banner = key
+	} else {
+		u.SellerProfile.Logo = key
+	}
+	deleteImage(c, kind, previous)
+	return nil
+}
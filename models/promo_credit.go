@@ -0,0 +1,255 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"log"
+	"os"
+	"time"
+	"user-service/db"
+	"user-service/enums"
+	"user-service/errs"
+	"user-service/i18n"
+)
+
+const (
+	defaultPromoRefundTTL = time.Hour * 24 * 30
+	promoRefundCampaign   = "refund"
+)
+
+var ErrPromoExpiryInPast = errs.New(errs.ErrValidation, i18n.CodePromoExpiryInPast, "promo credit must expire in the future")
+
+// BuyerPromoCredit is one grant of promotional credit. Remaining goes down as
+// the buyer spends it, grants closest to expiry are spent first
+type BuyerPromoCredit struct {
+	gorm.Model
+	BuyerID   uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Currency  string    `gorm:"size:3;default:THB;not null"`
+	Campaign  string
+	Amount    decimal.Decimal `gorm:"type:decimal(12,2);"`
+	Remaining decimal.Decimal `gorm:"type:decimal(12,2);"`
+	ExpiresAt time.Time       `gorm:"index"`
+}
+
+// promoRefundTTL is how long promo credit returned by a refund stays valid
+func promoRefundTTL() time.Duration {
+	ttl, err := time.ParseDuration(os.Getenv("PROMO_REFUND_TTL"))
+	if err != nil || ttl <= 0 {
+		return defaultPromoRefundTTL
+	}
+	return ttl
+}
+
+func (p *BuyerPromoCredit) reference() string {
+	return fmt.Sprintf("promo:%d", p.ID)
+}
+
+// GrantPromoCredit adds promotional credit from campaign to the buyer wallet in
+// currency, it can be spent until expiresAt
+func (u *Buyer) GrantPromoCredit(c context.Context, currency string, amount decimal.Decimal, campaign string, expiresAt time.Time) (*BuyerPromoCredit, error) {
+	if err := ValidateAmount(amount); err != nil {
+		return nil, err
+	}
+	if !expiresAt.After(time.Now()) {
+		return nil, ErrPromoExpiryInPast
+	}
+	var credit *BuyerPromoCredit
+	err := db.GetDB(c).Transaction(func(tx *gorm.DB) error {
+		wallet, err := lockBuyerWallet(tx, u.ID, currency)
+		if err != nil {
+			return err
+		}
+		credit, err = grantPromoCredit(tx, wallet, amount, campaign, expiresAt)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	notifyPromoCredit(c, credit)
+	return credit, nil
+}
+
+// grantPromoCredit books the grant on a locked wallet
+func grantPromoCredit(tx *gorm.DB, wallet BuyerWallet, amount decimal.Decimal, campaign string, expiresAt time.Time) (*BuyerPromoCredit, error) {
+	credit, err := createPromoCredit(tx, wallet, amount, campaign, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.
+		Model(&wallet).
+		Where("buyer_id = ?", wallet.BuyerID).
+		Update("PromoBalance", wallet.PromoBalance.Add(amount)).Error; err != nil {
+		return nil, err
+	}
+	return credit, tx.Create(&BuyerWalletTransaction{
+		BuyerID:      wallet.BuyerID,
+		Currency:     wallet.Currency,
+		Type:         enums.TransactionPromoCredit,
+		Amount:       amount,
+		PromoAmount:  amount,
+		BalanceAfter: wallet.Balance,
+		Reference:    credit.reference(),
+	}).Error
+}
+
+// createPromoCredit only records the grant, the caller updates the wallet
+// promo balance and the ledger
+func createPromoCredit(tx *gorm.DB, wallet BuyerWallet, amount decimal.Decimal, campaign string, expiresAt time.Time) (*BuyerPromoCredit, error) {
+	credit := BuyerPromoCredit{
+		BuyerID:   wallet.BuyerID,
+		Currency:  wallet.Currency,
+		Campaign:  campaign,
+		Amount:    amount,
+		Remaining: amount,
+		ExpiresAt: expiresAt,
+	}
+	if err := tx.Create(&credit).Error; err != nil {
+		return nil, err
+	}
+	return &credit, nil
+}
+
+// consumePromoCredit spends up to amount of the unexpired promo credit of a
+// locked wallet, soonest expiring first, and returns how much was spent
+func consumePromoCredit(tx *gorm.DB, wallet BuyerWallet, amount decimal.Decimal) (decimal.Decimal, error) {
+	var credits []BuyerPromoCredit
+	if err := tx.
+		Where("buyer_id = ? AND currency = ? AND remaining > 0 AND expires_at > ?", wallet.BuyerID, wallet.Currency, time.Now()).
+		Order("expires_at").
+		Order("id").
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Find(&credits).Error; err != nil {
+		return decimal.Zero, err
+	}
+	spent := decimal.Zero
+	for _, credit := range credits {
+		if !spent.LessThan(amount) {
+			break
+		}
+		take := decimal.Min(credit.Remaining, amount.Sub(spent))
+		if err := tx.
+			Model(&credit).
+			Where("buyer_id = ?", credit.BuyerID).
+			Update("Remaining", credit.Remaining.Sub(take)).Error; err != nil {
+			return decimal.Zero, err
+		}
+		spent = spent.Add(take)
+	}
+	return spent, nil
+}
+
+// spendBuyerWallet charges amount to a locked wallet, promo credit first and
+// cash for the rest, and appends the purchase ledger entry. fromHold tells
+// whether the amount was reserved by an escrow hold, the funds are then
+// already set aside and the whole hold is released
+func spendBuyerWallet(tx *gorm.DB, wallet BuyerWallet, amount decimal.Decimal, reference string, fromHold bool) (*BuyerWalletTransaction, error) {
+	if wallet.Frozen {
+		return nil, ErrWalletFrozen
+	}
+	promoSpent, err := consumePromoCredit(tx, wallet, amount)
+	if err != nil {
+		return nil, err
+	}
+	cashSpent := amount.Sub(promoSpent)
+	if !fromHold && wallet.SpendableBalance().LessThan(amount) {
+		return nil, ErrInsufficientFunds
+	}
+	// A hold counted promo credit that may have expired since, the cash
+	// must cover what the promo credit no longer does
+	if fromHold && wallet.Balance.LessThan(cashSpent) {
+		return nil, ErrInsufficientFunds
+	}
+
+	balance := wallet.Balance.Sub(cashSpent)
+	updates := map[string]interface{}{
+		"balance":       balance,
+		"promo_balance": wallet.PromoBalance.Sub(promoSpent),
+	}
+	if fromHold {
+		updates["held_balance"] = wallet.HeldBalance.Sub(amount)
+	}
+	if err := tx.
+		Model(&wallet).
+		Where("buyer_id = ?", wallet.BuyerID).
+		Updates(updates).Error; err != nil {
+		return nil, err
+	}
+	transaction := BuyerWalletTransaction{
+		BuyerID:      wallet.BuyerID,
+		Currency:     wallet.Currency,
+		Type:         enums.TransactionPurchase,
+		Amount:       amount.Neg(),
+		PromoAmount:  promoSpent.Neg(),
+		BalanceAfter: balance,
+		Reference:    reference,
+	}
+	if err := tx.Create(&transaction).Error; err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+// ExpirePromoCredits removes promo credit past its expiry from the wallets and
+// posts a ledger entry for each expired grant
+func ExpirePromoCredits(c context.Context) error {
+	var credits []BuyerPromoCredit
+	if err := db.GetDB(c).
+		Where("remaining > 0 AND expires_at <= ?", time.Now()).
+		Find(&credits).Error; err != nil {
+		return err
+	}
+	for i := range credits {
+		if err := credits[i].expire(c); err != nil {
+			return err
+		}
+	}
+	if len(credits) > 0 {
+		log.Printf("expired %d promo credits", len(credits))
+	}
+	return nil
+}
+
+func (p *BuyerPromoCredit) expire(c context.Context) error {
+	return db.GetDB(c).Transaction(func(tx *gorm.DB) error {
+		wallet, err := lockBuyerWallet(tx, p.BuyerID, p.Currency)
+		if err != nil {
+			return err
+		}
+		if err := tx.
+			Where("id = ? AND buyer_id = ?", p.ID, p.BuyerID).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(p).Error; err != nil {
+			return err
+		}
+		// Spent in the meantime
+		if !p.Remaining.IsPositive() {
+			return nil
+		}
+		expired := p.Remaining
+		if err := tx.
+			Model(p).
+			Where("buyer_id = ?", p.BuyerID).
+			Update("Remaining", decimal.Zero).Error; err != nil {
+			return err
+		}
+		if err := tx.
+			Model(&wallet).
+			Where("buyer_id = ?", p.BuyerID).
+			Update("PromoBalance", wallet.PromoBalance.Sub(expired)).Error; err != nil {
+			return err
+		}
+		return tx.Create(&BuyerWalletTransaction{
+			BuyerID:      p.BuyerID,
+			Currency:     p.Currency,
+			Type:         enums.TransactionPromoExpiry,
+			Amount:       expired.Neg(),
+			PromoAmount:  expired.Neg(),
+			BalanceAfter: wallet.Balance,
+			Reference:    p.reference(),
+		}).Error
+	})
+}
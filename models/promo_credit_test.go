@@ -0,0 +1,50 @@
+package models
+
+import (
+	"context"
+	"github.com/shopspring/decimal"
+	"testing"
+	"time"
+	"user-service/db"
+	"user-service/enums"
+)
+
+// Grants are spent soonest expiring first whatever order they were granted in
+func TestPromoCreditSpentSoonestExpiringFirst(t *testing.T) {
+	requireDB(t)
+	tests := []struct {
+		name          string
+		grants        []time.Duration
+		amount        string
+		wantRemaining []string
+	}{
+		{"granted in expiry order", []time.Duration{time.Hour, 2 * time.Hour}, "15.00", []string{"0", "5.00"}},
+		{"granted in reverse expiry order", []time.Duration{2 * time.Hour, time.Hour}, "15.00", []string{"5.00", "0"}},
+		{"part of the first to expire", []time.Duration{2 * time.Hour, time.Hour}, "4.00", []string{"10.00", "6.00"}},
+		{"every grant and cash", []time.Duration{2 * time.Hour, time.Hour}, "25.00", []string{"0", "0"}},
+	}
+	c := context.Background()
+	for _, test := range tests {
+		buyer := newTestBuyer(t, "100.00")
+		var credits []*BuyerPromoCredit
+		for _, ttl := range test.grants {
+			credit, err := buyer.GrantPromoCredit(c, enums.DefaultCurrency, decimal.NewFromInt(10), "test", time.Now().Add(ttl))
+			if err != nil {
+				t.Fatal(err)
+			}
+			credits = append(credits, credit)
+		}
+		if _, err := buyer.Debit(c, enums.DefaultCurrency, decimal.RequireFromString(test.amount), "order"); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		for i, credit := range credits {
+			var stored BuyerPromoCredit
+			if err := db.GetDB(c).Where("id = ? AND buyer_id = ?", credit.ID, buyer.ID).First(&stored).Error; err != nil {
+				t.Fatal(err)
+			}
+			if !stored.Remaining.Equal(decimal.RequireFromString(test.wantRemaining[i])) {
+				t.Errorf("%s: grant %d has %s left, want %s", test.name, i, stored.Remaining, test.wantRemaining[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,373 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"time"
+	"user-service/db"
+	"user-service/enums"
+	"user-service/errs"
+	"user-service/i18n"
+)
+
+const (
+	reconciliationFreezeReason = "balance does not match the ledger"
+)
+
+var ErrWalletFrozen = errs.New(errs.ErrLocked, i18n.CodeWalletFrozen, "wallet is frozen pending review")
+
+// WalletDiscrepancy is a wallet whose stored balances disagree with the sum of
+// its ledger entries or, for held balance, with its authorized holds
+type WalletDiscrepancy struct {
+	Group      string          `json:"group"`
+	UserID     uuid.UUID       `json:"user_id"`
+	Currency   string          `json:"currency"`
+	Field      string          `json:"field"`
+	Stored     decimal.Decimal `json:"stored"`
+	Expected   decimal.Decimal `json:"expected"`
+	Difference decimal.Decimal `json:"difference"`
+	Frozen     bool            `json:"frozen"`
+}
+
+// BooksCheck compares, for one currency, the money held on the platform with
+// the money that came in and went out of it.
+//
+// StoredTotal adds up buyer cash balances, seller balances and the platform
+// ledger. Promo credit is left out, it is not money until a buyer spends it
+// and the platform funds it. ExpectedTotal is what external flows account for:
+// the opening balances wallets brought from before the ledger, plus succeeded
+// top-ups, minus payouts not failed, minus the cash of purchases not
+// paid to a seller net of their refunds. A payout leaves the books when it is
+// requested, from then on the money is owed to the payout provider. The books
+// balance when Difference is zero
+type BooksCheck struct {
+	Currency      string          `json:"currency"`
+	StoredTotal   decimal.Decimal `json:"stored_total"`
+	Openings      decimal.Decimal `json:"openings"`
+	Topups        decimal.Decimal `json:"topups"`
+	Payouts       decimal.Decimal `json:"payouts"`
+	Purchases     decimal.Decimal `json:"purchases"`
+	ExpectedTotal decimal.Decimal `json:"expected_total"`
+	Difference    decimal.Decimal `json:"difference"`
+	Balanced      bool            `json:"balanced"`
+}
+
+type ReconciliationReport struct {
+	StartedAt      time.Time           `json:"started_at"`
+	FinishedAt     time.Time           `json:"finished_at"`
+	WalletsChecked int64               `json:"wallets_checked"`
+	Discrepancies  []WalletDiscrepancy `json:"discrepancies"`
+	Books          []BooksCheck        `json:"books"`
+}
+
+// Balanced reports whether the run found nothing to look at
+func (r ReconciliationReport) Balanced() bool {
+	if len(r.Discrepancies) > 0 {
+		return false
+	}
+	for _, books := range r.Books {
+		if !books.Balanced {
+			return false
+		}
+	}
+	return true
+}
+
+type reconciliationRow struct {
+	UserID   uuid.UUID
+	Currency string
+	Stored   decimal.Decimal
+	Expected decimal.Decimal
+}
+
+// Each query compares a wallet with the aggregate of its own rows. Wallets and
+// their ledger share the distribution column so Citus runs the joins on the
+// workers shard by shard
+const (
+	buyerCashQuery = `
+SELECT w.buyer_id AS user_id, w.currency, w.balance AS stored, COALESCE(t.total, 0) AS expected
+FROM buyer_wallets w
+LEFT JOIN (
+	SELECT buyer_id, currency, SUM(amount - promo_amount) AS total
+	FROM buyer_wallet_transactions WHERE deleted_at IS NULL GROUP BY buyer_id, currency
+) t ON t.buyer_id = w.buyer_id AND t.currency = w.currency
+WHERE w.deleted_at IS NULL AND w.balance <> COALESCE(t.total, 0)`
+	buyerPromoQuery = `
+SELECT w.buyer_id AS user_id, w.currency, w.promo_balance AS stored, COALESCE(t.total, 0) AS expected
+FROM buyer_wallets w
+LEFT JOIN (
+	SELECT buyer_id, currency, SUM(promo_amount) AS total
+	FROM buyer_wallet_transactions WHERE deleted_at IS NULL GROUP BY buyer_id, currency
+) t ON t.buyer_id = w.buyer_id AND t.currency = w.currency
+WHERE w.deleted_at IS NULL AND w.promo_balance <> COALESCE(t.total, 0)`
+	buyerHeldQuery = `
+SELECT w.buyer_id AS user_id, w.currency, w.held_balance AS stored, COALESCE(h.total, 0) AS expected
+FROM buyer_wallets w
+LEFT JOIN (
+	SELECT buyer_id, currency, SUM(amount) AS total
+	FROM buyer_wallet_holds WHERE deleted_at IS NULL AND status = ? GROUP BY buyer_id, currency
+) h ON h.buyer_id = w.buyer_id AND h.currency = w.currency
+WHERE w.deleted_at IS NULL AND w.held_balance <> COALESCE(h.total, 0)`
+	sellerQuery = `
+SELECT w.seller_id AS user_id, w.currency, w.balance AS stored, COALESCE(t.total, 0) AS expected
+FROM seller_wallets w
+LEFT JOIN (
+	SELECT seller_id, currency, SUM(amount) AS total
+	FROM seller_wallet_transactions WHERE deleted_at IS NULL GROUP BY seller_id, currency
+) t ON t.seller_id = w.seller_id AND t.currency = w.currency
+WHERE w.deleted_at IS NULL AND w.balance <> COALESCE(t.total, 0)`
+	storedTotalsQuery = `
+SELECT currency, SUM(total) AS total FROM (
+	SELECT currency, SUM(balance) AS total FROM buyer_wallets WHERE deleted_at IS NULL GROUP BY currency
+	UNION ALL
+	SELECT currency, SUM(balance) AS total FROM seller_wallets WHERE deleted_at IS NULL GROUP BY currency
+	UNION ALL
+	SELECT currency, SUM(amount) AS total FROM platform_ledger_entries WHERE deleted_at IS NULL GROUP BY currency
+) totals GROUP BY currency`
+	openingTotalsQuery = `
+SELECT currency, SUM(total) AS total FROM (
+	SELECT currency, SUM(amount) AS total FROM buyer_wallet_transactions WHERE deleted_at IS NULL AND type = ? GROUP BY currency
+	UNION ALL
+	SELECT currency, SUM(amount) AS total FROM seller_wallet_transactions WHERE deleted_at IS NULL AND type = ? GROUP BY currency
+) totals GROUP BY currency`
+	topupTotalsQuery = `
+SELECT currency, SUM(amount) AS total FROM topup_intents
+WHERE deleted_at IS NULL AND status = ? GROUP BY currency`
+	payoutTotalsQuery = `
+SELECT currency, SUM(net_amount) AS total FROM payout_requests
+WHERE deleted_at IS NULL AND status <> ? GROUP BY currency`
+	// Purchases without a settlement paid nobody on the platform, their cash
+	// left the books. Refunds point at the purchase through the original
+	// transaction. Settlements share buyer_id with the ledger so the join runs
+	// on the workers
+	purchaseTotalsQuery = `
+SELECT t.currency, SUM(t.amount - t.promo_amount) AS total
+FROM buyer_wallet_transactions t
+LEFT JOIN settlements s ON s.buyer_id = t.buyer_id
+	AND s.buyer_transaction_id = COALESCE(t.original_transaction_id, t.id)
+	AND s.deleted_at IS NULL
+WHERE t.deleted_at IS NULL AND t.type IN ? AND s.id IS NULL
+GROUP BY t.currency`
+)
+
+// ReconcileWallets recomputes every wallet from its ledger and reports the
+// wallets that disagree, then checks the books balance per currency against
+// the money that came in and went out. With freeze set the disagreeing buyer
+// and seller wallets are frozen until an admin reviews them
+func ReconcileWallets(c context.Context, freeze bool) (*ReconciliationReport, error) {
+	report := ReconciliationReport{StartedAt: time.Now(), Discrepancies: []WalletDiscrepancy{}}
+	database := db.GetDB(c)
+
+	// Every query reads the same snapshot, money moving between a wallet and
+	// the ledger mid-run is not reported as a discrepancy
+	snapshot := &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+	err := database.Transaction(func(tx *gorm.DB) error {
+		return reconcileSnapshot(tx, &report)
+	}, snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	// Freezing writes, it runs after the read only snapshot is closed
+	if freeze {
+		for i := range report.Discrepancies {
+			discrepancy := &report.Discrepancies[i]
+			if err := freezeWallet(database, discrepancy.Group, discrepancy.UserID, discrepancy.Currency, reconciliationFreezeReason); err != nil {
+				return nil, err
+			}
+			discrepancy.Frozen = true
+		}
+	}
+	report.FinishedAt = time.Now()
+	return &report, nil
+}
+
+// reconcileSnapshot runs the reconciliation queries, tx is expected to hold
+// a single snapshot
+func reconcileSnapshot(tx *gorm.DB, report *ReconciliationReport) error {
+	var buyerWallets, sellerWallets int64
+	if err := tx.Model(&BuyerWallet{}).Where("deleted_at IS NULL").Count(&buyerWallets).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&SellerWallet{}).Where("deleted_at IS NULL").Count(&sellerWallets).Error; err != nil {
+		return err
+	}
+	report.WalletsChecked = buyerWallets + sellerWallets
+
+	checks := []struct {
+		group string
+		field string
+		query string
+		args  []interface{}
+	}{
+		{enums.Buyer, "balance", buyerCashQuery, nil},
+		{enums.Buyer, "promo_balance", buyerPromoQuery, nil},
+		{enums.Buyer, "held_balance", buyerHeldQuery, []interface{}{enums.HoldAuthorized}},
+		{enums.Seller, "balance", sellerQuery, nil},
+	}
+	for _, check := range checks {
+		var rows []reconciliationRow
+		if err := tx.Raw(check.query, check.args...).Scan(&rows).Error; err != nil {
+			return err
+		}
+		for _, row := range rows {
+			report.Discrepancies = append(report.Discrepancies, WalletDiscrepancy{
+				Group:      check.group,
+				UserID:     row.UserID,
+				Currency:   row.Currency,
+				Field:      check.field,
+				Stored:     row.Stored,
+				Expected:   row.Expected,
+				Difference: row.Stored.Sub(row.Expected),
+			})
+		}
+	}
+	books, err := checkBooks(tx)
+	if err != nil {
+		return err
+	}
+	report.Books = books
+	return nil
+}
+
+type currencyTotalRow struct {
+	Currency string
+	Total    decimal.Decimal
+}
+
+func checkBooks(database *gorm.DB) ([]BooksCheck, error) {
+	totals := []struct {
+		query string
+		args  []interface{}
+		set   func(check *BooksCheck, total decimal.Decimal)
+	}{
+		{storedTotalsQuery, nil, func(check *BooksCheck, total decimal.Decimal) { check.StoredTotal = total }},
+		{openingTotalsQuery, []interface{}{enums.TransactionOpeningBalance, enums.TransactionOpeningBalance}, func(check *BooksCheck, total decimal.Decimal) { check.Openings = total }},
+		{topupTotalsQuery, []interface{}{enums.TopupSucceeded}, func(check *BooksCheck, total decimal.Decimal) { check.Topups = total }},
+		{payoutTotalsQuery, []interface{}{enums.PayoutFailed}, func(check *BooksCheck, total decimal.Decimal) { check.Payouts = total }},
+		{purchaseTotalsQuery, []interface{}{[]string{enums.TransactionPurchase, enums.TransactionRefund}}, func(check *BooksCheck, total decimal.Decimal) { check.Purchases = total }},
+	}
+	byCurrency := map[string]*BooksCheck{}
+	var currencies []string
+	checkFor := func(currency string) *BooksCheck {
+		if check, ok := byCurrency[currency]; ok {
+			return check
+		}
+		byCurrency[currency] = &BooksCheck{Currency: currency}
+		currencies = append(currencies, currency)
+		return byCurrency[currency]
+	}
+	for _, total := range totals {
+		var rows []currencyTotalRow
+		if err := database.Raw(total.query, total.args...).Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			total.set(checkFor(row.Currency), row.Total)
+		}
+	}
+	books := make([]BooksCheck, 0, len(currencies))
+	for _, currency := range currencies {
+		books = append(books, byCurrency[currency].balance())
+	}
+	return books, nil
+}
+
+// balance works out the expected total and the difference from the totals
+func (b BooksCheck) balance() BooksCheck {
+	// Purchases are negative, they are debits net of refunds
+	b.ExpectedTotal = b.Openings.Add(b.Topups).Sub(b.Payouts).Add(b.Purchases)
+	b.Difference = b.StoredTotal.Sub(b.ExpectedTotal)
+	b.Balanced = b.Difference.IsZero()
+	return b
+}
+
+func freezeWallet(database *gorm.DB, group string, userID uuid.UUID, currency string, reason string) error {
+	updates := map[string]interface{}{"frozen": true, "frozen_reason": reason}
+	if group == enums.Seller {
+		return database.
+			Model(&SellerWallet{}).
+			Where("seller_id = ? AND currency = ?", userID, currency).
+			Updates(updates).Error
+	}
+	return database.
+		Model(&BuyerWallet{}).
+		Where("buyer_id = ? AND currency = ?", userID, currency).
+		Updates(updates).Error
+}
+
+// UnfreezeWallet lifts a freeze once an admin reviewed the wallet
+func UnfreezeWallet(c context.Context, group string, userID uuid.UUID, currency string) error {
+	updates := map[string]interface{}{"frozen": false, "frozen_reason": ""}
+	var result *gorm.DB
+	if group == enums.Seller {
+		result = db.GetDB(c).
+			Model(&SellerWallet{}).
+			Where("seller_id = ? AND currency = ?", userID, currency).
+			Updates(updates)
+	} else {
+		result = db.GetDB(c).
+			Model(&BuyerWallet{}).
+			Where("buyer_id = ? AND currency = ?", userID, currency).
+			Updates(updates)
+	}
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// FrozenWallet is a wallet waiting for review
+type FrozenWallet struct {
+	Group        string
+	UserID       uuid.UUID
+	Currency     string
+	Balance      decimal.Decimal
+	FrozenReason string
+	UpdatedAt    time.Time
+}
+
+func ListFrozenWallets(c context.Context) ([]FrozenWallet, error) {
+	var buyerWallets []BuyerWallet
+	if err := db.GetDB(c).
+		Where("frozen AND deleted_at IS NULL").
+		Order("updated_at").
+		Find(&buyerWallets).Error; err != nil {
+		return nil, err
+	}
+	var sellerWallets []SellerWallet
+	if err := db.GetDB(c).
+		Where("frozen AND deleted_at IS NULL").
+		Order("updated_at").
+		Find(&sellerWallets).Error; err != nil {
+		return nil, err
+	}
+	wallets := make([]FrozenWallet, 0, len(buyerWallets)+len(sellerWallets))
+	for _, wallet := range buyerWallets {
+		wallets = append(wallets, FrozenWallet{
+			Group:        enums.Buyer,
+			UserID:       wallet.BuyerID,
+			Currency:     wallet.Currency,
+			Balance:      wallet.Balance,
+			FrozenReason: wallet.FrozenReason,
+			UpdatedAt:    wallet.UpdatedAt,
+		})
+	}
+	for _, wallet := range sellerWallets {
+		wallets = append(wallets, FrozenWallet{
+			Group:        enums.Seller,
+			UserID:       wallet.SellerID,
+			Currency:     wallet.Currency,
+			Balance:      wallet.Balance,
+			FrozenReason: wallet.FrozenReason,
+			UpdatedAt:    wallet.UpdatedAt,
+		})
+	}
+	return wallets, nil
+}
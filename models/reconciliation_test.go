@@ -0,0 +1,101 @@
+package models
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"testing"
+	"time"
+	"user-service/enums"
+)
+
+func TestBooksCheckBalance(t *testing.T) {
+	tests := []struct {
+		name         string
+		stored       string
+		openings     string
+		topups       string
+		payouts      string
+		purchases    string
+		wantExpected string
+		wantBalanced bool
+	}{
+		{"nothing happened", "0", "0", "0", "0", "0", "0", true},
+		{"top-ups still held", "100.00", "0", "100.00", "0", "0", "100.00", true},
+		{"paid out", "40.00", "0", "100.00", "60.00", "0", "40.00", true},
+		{"spent off the platform", "70.00", "0", "100.00", "0", "-30.00", "70.00", true},
+		{"legacy balances", "150.00", "50.00", "100.00", "0", "0", "150.00", true},
+		{"legacy balances paid out", "30.00", "50.00", "0", "20.00", "0", "30.00", true},
+		{"every flow", "25.00", "0", "100.00", "45.00", "-30.00", "25.00", true},
+		{"money appeared", "100.01", "0", "100.00", "0", "0", "100.00", false},
+		{"money went missing", "99.99", "0", "100.00", "0", "0", "100.00", false},
+	}
+	for _, test := range tests {
+		check := BooksCheck{
+			StoredTotal: decimal.RequireFromString(test.stored),
+			Openings:    decimal.RequireFromString(test.openings),
+			Topups:      decimal.RequireFromString(test.topups),
+			Payouts:     decimal.RequireFromString(test.payouts),
+			Purchases:   decimal.RequireFromString(test.purchases),
+		}.balance()
+		if !check.ExpectedTotal.Equal(decimal.RequireFromString(test.wantExpected)) || check.Balanced != test.wantBalanced {
+			t.Errorf("%s: expected %s balanced %t, want %s %t", test.name, check.ExpectedTotal, check.Balanced, test.wantExpected, test.wantBalanced)
+		}
+		if !check.Difference.Equal(check.StoredTotal.Sub(check.ExpectedTotal)) {
+			t.Errorf("%s: difference %s", test.name, check.Difference)
+		}
+	}
+}
+
+// Wallets moved only through the ledger reconcile, a balance set behind its
+// back is reported
+func TestReconcileWalletsFindsDiscrepancies(t *testing.T) {
+	requireDB(t)
+	c := context.Background()
+	seller, _ := newTestSeller(t, "0")
+	tests := []struct {
+		name      string
+		setup     func() uuid.UUID
+		wantField string
+	}{
+		{"balance without a ledger entry", func() uuid.UUID {
+			return newTestBuyer(t, "100.00").ID
+		}, "balance"},
+		{"promo credit and a settlement", func() uuid.UUID {
+			buyer := newTestBuyer(t, "0")
+			if _, err := buyer.GrantPromoCredit(c, enums.DefaultCurrency, decimal.NewFromInt(50), "test", time.Now().Add(time.Hour)); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := buyer.Settle(c, enums.DefaultCurrency, seller.ID, decimal.NewFromInt(20), "", "order-"+uuid.New().String()); err != nil {
+				t.Fatal(err)
+			}
+			return buyer.ID
+		}, ""},
+		{"promo credit and an open hold", func() uuid.UUID {
+			buyer := newTestBuyer(t, "0")
+			if _, err := buyer.GrantPromoCredit(c, enums.DefaultCurrency, decimal.NewFromInt(50), "test", time.Now().Add(time.Hour)); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := buyer.AuthorizeHold(c, enums.DefaultCurrency, seller.ID, decimal.NewFromInt(20), "", "order", time.Hour); err != nil {
+				t.Fatal(err)
+			}
+			return buyer.ID
+		}, ""},
+	}
+	for _, test := range tests {
+		buyerID := test.setup()
+		report, err := ReconcileWallets(c, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		field := ""
+		for _, discrepancy := range report.Discrepancies {
+			if discrepancy.Group == enums.Buyer && discrepancy.UserID == buyerID {
+				field = discrepancy.Field
+			}
+		}
+		if field != test.wantField {
+			t.Errorf("%s: discrepancy on %q, want %q", test.name, field, test.wantField)
+		}
+	}
+}
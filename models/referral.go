@@ -0,0 +1,376 @@
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"log"
+	"math/big"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"user-service/db"
+	"user-service/enums"
+	"user-service/errs"
+	"user-service/i18n"
+)
+
+const (
+	referralCodeLength       = 8
+	referralCodeAlphabet     = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	referralCodeAttempts     = 5
+	referralCampaign         = "referral"
+	defaultReferralReward    = 50
+	defaultReferralMinimum   = 100
+	defaultReferralCap       = 20
+	defaultReferralRewardTTL = time.Hour * 24 * 90
+)
+
+var (
+	ErrInvalidReferralCode = errs.New(errs.ErrValidation, i18n.CodeInvalidReferralCode, "invalid referral code")
+	errReferralCodeTaken   = errors.New("could not generate a unique referral code")
+)
+
+// ReferralCode maps a code to the buyer or seller owning it. It is a Citus
+// reference table so codes are unique across every shard and both user groups
+type ReferralCode struct {
+	Code      string `gorm:"primaryKey;size:16"`
+	CreatedAt time.Time
+	UserID    uuid.UUID `gorm:"type:uuid;uniqueIndex:referral_code_owner"`
+	UserGroup string    `gorm:"uniqueIndex:referral_code_owner"`
+	Username  string
+	// Where the owner signed up from, compared with the referees
+	SignupIP string
+	DeviceID string
+}
+
+// Referral links a new account to the account whose code it signed up with
+type Referral struct {
+	gorm.Model
+	ReferrerID    uuid.UUID `gorm:"type:uuid;primaryKey"`
+	ReferrerGroup string
+	RefereeID     uuid.UUID `gorm:"type:uuid;index"`
+	RefereeGroup  string
+	Code          string
+	Status        string `gorm:"index"`
+	RejectReason  string
+	SignupIP      string
+	DeviceID      string
+	RewardedAt    *time.Time
+}
+
+// SignupSignal is what we know about the client creating an account
+type SignupSignal struct {
+	IP       string
+	DeviceID string
+}
+
+// similarTo reports whether two signups probably come from the same person:
+// the same device or the same IPv4 /24 or IPv6 /64 network
+func (s SignupSignal) similarTo(other SignupSignal) bool {
+	if s.DeviceID != "" && s.DeviceID == other.DeviceID {
+		return true
+	}
+	ip, otherIP := net.ParseIP(s.IP), net.ParseIP(other.IP)
+	if ip == nil || otherIP == nil {
+		return false
+	}
+	if ip.To4() != nil && otherIP.To4() != nil {
+		mask := net.CIDRMask(24, 32)
+		return ip.To4().Mask(mask).Equal(otherIP.To4().Mask(mask))
+	}
+	mask := net.CIDRMask(64, 128)
+	return ip.Mask(mask).Equal(otherIP.Mask(mask))
+}
+
+// ReferralPolicy holds the referral program settings
+type ReferralPolicy struct {
+	Reward      decimal.Decimal
+	MinPurchase decimal.Decimal
+	MaxPerUser  int
+	RewardTTL   time.Duration
+}
+
+func LoadReferralPolicy() ReferralPolicy {
+	policy := ReferralPolicy{
+		Reward:      decimalFromEnv("REFERRAL_REWARD_AMOUNT", decimal.NewFromInt(defaultReferralReward)),
+		MinPurchase: decimalFromEnv("REFERRAL_MIN_PURCHASE", decimal.NewFromInt(defaultReferralMinimum)),
+		MaxPerUser:  defaultReferralCap,
+		RewardTTL:   defaultReferralRewardTTL,
+	}
+	if maxPerUser, err := strconv.Atoi(os.Getenv("REFERRAL_MAX_PER_REFERRER")); err == nil && maxPerUser >= 0 {
+		policy.MaxPerUser = maxPerUser
+	}
+	if ttl, err := time.ParseDuration(os.Getenv("REFERRAL_REWARD_TTL")); err == nil && ttl > 0 {
+		policy.RewardTTL = ttl
+	}
+	return policy
+}
+
+func generateReferralCode() (string, error) {
+	code := make([]byte, referralCodeLength)
+	max := big.NewInt(int64(len(referralCodeAlphabet)))
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = referralCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+// createReferralCode reserves a fresh code for the user, retrying on the rare
+// collision
+func createReferralCode(tx *gorm.DB, userID uuid.UUID, group string, username string, signal SignupSignal) (string, error) {
+	for attempt := 0; attempt < referralCodeAttempts; attempt++ {
+		code, err := generateReferralCode()
+		if err != nil {
+			return "", err
+		}
+		result := tx.
+			Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&ReferralCode{
+				Code:      code,
+				UserID:    userID,
+				UserGroup: group,
+				Username:  username,
+				SignupIP:  signal.IP,
+				DeviceID:  signal.DeviceID,
+			})
+		if result.Error != nil {
+			return "", result.Error
+		}
+		if result.RowsAffected == 1 {
+			return code, nil
+		}
+	}
+	return "", errReferralCodeTaken
+}
+
+// ensureReferralCode returns the code of the user, creating one for accounts
+// opened before the referral program
+func ensureReferralCode(c context.Context, userID uuid.UUID, group string, username string) (string, error) {
+	existing := ReferralCode{}
+	result := db.GetDB(c).
+		Where("user_id = ? AND user_group = ?", userID, group).
+		Limit(1).
+		Find(&existing)
+	if result.Error != nil {
+		return "", result.Error
+	}
+	if result.RowsAffected == 1 {
+		return existing.Code, nil
+	}
+	return createReferralCode(db.GetDB(c), userID, group, username, SignupSignal{})
+}
+
+// applyReferral records that the new account signed up with code. Referrals
+// failing a fraud guard are kept as rejected so they can be reviewed, they
+// never pay out. The code row is locked so signups racing on the same code
+// are counted one after the other against the cap
+func applyReferral(tx *gorm.DB, code string, refereeID uuid.UUID, refereeGroup string, refereeUsername string, signal SignupSignal) error {
+	owner := ReferralCode{}
+	if err := tx.
+		Where("code = ?", strings.ToUpper(strings.TrimSpace(code))).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&owner).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidReferralCode
+		}
+		return err
+	}
+	referral := Referral{
+		ReferrerID:    owner.UserID,
+		ReferrerGroup: owner.UserGroup,
+		RefereeID:     refereeID,
+		RefereeGroup:  refereeGroup,
+		Code:          owner.Code,
+		Status:        enums.ReferralPending,
+		SignupIP:      signal.IP,
+		DeviceID:      signal.DeviceID,
+	}
+
+	ownerSignal := SignupSignal{IP: owner.SignupIP, DeviceID: owner.DeviceID}
+	var previous []Referral
+	if err := tx.
+		Where("referrer_id = ?", owner.UserID).
+		Find(&previous).Error; err != nil {
+		return err
+	}
+	counted := 0
+	similar := false
+	for _, p := range previous {
+		if p.Status != enums.ReferralRejected {
+			counted++
+		}
+		if signal.similarTo(SignupSignal{IP: p.SignupIP, DeviceID: p.DeviceID}) {
+			similar = true
+		}
+	}
+
+	switch {
+	case strings.EqualFold(owner.Username, refereeUsername):
+		referral.RejectReason = enums.ReferralSelfReferral
+	case signal.DeviceID != "" && signal.DeviceID == owner.DeviceID:
+		referral.RejectReason = enums.ReferralSelfReferral
+	case counted >= LoadReferralPolicy().MaxPerUser:
+		referral.RejectReason = enums.ReferralCapReached
+	case similar || signal.similarTo(ownerSignal):
+		referral.RejectReason = enums.ReferralSimilarSignup
+	}
+	if referral.RejectReason != "" {
+		referral.Status = enums.ReferralRejected
+	}
+	return tx.Create(&referral).Error
+}
+
+// RewardReferrals pays both sides of the pending referrals whose referee made
+// a qualifying purchase, a buyer referee by buying and a seller referee by
+// selling at least REFERRAL_MIN_PURCHASE
+func RewardReferrals(c context.Context) error {
+	policy := LoadReferralPolicy()
+	var pending []Referral
+	if err := db.GetDB(c).
+		Where("status = ?", enums.ReferralPending).
+		Order("id").
+		Find(&pending).Error; err != nil {
+		return err
+	}
+	rewarded := 0
+	for i := range pending {
+		qualified, err := pending[i].qualifies(c, policy)
+		if err != nil {
+			return err
+		}
+		if !qualified {
+			continue
+		}
+		if err := pending[i].reward(c, policy); err != nil {
+			return err
+		}
+		rewarded++
+	}
+	if rewarded > 0 {
+		log.Printf("rewarded %d referrals", rewarded)
+	}
+	return nil
+}
+
+func (r *Referral) qualifies(c context.Context, policy ReferralPolicy) (bool, error) {
+	var qualified bool
+	var tx *gorm.DB
+	if r.RefereeGroup == enums.Seller {
+		tx = db.GetDB(c).
+			Model(&SellerWalletTransaction{}).
+			Where("seller_id = ? AND type = ? AND amount >= ?", r.RefereeID, enums.TransactionSale, policy.MinPurchase)
+	} else {
+		tx = db.GetDB(c).
+			Model(&BuyerWalletTransaction{}).
+			Where("buyer_id = ? AND type = ? AND -amount >= ?", r.RefereeID, enums.TransactionPurchase, policy.MinPurchase)
+	}
+	if err := tx.Select("count(*) > 0").Find(&qualified).Error; err != nil {
+		return false, err
+	}
+	return qualified, nil
+}
+
+func (r *Referral) reference() string {
+	return fmt.Sprintf("referral:%d", r.ID)
+}
+
+// reward credits the referrer and the referee in one transaction. Buyers get
+// promo credit, sellers get cash funded by the platform wallet. Buyer wallets
+// are locked before seller wallets like everywhere else
+func (r *Referral) reward(c context.Context, policy ReferralPolicy) error {
+	return db.GetDB(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Where("id = ? AND referrer_id = ?", r.ID, r.ReferrerID).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(r).Error; err != nil {
+			return err
+		}
+		if r.Status != enums.ReferralPending {
+			return nil
+		}
+
+		type party struct {
+			id    uuid.UUID
+			group string
+		}
+		parties := []party{{r.ReferrerID, r.ReferrerGroup}, {r.RefereeID, r.RefereeGroup}}
+		first, second := parties[0], parties[1]
+		if first.group == enums.Seller && second.group == enums.Buyer ||
+			first.group == second.group && second.id.String() < first.id.String() {
+			first, second = second, first
+		}
+		for _, p := range []party{first, second} {
+			if err := creditReferralReward(tx, p.id, p.group, policy, r.reference()); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		r.Status = enums.ReferralRewarded
+		r.RewardedAt = &now
+		return tx.
+			Model(r).
+			Where("referrer_id = ?", r.ReferrerID).
+			Updates(map[string]interface{}{"status": r.Status, "rewarded_at": r.RewardedAt}).Error
+	})
+}
+
+func creditReferralReward(tx *gorm.DB, userID uuid.UUID, group string, policy ReferralPolicy, reference string) error {
+	if group == enums.Buyer {
+		wallet, err := lockBuyerWallet(tx, userID, enums.DefaultCurrency)
+		if err != nil {
+			return err
+		}
+		_, err = grantPromoCredit(tx, wallet, policy.Reward, referralCampaign, time.Now().Add(policy.RewardTTL))
+		return err
+	}
+
+	wallet, err := lockSellerWallet(tx, userID, enums.DefaultCurrency)
+	if err != nil {
+		return err
+	}
+	updatedBalance := wallet.Balance.Add(policy.Reward)
+	if err := tx.
+		Model(&wallet).
+		Where("seller_id = ?", userID).
+		Update("Balance", updatedBalance).Error; err != nil {
+		return err
+	}
+	if _, err := createSellerTransaction(tx, wallet, enums.TransactionReferralReward, policy.Reward, updatedBalance, reference); err != nil {
+		return err
+	}
+	return creditPlatformWallet(tx, enums.DefaultCurrency, enums.TransactionReferralReward, policy.Reward.Neg(), reference)
+}
+
+// ReferralStats counts the referrals of a referrer by status
+func ReferralStats(c context.Context, referrerID uuid.UUID) (map[string]int64, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	if err := db.GetDB(c).
+		Model(&Referral{}).
+		Select("status, count(*) AS count").
+		Where("referrer_id = ?", referrerID).
+		Group("status").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	stats := map[string]int64{}
+	for _, row := range rows {
+		stats[row.Status] = row.Count
+	}
+	return stats, nil
+}
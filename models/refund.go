@@ -0,0 +1,224 @@
+package models
+
+import (
+	"context"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"time"
+	"user-service/db"
+	"user-service/enums"
+	"user-service/errs"
+	"user-service/i18n"
+)
+
+var (
+	ErrNotRefundable        = errs.New(errs.ErrValidation, i18n.CodeNotRefundable, "only purchase transactions can be refunded")
+	ErrRefundExceedsCharged = errs.New(errs.ErrValidation, i18n.CodeRefundExceedsCharged, "refund exceeds the amount charged")
+)
+
+// Refund is the outcome of reversing all or part of a purchase. Amount and
+// Commission are in the buyer currency, SellerAmount in the seller currency
+type Refund struct {
+	Amount decimal.Decimal
+	// PromoAmount is the part of Amount returned as promo credit
+	PromoAmount       decimal.Decimal
+	SellerAmount      decimal.Decimal
+	SellerCurrency    string
+	Commission        decimal.Decimal
+	BuyerTransaction  *BuyerWalletTransaction
+	SellerTransaction *SellerWalletTransaction
+}
+
+// Refund returns amount of the purchase transaction to the buyer, a zero amount
+// refunds whatever is left. When the purchase was settled to a seller the seller
+// share and the platform commission are reversed proportionally, at the
+// exchange rate of the settlement. The seller balance may go negative if the
+// money was already paid out.
+//
+// What the buyer paid in cash comes back as cash first, the part paid with
+// promo credit comes back as promo credit valid for PROMO_REFUND_TTL.
+func (u *Buyer) Refund(c context.Context, transactionID uint, amount decimal.Decimal, reference string) (*Refund, error) {
+	if !amount.IsZero() {
+		if err := ValidateAmount(amount); err != nil {
+			return nil, err
+		}
+	}
+	refund := Refund{}
+	err := db.GetDB(c).Transaction(func(tx *gorm.DB) error {
+		original := BuyerWalletTransaction{}
+		if err := tx.
+			Where("id = ? AND buyer_id = ?", transactionID, u.ID).
+			First(&original).Error; err != nil {
+			return err
+		}
+		if original.Type != enums.TransactionPurchase {
+			return ErrNotRefundable
+		}
+		// Every refund of this buyer wallet is serialised by the wallet lock
+		buyerWallet, err := lockBuyerWallet(tx, u.ID, original.Currency)
+		if err != nil {
+			return err
+		}
+
+		charged := original.Amount.Neg()
+		refunded, err := sumRefunds(tx.Model(&BuyerWalletTransaction{}).Where("buyer_id = ?", u.ID), original.ID)
+		if err != nil {
+			return err
+		}
+		remaining := charged.Sub(refunded)
+		if amount.IsZero() {
+			amount = remaining
+		}
+		if !amount.IsPositive() || amount.GreaterThan(remaining) {
+			return ErrRefundExceedsCharged
+		}
+		refund.Amount = amount
+
+		promoRefunded, err := sumRefundColumn(tx.Model(&BuyerWalletTransaction{}).Where("buyer_id = ?", u.ID), "promo_amount", original.ID)
+		if err != nil {
+			return err
+		}
+		cashRemaining := charged.Sub(original.PromoAmount.Neg()).Sub(refunded.Sub(promoRefunded))
+		refund.PromoAmount = amount.Sub(decimal.Min(amount, cashRemaining))
+
+		buyerBalance := buyerWallet.Balance.Add(amount.Sub(refund.PromoAmount))
+		if err := tx.
+			Model(&buyerWallet).
+			Where("buyer_id = ?", u.ID).
+			Updates(map[string]interface{}{
+				"balance":       buyerBalance,
+				"promo_balance": buyerWallet.PromoBalance.Add(refund.PromoAmount),
+			}).Error; err != nil {
+			return err
+		}
+		if refund.PromoAmount.IsPositive() {
+			if _, err := createPromoCredit(tx, buyerWallet, refund.PromoAmount, promoRefundCampaign, time.Now().Add(promoRefundTTL())); err != nil {
+				return err
+			}
+		}
+		refund.BuyerTransaction = &BuyerWalletTransaction{
+			BuyerID:               u.ID,
+			Currency:              buyerWallet.Currency,
+			Type:                  enums.TransactionRefund,
+			Amount:                amount,
+			PromoAmount:           refund.PromoAmount,
+			BalanceAfter:          buyerBalance,
+			Reference:             reference,
+			OriginalTransactionID: &original.ID,
+		}
+		if err := tx.Create(refund.BuyerTransaction).Error; err != nil {
+			return err
+		}
+
+		settlement := Settlement{}
+		result := tx.
+			Where("buyer_id = ? AND buyer_transaction_id = ?", u.ID, original.ID).
+			Limit(1).
+			Find(&settlement)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			// A plain debit, nothing was paid to a seller
+			return nil
+		}
+		return refund.reverseSettlement(tx, settlement, amount.Equal(remaining), reference)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+// reverseSettlement takes the seller share and the commission of the refunded
+// amount back. The last refund takes whatever is left so rounding never leaves
+// a residue
+func (r *Refund) reverseSettlement(tx *gorm.DB, settlement Settlement, isFinal bool, reference string) error {
+	sellerWallet, err := lockSellerWallet(tx, settlement.SellerID, settlement.SellerCurrency)
+	if err != nil {
+		return err
+	}
+	sellerRefunded, err := sumRefunds(
+		tx.Model(&SellerWalletTransaction{}).Where("seller_id = ?", settlement.SellerID),
+		settlement.SellerTransactionID,
+	)
+	if err != nil {
+		return err
+	}
+	r.SellerCurrency = sellerWallet.Currency
+	if isFinal && settlement.SellerCurrency == settlement.Currency {
+		// sellerRefunded is negative, it was taken from the seller
+		r.SellerAmount = settlement.SellerAmount.Add(sellerRefunded)
+		r.Commission = r.Amount.Sub(r.SellerAmount)
+	} else if isFinal {
+		r.SellerAmount = settlement.SellerCreditAmount.Add(sellerRefunded)
+		r.Commission = settlement.Commission.Sub(settlement.RefundedCommission)
+	} else {
+		sellerShare := r.Amount.Mul(settlement.SellerAmount).Div(settlement.Amount).Round(2)
+		r.SellerAmount = sellerShare.Mul(settlement.ExchangeRate).Round(2)
+		r.Commission = r.Amount.Sub(sellerShare)
+	}
+
+	sellerBalance := sellerWallet.Balance.Sub(r.SellerAmount)
+	if err := tx.
+		Model(&sellerWallet).
+		Where("seller_id = ?", settlement.SellerID).
+		Update("Balance", sellerBalance).Error; err != nil {
+		return err
+	}
+	r.SellerTransaction = &SellerWalletTransaction{
+		SellerID:              settlement.SellerID,
+		Currency:              sellerWallet.Currency,
+		Type:                  enums.TransactionRefund,
+		Amount:                r.SellerAmount.Neg(),
+		BalanceAfter:          sellerBalance,
+		Reference:             reference,
+		OriginalTransactionID: &settlement.SellerTransactionID,
+	}
+	if err := tx.Create(r.SellerTransaction).Error; err != nil {
+		return err
+	}
+
+	if settlement.SellerCurrency != settlement.Currency {
+		// The exchange is reversed at the settlement rate
+		if err := bookExchange(tx, settlement.SellerCurrency, r.SellerAmount, settlement.Currency, r.Amount.Sub(r.Commission), reference); err != nil {
+			return err
+		}
+	}
+	if r.PromoAmount.IsPositive() {
+		// The buyer has the promo credit back, the platform stops funding it
+		if err := creditPlatformWallet(tx, settlement.Currency, enums.TransactionRefund, r.PromoAmount, reference); err != nil {
+			return err
+		}
+	}
+	if r.Commission.IsZero() {
+		return nil
+	}
+	if err := tx.
+		Model(&settlement).
+		Where("buyer_id = ?", settlement.BuyerID).
+		Update("RefundedCommission", settlement.RefundedCommission.Add(r.Commission)).Error; err != nil {
+		return err
+	}
+	return creditPlatformWallet(tx, settlement.Currency, enums.TransactionRefund, r.Commission.Neg(), reference)
+}
+
+// sumRefunds adds up the refund entries already posted against a transaction
+func sumRefunds(tx *gorm.DB, originalID uint) (decimal.Decimal, error) {
+	return sumRefundColumn(tx, "amount", originalID)
+}
+
+func sumRefundColumn(tx *gorm.DB, column string, originalID uint) (decimal.Decimal, error) {
+	var total decimal.NullDecimal
+	if err := tx.
+		Select("SUM("+column+")").
+		Where("type = ? AND original_transaction_id = ?", enums.TransactionRefund, originalID).
+		Row().
+		Scan(&total); err != nil {
+		return decimal.Zero, err
+	}
+	if !total.Valid {
+		return decimal.Zero, nil
+	}
+	return total.Decimal, nil
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"testing"
+	"time"
+	"user-service/enums"
+)
+
+// Refunding a settled purchase in full, at once or in parts, puts the buyer,
+// the seller and the platform back where they were without a rounding residue
+func TestRefundReversesSettlement(t *testing.T) {
+	requireDB(t)
+	tests := []struct {
+		name    string
+		cash    string
+		promo   string
+		refunds []string
+	}{
+		{"at once", "100.00", "0", []string{"0"}},
+		{"part then the rest", "100.00", "0", []string{"33.33", "0"}},
+		{"in thirds", "100.00", "0", []string{"33.33", "33.33", "33.34"}},
+		{"paid partly with promo credit", "50.00", "50.00", []string{"30.00", "0"}},
+	}
+	c := context.Background()
+	for _, test := range tests {
+		buyer := newTestBuyer(t, test.cash)
+		seller, _ := newTestSeller(t, "0")
+		setTestCommissionRate(t, seller.ID, "0.1")
+		if promo := decimal.RequireFromString(test.promo); promo.IsPositive() {
+			if _, err := buyer.GrantPromoCredit(c, enums.DefaultCurrency, promo, "test", time.Now().Add(time.Hour)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		references := []string{"order-" + uuid.New().String()}
+		settlement, err := buyer.Settle(c, enums.DefaultCurrency, seller.ID, decimal.NewFromInt(100), "", references[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, amount := range test.refunds {
+			reference := "refund-" + uuid.New().String()
+			references = append(references, reference)
+			if _, err := buyer.Refund(c, settlement.BuyerTransactionID, decimal.RequireFromString(amount), reference); err != nil {
+				t.Fatalf("%s: refund of %s: %v", test.name, amount, err)
+			}
+		}
+
+		wallet := testBuyerWallet(t, buyer.ID)
+		if !wallet.Balance.Equal(decimal.RequireFromString(test.cash)) || !wallet.PromoBalance.Equal(decimal.RequireFromString(test.promo)) {
+			t.Errorf("%s: buyer has cash %s promo %s, want %s %s", test.name, wallet.Balance, wallet.PromoBalance, test.cash, test.promo)
+		}
+		if balance := testSellerWallet(t, seller.ID).Balance; !balance.IsZero() {
+			t.Errorf("%s: seller kept %s", test.name, balance)
+		}
+		if total := platformTotal(t, references...); !total.IsZero() {
+			t.Errorf("%s: platform kept %s", test.name, total)
+		}
+	}
+}
+
+func TestRefundRejects(t *testing.T) {
+	requireDB(t)
+	c := context.Background()
+	buyer := newTestBuyer(t, "100.00")
+	purchase, err := buyer.Debit(c, enums.DefaultCurrency, decimal.NewFromInt(60), "order")
+	if err != nil {
+		t.Fatal(err)
+	}
+	refund, err := buyer.Refund(c, purchase.ID, decimal.NewFromInt(50), "refund")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := newTestBuyer(t, "0")
+
+	tests := []struct {
+		name          string
+		buyer         *Buyer
+		transactionID uint
+		amount        string
+		want          error
+	}{
+		{"more than is left", buyer, purchase.ID, "10.01", ErrRefundExceedsCharged},
+		{"a refund", buyer, refund.BuyerTransaction.ID, "1.00", ErrNotRefundable},
+		{"another buyer's purchase", other, purchase.ID, "1.00", gorm.ErrRecordNotFound},
+	}
+	for _, test := range tests {
+		if _, err := test.buyer.Refund(c, test.transactionID, decimal.RequireFromString(test.amount), "refund"); !errors.Is(err, test.want) {
+			t.Errorf("%s: Refund = %v, want %v", test.name, err, test.want)
+		}
+	}
+}
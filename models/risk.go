@@ -0,0 +1,376 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"user-service/db"
+	"user-service/enums"
+)
+
+const (
+	defaultRiskTopupsPerHour      = 5
+	defaultRiskSpikeMultiplier    = 5
+	defaultRiskSpikeMinHistory    = 3
+	defaultRiskSpikeMinAmount     = 1000
+	defaultRiskSpikeWindow        = time.Hour * 24 * 30
+	defaultRiskLargeDebit         = 5000
+	defaultRiskDeviceTrustedAfter = time.Hour * 72
+)
+
+// RiskRequest describes a wallet operation about to happen
+type RiskRequest struct {
+	Group     string
+	UserID    uuid.UUID
+	Operation string
+	Currency  string
+	Amount    decimal.Decimal
+	// DeviceID comes from the X-Device-ID header, empty when the client did
+	// not send one
+	DeviceID string
+	IP       string
+	// MFAVerified is set when the user passed a second factor for this
+	// request, it turns a challenge into an allow
+	MFAVerified bool
+}
+
+// RiskDecision is the log entry of one evaluation, kept for review
+type RiskDecision struct {
+	gorm.Model
+	UserID      uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserGroup   string
+	Operation   string
+	Currency    string          `gorm:"size:3"`
+	Amount      decimal.Decimal `gorm:"type:decimal(12,2);"`
+	DeviceID    string
+	IP          string
+	MFAVerified bool
+	Decision    string `gorm:"index"`
+	// Rules lists the triggered rules separated by commas, Reason their
+	// explanations separated by "; "
+	Rules  string
+	Reason string
+}
+
+// TriggeredRules splits Rules
+func (d RiskDecision) TriggeredRules() []string {
+	if d.Rules == "" {
+		return []string{}
+	}
+	return strings.Split(d.Rules, ",")
+}
+
+// RiskRejection is returned when the decision is not allow
+type RiskRejection struct {
+	Decision RiskDecision
+}
+
+func (e *RiskRejection) Error() string {
+	if e.Decision.Decision == enums.RiskChallenge {
+		return "additional verification required: " + e.Decision.Reason
+	}
+	return "operation blocked: " + e.Decision.Reason
+}
+
+// KnownDevice remembers the devices a user operated their wallet from. A
+// device becomes trusted once it has been seen for a while or passed MFA
+type KnownDevice struct {
+	gorm.Model
+	UserID      uuid.UUID `gorm:"type:uuid;primaryKey;uniqueIndex:known_device_user"`
+	DeviceID    string    `gorm:"uniqueIndex:known_device_user"`
+	UserGroup   string
+	FirstSeenAt time.Time
+	Trusted     bool `gorm:"default:false;not null"`
+}
+
+// RiskRule inspects one operation. Check returns why the operation looks
+// risky, or an empty string when the rule is not triggered
+type RiskRule interface {
+	Name() string
+	Action() string
+	Check(c context.Context, request RiskRequest) (string, error)
+}
+
+// LoadRiskRules builds the rules from the environment. Every rule has an
+// action, setting it to allow keeps logging what it would have caught
+func LoadRiskRules() []RiskRule {
+	return []RiskRule{
+		topupVelocityRule{
+			action:     riskActionFromEnv("RISK_TOPUP_VELOCITY_ACTION", enums.RiskBlock),
+			maxPerHour: intFromEnv("RISK_TOPUPS_PER_HOUR", defaultRiskTopupsPerHour),
+		},
+		amountSpikeRule{
+			action:     riskActionFromEnv("RISK_AMOUNT_SPIKE_ACTION", enums.RiskChallenge),
+			multiplier: decimalFromEnv("RISK_SPIKE_MULTIPLIER", decimal.NewFromInt(defaultRiskSpikeMultiplier)),
+			minHistory: intFromEnv("RISK_SPIKE_MIN_HISTORY", defaultRiskSpikeMinHistory),
+			minAmount:  decimalFromEnv("RISK_SPIKE_MIN_AMOUNT", decimal.NewFromInt(defaultRiskSpikeMinAmount)),
+			window:     durationFromEnv("RISK_SPIKE_WINDOW", defaultRiskSpikeWindow),
+		},
+		newDeviceDebitRule{
+			action:       riskActionFromEnv("RISK_NEW_DEVICE_ACTION", enums.RiskChallenge),
+			largeDebit:   decimalFromEnv("RISK_LARGE_DEBIT", decimal.NewFromInt(defaultRiskLargeDebit)),
+			trustedAfter: durationFromEnv("RISK_DEVICE_TRUSTED_AFTER", defaultRiskDeviceTrustedAfter),
+		},
+	}
+}
+
+// EvaluateRisk runs every rule against the operation and logs the decision.
+// The most severe action of the triggered rules wins, a challenge is let
+// through when the request passed MFA. Anything but allow comes back as a
+// *RiskRejection
+func EvaluateRisk(c context.Context, request RiskRequest) (*RiskDecision, error) {
+	decision := RiskDecision{
+		UserID:      request.UserID,
+		UserGroup:   request.Group,
+		Operation:   request.Operation,
+		Currency:    request.Currency,
+		Amount:      request.Amount,
+		DeviceID:    request.DeviceID,
+		IP:          request.IP,
+		MFAVerified: request.MFAVerified,
+		Decision:    enums.RiskAllow,
+	}
+	var rules []string
+	var reasons []string
+	for _, rule := range LoadRiskRules() {
+		reason, err := rule.Check(c, request)
+		if err != nil {
+			return nil, err
+		}
+		if reason == "" {
+			continue
+		}
+		rules = append(rules, rule.Name())
+		reasons = append(reasons, reason)
+		decision.Decision = enums.MoreSevereRisk(decision.Decision, rule.Action())
+	}
+	if decision.Decision == enums.RiskChallenge && request.MFAVerified {
+		decision.Decision = enums.RiskAllow
+	}
+	decision.Rules = strings.Join(rules, ",")
+	decision.Reason = strings.Join(reasons, "; ")
+
+	if err := db.GetDB(c).Create(&decision).Error; err != nil {
+		return nil, err
+	}
+	if decision.Decision != enums.RiskAllow {
+		log.Printf(
+			"risk %s %s of %s %s by %s %s: %s",
+			decision.Decision, request.Operation, request.Amount.StringFixed(2), request.Currency,
+			request.Group, request.UserID, decision.Reason,
+		)
+		if decision.Decision == enums.RiskBlock {
+			notifyRiskBlocked(c, request)
+		}
+		return &decision, &RiskRejection{Decision: decision}
+	}
+	// Only a device that got through is remembered. Remembering a challenged
+	// one would start its trust clock for whoever holds a stolen token
+	if err := rememberDevice(c, request); err != nil {
+		return nil, err
+	}
+	return &decision, nil
+}
+
+// rememberDevice records the first time a device is seen, passing MFA on it
+// makes it trusted straight away
+func rememberDevice(c context.Context, request RiskRequest) error {
+	if request.DeviceID == "" {
+		return nil
+	}
+	device := KnownDevice{
+		UserID:      request.UserID,
+		DeviceID:    request.DeviceID,
+		UserGroup:   request.Group,
+		FirstSeenAt: time.Now(),
+		Trusted:     request.MFAVerified,
+	}
+	onConflict := clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "device_id"}},
+		DoNothing: true,
+	}
+	if request.MFAVerified {
+		onConflict = clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "device_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"trusted": true}),
+		}
+	}
+	return db.GetDB(c).Clauses(onConflict).Create(&device).Error
+}
+
+// ListRiskDecisions returns the latest decisions with the given outcome, every
+// outcome when decision is empty
+func ListRiskDecisions(c context.Context, decision string, userID uuid.UUID, limit int) ([]RiskDecision, error) {
+	tx := db.GetDB(c)
+	if decision != "" {
+		tx = tx.Where("decision = ?", decision)
+	}
+	if userID != uuid.Nil {
+		tx = tx.Where("user_id = ?", userID)
+	}
+	var decisions []RiskDecision
+	if err := tx.
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&decisions).Error; err != nil {
+		return nil, err
+	}
+	return decisions, nil
+}
+
+// topupVelocityRule catches scripted top-ups, counting every top-up started
+// in the last hour whether or not it was paid
+type topupVelocityRule struct {
+	action     string
+	maxPerHour int
+}
+
+func (r topupVelocityRule) Name() string   { return enums.RiskRuleTopupVelocity }
+func (r topupVelocityRule) Action() string { return r.action }
+
+func (r topupVelocityRule) Check(c context.Context, request RiskRequest) (string, error) {
+	if request.Operation != enums.RiskOperationTopup {
+		return "", nil
+	}
+	var count int64
+	if err := db.GetDB(c).
+		Model(&TopupIntent{}).
+		Where("buyer_id = ? AND created_at >= ?", request.UserID, time.Now().Add(-time.Hour)).
+		Count(&count).Error; err != nil {
+		return "", err
+	}
+	if count < int64(r.maxPerHour) {
+		return "", nil
+	}
+	return fmt.Sprintf("%d top-ups in the last hour", count), nil
+}
+
+// amountSpikeRule compares the amount with the average of the same kind of
+// operation over the window. Users without enough history and small amounts
+// are left alone
+type amountSpikeRule struct {
+	action     string
+	multiplier decimal.Decimal
+	minHistory int
+	minAmount  decimal.Decimal
+	window     time.Duration
+}
+
+func (r amountSpikeRule) Name() string   { return enums.RiskRuleAmountSpike }
+func (r amountSpikeRule) Action() string { return r.action }
+
+func (r amountSpikeRule) Check(c context.Context, request RiskRequest) (string, error) {
+	rate, err := defaultCurrencyRate(c, request.Currency)
+	if err != nil {
+		return "", err
+	}
+	if request.Amount.LessThan(r.minAmount.Mul(rate)) {
+		return "", nil
+	}
+
+	var history struct {
+		Count   int64
+		Average decimal.Decimal
+	}
+	since := time.Now().Add(-r.window)
+	var tx *gorm.DB
+	switch request.Operation {
+	case enums.RiskOperationTopup:
+		tx = db.GetDB(c).Model(&BuyerWalletTransaction{}).
+			Where("buyer_id = ? AND type = ?", request.UserID, enums.TransactionTopup)
+	case enums.RiskOperationPayout:
+		tx = db.GetDB(c).Model(&SellerWalletTransaction{}).
+			Where("seller_id = ? AND type = ?", request.UserID, enums.TransactionPayout)
+	default:
+		tx = db.GetDB(c).Model(&BuyerWalletTransaction{}).
+			Where("buyer_id = ? AND type = ?", request.UserID, enums.TransactionPurchase)
+	}
+	if err := tx.
+		Select("COUNT(*) AS count, COALESCE(AVG(ABS(amount)), 0) AS average").
+		Where("currency = ? AND created_at >= ?", request.Currency, since).
+		Scan(&history).Error; err != nil {
+		return "", err
+	}
+	if history.Count < int64(r.minHistory) {
+		return "", nil
+	}
+	limit := history.Average.Mul(r.multiplier)
+	if !request.Amount.GreaterThan(limit) {
+		return "", nil
+	}
+	return fmt.Sprintf(
+		"amount %s is more than %s times the average of %s",
+		request.Amount.StringFixed(2), r.multiplier.String(), history.Average.StringFixed(2),
+	), nil
+}
+
+// newDeviceDebitRule catches large debits from a device the user has not
+// been using for long, a missing device ID counts as a new device
+type newDeviceDebitRule struct {
+	action       string
+	largeDebit   decimal.Decimal
+	trustedAfter time.Duration
+}
+
+func (r newDeviceDebitRule) Name() string   { return enums.RiskRuleNewDeviceDebit }
+func (r newDeviceDebitRule) Action() string { return r.action }
+
+func (r newDeviceDebitRule) Check(c context.Context, request RiskRequest) (string, error) {
+	if request.Operation == enums.RiskOperationTopup {
+		return "", nil
+	}
+	rate, err := defaultCurrencyRate(c, request.Currency)
+	if err != nil {
+		return "", err
+	}
+	if request.Amount.LessThan(r.largeDebit.Mul(rate)) {
+		return "", nil
+	}
+	if request.DeviceID == "" {
+		return "large debit without a device ID", nil
+	}
+	var device KnownDevice
+	err = db.GetDB(c).
+		Where("user_id = ? AND device_id = ?", request.UserID, request.DeviceID).
+		First(&device).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+	if err == nil && (device.Trusted || device.FirstSeenAt.Before(time.Now().Add(-r.trustedAfter))) {
+		return "", nil
+	}
+	return "large debit from a new device", nil
+}
+
+func riskActionFromEnv(key string, fallback string) string {
+	action := os.Getenv(key)
+	if !enums.IsValidRiskAction(action) {
+		return fallback
+	}
+	return action
+}
+
+func intFromEnv(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value < 0 {
+		return fallback
+	}
+	return value
+}
+
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"testing"
+	"user-service/db"
+	"user-service/enums"
+)
+
+func TestChallengedPayoutPassesAfterMFA(t *testing.T) {
+	requireDB(t)
+	c := context.Background()
+	seller, destination := newTestSeller(t, "10000.00")
+	amount := decimal.NewFromInt(defaultRiskLargeDebit)
+	request := RiskRequest{
+		Group:     enums.Seller,
+		UserID:    seller.ID,
+		Operation: enums.RiskOperationPayout,
+		Currency:  enums.DefaultCurrency,
+		Amount:    amount,
+		DeviceID:  "device-" + uuid.New().String(),
+	}
+
+	_, err := EvaluateRisk(c, request)
+	var rejection *RiskRejection
+	if !errors.As(err, &rejection) || rejection.Decision.Decision != enums.RiskChallenge {
+		t.Fatalf("large payout from a new device: got %v, want a challenge", err)
+	}
+
+	request.MFAVerified = true
+	decision, err := EvaluateRisk(c, request)
+	if err != nil {
+		t.Fatalf("payout after MFA: %v", err)
+	}
+	if decision.Decision != enums.RiskAllow {
+		t.Fatalf("payout after MFA: got %s, want allow", decision.Decision)
+	}
+	if _, err := seller.RequestPayout(c, enums.DefaultCurrency, destination.ID, amount); err != nil {
+		t.Fatalf("request payout: %v", err)
+	}
+
+	// Passing MFA trusted the device, the next payout from it goes through
+	request.MFAVerified = false
+	if _, err := EvaluateRisk(c, request); err != nil {
+		t.Fatalf("payout from the trusted device: %v", err)
+	}
+}
+
+// A challenged device is not remembered, it would become trusted after
+// RISK_DEVICE_TRUSTED_AFTER without ever passing the second factor
+func TestChallengedDeviceIsNotRemembered(t *testing.T) {
+	requireDB(t)
+	c := context.Background()
+	seller, _ := newTestSeller(t, "0")
+	request := RiskRequest{
+		Group:     enums.Seller,
+		UserID:    seller.ID,
+		Operation: enums.RiskOperationPayout,
+		Currency:  enums.DefaultCurrency,
+		Amount:    decimal.NewFromInt(defaultRiskLargeDebit),
+		DeviceID:  "device-" + uuid.New().String(),
+	}
+	tests := []struct {
+		name        string
+		mfaVerified bool
+		want        int64
+	}{
+		{"challenged", false, 0},
+		{"passed the second factor", true, 1},
+	}
+	for _, test := range tests {
+		request.MFAVerified = test.mfaVerified
+		_, _ = EvaluateRisk(c, request)
+		var devices int64
+		if err := db.GetDB(c).
+			Model(&KnownDevice{}).
+			Where("user_id = ? AND device_id = ?", seller.ID, request.DeviceID).
+			Count(&devices).Error; err != nil {
+			t.Fatal(err)
+		}
+		if devices != test.want {
+			t.Errorf("%s: %d devices remembered, want %d", test.name, devices, test.want)
+		}
+	}
+}
+
+func TestLargeDebitWithoutDeviceIsChallenged(t *testing.T) {
+	requireDB(t)
+	seller, _ := newTestSeller(t, "0")
+	_, err := EvaluateRisk(context.Background(), RiskRequest{
+		Group:     enums.Seller,
+		UserID:    seller.ID,
+		Operation: enums.RiskOperationPayout,
+		Currency:  enums.DefaultCurrency,
+		Amount:    decimal.NewFromInt(defaultRiskLargeDebit),
+	})
+	var rejection *RiskRejection
+	if !errors.As(err, &rejection) || rejection.Decision.Decision != enums.RiskChallenge {
+		t.Fatalf("got %v, want a challenge", err)
+	}
+}
@@ -0,0 +1,181 @@
+package models
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"github.com/google/uuid"
+	"strconv"
+	"strings"
+	"time"
+	"user-service/db"
+	"user-service/enums"
+	"user-service/errs"
+	"user-service/forms"
+	"user-service/i18n"
+)
+
+const (
+	defaultDirectoryPageSize = 20
+	maxDirectoryPageSize     = 100
+	// searchQuery turns the search box into a tsquery, quotes, OR and -word
+	// work the way people expect from web search
+	searchQuery = "websearch_to_tsquery('simple', ?)"
+)
+
+var ErrSearchTermRequired = errs.New(errs.ErrValidation, i18n.CodeSearchTermRequired, "sorting by relevance needs a search term")
+
+// SellerDirectoryEntry is one seller of a directory page
+type SellerDirectoryEntry struct {
+	ID          uuid.UUID
+	JoinedAt    time.Time
+	FirstName   string
+	LastName    string
+	DisplayName string
+	Bio         string
+	Slug        string
+	Logo        string
+	Country     string
+	Verified    bool
+	// Rank is the search relevance, zero when there is no search term
+	Rank float32
+}
+
+// StorefrontName is what buyers see the seller as
+func (e SellerDirectoryEntry) StorefrontName() string {
+	return SellerProfile{FirstName: e.FirstName, LastName: e.LastName, DisplayName: e.DisplayName}.StorefrontName()
+}
+
+// ListSellerDirectory returns a page of sellers and the cursor of the next
+// page, empty on the last one. Sellers and their profiles are co-located on
+// seller ID under Citus, so the join and the ordered limit run on every
+// shard and are merged by the coordinator
+func ListSellerDirectory(c context.Context, query forms.SellerDirectoryQuery) ([]SellerDirectoryEntry, string, error) {
+	search := strings.TrimSpace(query.Q)
+	sort := query.Sort
+	if sort == "" {
+		sort = enums.SellerSortNewest
+		if search != "" {
+			sort = enums.SellerSortRelevance
+		}
+	}
+	if sort == enums.SellerSortRelevance && search == "" {
+		return nil, "", ErrSearchTermRequired
+	}
+
+	tx := db.GetDB(c).
+		Table("sellers").
+		Joins("JOIN seller_profiles ON seller_profiles.seller_id = sellers.id AND seller_profiles.deleted_at IS NULL").
+		Where("sellers.deleted_at IS NULL")
+	columns := "sellers.id, sellers.created_at AS joined_at, seller_profiles.first_name, seller_profiles.last_name, " +
+		"seller_profiles.display_name, seller_profiles.bio, seller_profiles.slug, seller_profiles.logo, " +
+		"seller_profiles.country, seller_profiles.verified"
+	rank := "ts_rank(seller_profiles.search_vector, " + searchQuery + ")"
+	if search != "" {
+		tx = tx.
+			Select(columns+", "+rank+" AS rank", search).
+			Where("seller_profiles.search_vector @@ "+searchQuery, search)
+	} else {
+		tx = tx.Select(columns)
+	}
+	if query.Verified != nil {
+		tx = tx.Where("seller_profiles.verified = ?", *query.Verified)
+	}
+	if query.Country != "" {
+		tx = tx.Where("seller_profiles.country = ?", strings.ToUpper(query.Country))
+	}
+	if !query.JoinedFrom.IsZero() {
+		tx = tx.Where("sellers.created_at >= ?", query.JoinedFrom)
+	}
+	if !query.JoinedTo.IsZero() {
+		// "joined_to" is a date, so include the whole day
+		tx = tx.Where("sellers.created_at < ?", query.JoinedTo.AddDate(0, 0, 1))
+	}
+
+	if query.Cursor != "" {
+		cursor, err := decodeDirectoryCursor(query.Cursor, sort)
+		if err != nil {
+			return nil, "", err
+		}
+		if sort == enums.SellerSortRelevance {
+			tx = tx.Where("("+rank+", sellers.id) < (CAST(? AS real), ?)", search, cursor.rank, cursor.id)
+		} else {
+			tx = tx.Where("(sellers.created_at, sellers.id) < (?, ?)", cursor.joinedAt, cursor.id)
+		}
+	}
+	if sort == enums.SellerSortRelevance {
+		// Postgres orders by the output column, no need to bind the term again
+		tx = tx.Order("rank DESC")
+	} else {
+		tx = tx.Order("sellers.created_at DESC")
+	}
+
+	limit := directoryPageSize(query.Limit)
+	var entries []SellerDirectoryEntry
+	if err := tx.Order("sellers.id DESC").Limit(limit + 1).Scan(&entries).Error; err != nil {
+		return nil, "", err
+	}
+
+	nextCursor := ""
+	if len(entries) > limit {
+		entries = entries[:limit]
+		nextCursor = encodeDirectoryCursor(entries[limit-1], sort)
+	}
+	return entries, nextCursor, nil
+}
+
+func directoryPageSize(limit int) int {
+	if limit <= 0 {
+		return defaultDirectoryPageSize
+	}
+	if limit > maxDirectoryPageSize {
+		return maxDirectoryPageSize
+	}
+	return limit
+}
+
+type directoryCursor struct {
+	rank     float32
+	joinedAt time.Time
+	id       uuid.UUID
+}
+
+// encodeDirectoryCursor keeps the sort in the cursor so a cursor from one
+// ordering is refused by the other
+func encodeDirectoryCursor(entry SellerDirectoryEntry, sort string) string {
+	position := strconv.FormatInt(entry.JoinedAt.UnixNano(), 10)
+	if sort == enums.SellerSortRelevance {
+		position = strconv.FormatFloat(float64(entry.Rank), 'g', -1, 32)
+	}
+	raw := fmt.Sprintf("%s:%s:%s", sort, position, entry.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeDirectoryCursor(encoded string, sort string) (directoryCursor, error) {
+	var cursor directoryCursor
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return cursor, ErrInvalidCursor
+	}
+	parts := strings.SplitN(string(raw), ":", 3)
+	if len(parts) != 3 || parts[0] != sort {
+		return cursor, ErrInvalidCursor
+	}
+	if cursor.id, err = uuid.Parse(parts[2]); err != nil {
+		return cursor, ErrInvalidCursor
+	}
+	if sort == enums.SellerSortRelevance {
+		rank, err := strconv.ParseFloat(parts[1], 32)
+		if err != nil {
+			return cursor, ErrInvalidCursor
+		}
+		cursor.rank = float32(rank)
+		return cursor, nil
+	}
+	nanos, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return cursor, ErrInvalidCursor
+	}
+	cursor.joinedAt = time.Unix(0, nanos)
+	return cursor, nil
+}
@@ -0,0 +1,164 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"time"
+	"user-service/db"
+	"user-service/enums"
+	"user-service/rates"
+)
+
+// Settlement records a buyer to seller payment and how it was split between
+// the seller and the platform commission.
+//
+// A settlement touches the buyer wallet, the seller wallet and the platform
+// ledger in one database transaction. On Citus the buyer and seller wallets are
+// usually on different shards, the coordinator then commits the transaction
+// with two-phase commit so either every wallet is updated or none is. Wallets
+// are always locked in buyer, seller order to avoid deadlocks, the platform
+// ledger is append-only and takes no lock.
+//
+// Amount, Commission and SellerAmount are in the buyer wallet currency. When
+// the seller has no wallet in that currency the seller share is converted into
+// the seller default wallet, the rate used is kept in ExchangeRate so refunds
+// reverse at the same rate.
+//
+// PromoAmount is the part the buyer paid with promo credit, the seller is paid
+// in full and the platform wallet funds that part.
+type Settlement struct {
+	gorm.Model
+	BuyerID             uuid.UUID `gorm:"type:uuid;primaryKey"`
+	SellerID            uuid.UUID `gorm:"type:uuid;index"`
+	HoldID              *uint
+	Currency            string          `gorm:"size:3;default:THB;not null"`
+	Amount              decimal.Decimal `gorm:"type:decimal(12,2);"`
+	PromoAmount         decimal.Decimal `gorm:"type:decimal(12,2);default:0;not null"`
+	CommissionRate      decimal.Decimal `gorm:"type:decimal(5,4);"`
+	Commission          decimal.Decimal `gorm:"type:decimal(12,2);"`
+	SellerAmount        decimal.Decimal `gorm:"type:decimal(12,2);"`
+	SellerCurrency      string          `gorm:"size:3;default:THB;not null"`
+	ExchangeRate        decimal.Decimal `gorm:"type:decimal(18,8);default:1;not null"`
+	RateAsOf            *time.Time
+	SellerCreditAmount  decimal.Decimal `gorm:"type:decimal(12,2);"`
+	RefundedCommission  decimal.Decimal `gorm:"type:decimal(12,2);default:0;not null"`
+	Category            string
+	Reference           string
+	BuyerTransactionID  uint
+	SellerTransactionID uint
+}
+
+// Settle debits the buyer wallet in currency, promo credit first, credits the
+// seller and books the commission into the platform ledger atomically
+func (u *Buyer) Settle(c context.Context, currency string, sellerID uuid.UUID, amount decimal.Decimal, category string, reference string) (*Settlement, error) {
+	if err := ValidateAmount(amount); err != nil {
+		return nil, err
+	}
+	settlement := Settlement{
+		BuyerID:   u.ID,
+		SellerID:  sellerID,
+		Amount:    amount,
+		Category:  category,
+		Reference: reference,
+	}
+	err := db.GetDB(c).Transaction(func(tx *gorm.DB) error {
+		buyerWallet, err := lockBuyerWallet(tx, u.ID, currency)
+		if err != nil {
+			return err
+		}
+		return settlement.apply(tx, buyerWallet, false)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &settlement, nil
+}
+
+// apply performs the settlement within tx. buyerWallet must already be locked
+// and fromHold tells whether the amount was reserved by an escrow hold
+func (s *Settlement) apply(tx *gorm.DB, buyerWallet BuyerWallet, fromHold bool) error {
+	s.Currency = buyerWallet.Currency
+	buyerTransaction, err := spendBuyerWallet(tx, buyerWallet, s.Amount, s.Reference, fromHold)
+	if err != nil {
+		return err
+	}
+	s.PromoAmount = buyerTransaction.PromoAmount.Neg()
+
+	s.CommissionRate, err = resolveCommissionRate(tx, s.SellerID, s.Category)
+	if err != nil {
+		return err
+	}
+	s.Commission = s.Amount.Mul(s.CommissionRate).Round(2)
+	s.SellerAmount = s.Amount.Sub(s.Commission)
+
+	sellerWallet, err := lockSettlementSellerWallet(tx, s.SellerID, s.Currency)
+	if err != nil {
+		return err
+	}
+	s.SellerCurrency = sellerWallet.Currency
+	s.ExchangeRate = decimal.NewFromInt(1)
+	s.SellerCreditAmount = s.SellerAmount
+	if s.SellerCurrency != s.Currency {
+		quote, err := rates.GetSource().Rate(tx.Statement.Context, s.Currency, s.SellerCurrency)
+		if err != nil {
+			return err
+		}
+		s.ExchangeRate = quote.Rate
+		s.RateAsOf = &quote.AsOf
+		s.SellerCreditAmount = s.SellerAmount.Mul(quote.Rate).Round(2)
+	}
+	sellerBalance := sellerWallet.Balance.Add(s.SellerCreditAmount)
+	if err := tx.
+		Model(&sellerWallet).
+		Where("seller_id = ?", s.SellerID).
+		Update("Balance", sellerBalance).Error; err != nil {
+		return err
+	}
+	sellerTransaction, err := createSellerTransaction(tx, sellerWallet, enums.TransactionSale, s.SellerCreditAmount, sellerBalance, s.Reference)
+	if err != nil {
+		return err
+	}
+
+	if s.Commission.IsPositive() {
+		if err := creditPlatformWallet(tx, s.Currency, enums.TransactionCommission, s.Commission, s.Reference); err != nil {
+			return err
+		}
+	}
+	if s.PromoAmount.IsPositive() {
+		if err := creditPlatformWallet(tx, s.Currency, enums.TransactionPromoFunding, s.PromoAmount.Neg(), s.Reference); err != nil {
+			return err
+		}
+	}
+	if s.SellerCurrency != s.Currency {
+		if err := bookExchange(tx, s.Currency, s.SellerAmount, s.SellerCurrency, s.SellerCreditAmount, s.Reference); err != nil {
+			return err
+		}
+	}
+
+	s.BuyerTransactionID = buyerTransaction.ID
+	s.SellerTransactionID = sellerTransaction.ID
+	return tx.Create(s).Error
+}
+
+// bookExchange keeps the books of every currency whole when a seller is paid in
+// another currency than the buyer paid in: the platform takes sold in one
+// currency and pays bought in the other
+func bookExchange(tx *gorm.DB, soldCurrency string, sold decimal.Decimal, boughtCurrency string, bought decimal.Decimal, reference string) error {
+	if err := creditPlatformWallet(tx, soldCurrency, enums.TransactionExchange, sold, reference); err != nil {
+		return err
+	}
+	return creditPlatformWallet(tx, boughtCurrency, enums.TransactionExchange, bought.Neg(), reference)
+}
+
+// lockSettlementSellerWallet picks the seller wallet in the buyer currency and
+// falls back to the seller default wallet
+func lockSettlementSellerWallet(tx *gorm.DB, sellerID uuid.UUID, currency string) (SellerWallet, error) {
+	wallet, err := lockSellerWallet(tx, sellerID, currency)
+	if errors.Is(err, gorm.ErrRecordNotFound) && currency != enums.DefaultCurrency {
+		return lockSellerWallet(tx, sellerID, enums.DefaultCurrency)
+	}
+	return wallet, err
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"testing"
+	"time"
+	"user-service/enums"
+)
+
+// The buyer pays the amount, the seller gets it less commission and the
+// platform keeps the commission less the promo credit it funds
+func TestSettleSplitsTheAmount(t *testing.T) {
+	requireDB(t)
+	tests := []struct {
+		name           string
+		cash           string
+		promo          string
+		rate           string
+		amount         string
+		want           error
+		wantCommission string
+		wantSeller     string
+		wantPlatform   string
+	}{
+		{"no commission", "100.00", "0", "0", "100.00", nil, "0", "100.00", "0"},
+		{"ten percent", "100.00", "0", "0.1", "100.00", nil, "10.00", "90.00", "10.00"},
+		{"commission rounded to cents", "100.00", "0", "0.075", "33.33", nil, "2.50", "30.83", "2.50"},
+		{"promo credit funded by the platform", "100.00", "20.00", "0.1", "100.00", nil, "10.00", "90.00", "-10.00"},
+		{"more than the buyer has", "10.00", "0", "0.1", "10.01", ErrInsufficientFunds, "0", "0", "0"},
+	}
+	c := context.Background()
+	for _, test := range tests {
+		buyer := newTestBuyer(t, test.cash)
+		seller, _ := newTestSeller(t, "0")
+		setTestCommissionRate(t, seller.ID, test.rate)
+		if promo := decimal.RequireFromString(test.promo); promo.IsPositive() {
+			if _, err := buyer.GrantPromoCredit(c, enums.DefaultCurrency, promo, "test", time.Now().Add(time.Hour)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		reference := "order-" + uuid.New().String()
+		settlement, err := buyer.Settle(c, enums.DefaultCurrency, seller.ID, decimal.RequireFromString(test.amount), "", reference)
+		if !errors.Is(err, test.want) {
+			t.Errorf("%s: Settle = %v, want %v", test.name, err, test.want)
+			continue
+		}
+		if err == nil && !settlement.Commission.Equal(decimal.RequireFromString(test.wantCommission)) {
+			t.Errorf("%s: commission %s, want %s", test.name, settlement.Commission, test.wantCommission)
+		}
+		if balance := testSellerWallet(t, seller.ID).Balance; !balance.Equal(decimal.RequireFromString(test.wantSeller)) {
+			t.Errorf("%s: seller balance %s, want %s", test.name, balance, test.wantSeller)
+		}
+		if total := platformTotal(t, reference); !total.Equal(decimal.RequireFromString(test.wantPlatform)) {
+			t.Errorf("%s: platform booked %s, want %s", test.name, total, test.wantPlatform)
+		}
+	}
+}
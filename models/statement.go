@@ -0,0 +1,266 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"strconv"
+	"strings"
+	"time"
+	"user-service/db"
+	"user-service/enums"
+	"user-service/errs"
+	"user-service/i18n"
+)
+
+var ErrStatementNotAvailable = errs.New(errs.ErrValidation, i18n.CodeStatementNotAvailable, "statement month has not started yet")
+
+// Statement is the monthly summary of one wallet. Balances are the cash
+// balance, promo credit only shows on the lines that moved it
+type Statement struct {
+	Group       string
+	AccountID   uuid.UUID
+	Username    string
+	AccountName string
+	Currency    string
+	// PeriodStart is the first instant of the month, PeriodEnd the first
+	// instant of the next one
+	PeriodStart     time.Time
+	PeriodEnd       time.Time
+	OpeningBalance  decimal.Decimal
+	ClosingBalance  decimal.Decimal
+	TotalCredits    decimal.Decimal
+	TotalDebits     decimal.Decimal
+	TotalFees       decimal.Decimal
+	TotalCommission decimal.Decimal
+	Lines           []StatementLine
+}
+
+type StatementLine struct {
+	ID        uint
+	Date      time.Time
+	Type      string
+	Reference string
+	// Amount is the change of the cash balance
+	Amount      decimal.Decimal
+	PromoAmount decimal.Decimal
+	// Fee is what the platform charged on a payout, Commission what it kept
+	// from a sale. Both are already deducted from Amount
+	Fee        decimal.Decimal
+	Commission decimal.Decimal
+	Balance    decimal.Decimal
+}
+
+// StatementPeriod returns the bounds of month in UTC
+func StatementPeriod(month time.Time) (time.Time, time.Time, error) {
+	start := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if start.After(time.Now()) {
+		return time.Time{}, time.Time{}, ErrStatementNotAvailable
+	}
+	return start, start.AddDate(0, 1, 0), nil
+}
+
+// Statement builds the statement of the buyer wallet in currency for month
+func (u *Buyer) Statement(c context.Context, currency string, month time.Time) (*Statement, error) {
+	start, end, err := StatementPeriod(month)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.RetrieveByUserIDWithProfile(c, u.ID); err != nil {
+		return nil, err
+	}
+	var wallet BuyerWallet
+	if err := db.GetDB(c).
+		Where("buyer_id = ? AND currency = ? AND deleted_at IS NULL", u.ID, currency).
+		First(&wallet).Error; err != nil {
+		return nil, err
+	}
+
+	var opening BuyerWalletTransaction
+	err = db.GetDB(c).
+		Where("buyer_id = ? AND currency = ? AND created_at < ?", u.ID, currency, start).
+		Order("created_at DESC").
+		Order("id DESC").
+		First(&opening).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	statement := newStatement(enums.Buyer, u.ID, u.Username, u.BuyerProfile.FirstName, u.BuyerProfile.LastName, currency, start, end, opening.BalanceAfter)
+
+	var transactions []BuyerWalletTransaction
+	if err := db.GetDB(c).
+		Where("buyer_id = ? AND currency = ? AND created_at >= ? AND created_at < ?", u.ID, currency, start, end).
+		Order("created_at").
+		Order("id").
+		Find(&transactions).Error; err != nil {
+		return nil, err
+	}
+	for _, transaction := range transactions {
+		statement.addLine(StatementLine{
+			ID:          transaction.ID,
+			Date:        transaction.CreatedAt,
+			Type:        transaction.Type,
+			Reference:   transaction.Reference,
+			Amount:      transaction.CashAmount(),
+			PromoAmount: transaction.PromoAmount,
+			Balance:     transaction.BalanceAfter,
+		})
+	}
+	return statement, nil
+}
+
+// Statement builds the statement of the seller wallet in currency for month,
+// sales show the commission kept by the platform and payouts their fee
+func (u *Seller) Statement(c context.Context, currency string, month time.Time) (*Statement, error) {
+	start, end, err := StatementPeriod(month)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.RetrieveByUserIDWithProfile(c, u.ID); err != nil {
+		return nil, err
+	}
+	var wallet SellerWallet
+	if err := db.GetDB(c).
+		Where("seller_id = ? AND currency = ? AND deleted_at IS NULL", u.ID, currency).
+		First(&wallet).Error; err != nil {
+		return nil, err
+	}
+
+	var opening SellerWalletTransaction
+	err = db.GetDB(c).
+		Where("seller_id = ? AND currency = ? AND created_at < ?", u.ID, currency, start).
+		Order("created_at DESC").
+		Order("id DESC").
+		First(&opening).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	statement := newStatement(enums.Seller, u.ID, u.Username, u.SellerProfile.FirstName, u.SellerProfile.LastName, currency, start, end, opening.BalanceAfter)
+
+	var transactions []SellerWalletTransaction
+	if err := db.GetDB(c).
+		Where("seller_id = ? AND currency = ? AND created_at >= ? AND created_at < ?", u.ID, currency, start, end).
+		Order("created_at").
+		Order("id").
+		Find(&transactions).Error; err != nil {
+		return nil, err
+	}
+	commissions, err := sellerStatementCommissions(c, u.ID, transactions)
+	if err != nil {
+		return nil, err
+	}
+	fees, err := sellerStatementFees(c, u.ID, transactions)
+	if err != nil {
+		return nil, err
+	}
+	for _, transaction := range transactions {
+		line := StatementLine{
+			ID:        transaction.ID,
+			Date:      transaction.CreatedAt,
+			Type:      transaction.Type,
+			Reference: transaction.Reference,
+			Amount:    transaction.Amount,
+			Balance:   transaction.BalanceAfter,
+		}
+		switch transaction.Type {
+		case enums.TransactionSale:
+			line.Commission = commissions[transaction.ID]
+		case enums.TransactionPayout:
+			line.Fee = fees[transaction.Reference]
+		case enums.TransactionPayoutReversal:
+			line.Fee = fees[transaction.Reference].Neg()
+		}
+		statement.addLine(line)
+	}
+	return statement, nil
+}
+
+// newStatement starts a statement at the opening balance, a month without
+// transactions closes where it opened
+func newStatement(group string, id uuid.UUID, username string, firstName string, lastName string, currency string, start time.Time, end time.Time, opening decimal.Decimal) *Statement {
+	return &Statement{
+		Group:          group,
+		AccountID:      id,
+		Username:       username,
+		AccountName:    strings.TrimSpace(firstName + " " + lastName),
+		Currency:       currency,
+		PeriodStart:    start,
+		PeriodEnd:      end,
+		OpeningBalance: opening,
+		ClosingBalance: opening,
+		Lines:          []StatementLine{},
+	}
+}
+
+func (s *Statement) addLine(line StatementLine) {
+	if line.Amount.IsPositive() {
+		s.TotalCredits = s.TotalCredits.Add(line.Amount)
+	} else {
+		s.TotalDebits = s.TotalDebits.Add(line.Amount.Neg())
+	}
+	s.TotalFees = s.TotalFees.Add(line.Fee)
+	s.TotalCommission = s.TotalCommission.Add(line.Commission)
+	s.ClosingBalance = line.Balance
+	s.Lines = append(s.Lines, line)
+}
+
+// sellerStatementCommissions maps sale transactions to the commission of their
+// settlement, converted to the seller currency
+func sellerStatementCommissions(c context.Context, sellerID uuid.UUID, transactions []SellerWalletTransaction) (map[uint]decimal.Decimal, error) {
+	var ids []uint
+	for _, transaction := range transactions {
+		if transaction.Type == enums.TransactionSale {
+			ids = append(ids, transaction.ID)
+		}
+	}
+	commissions := make(map[uint]decimal.Decimal, len(ids))
+	if len(ids) == 0 {
+		return commissions, nil
+	}
+	var settlements []Settlement
+	if err := db.GetDB(c).
+		Where("seller_id = ? AND seller_transaction_id IN ?", sellerID, ids).
+		Find(&settlements).Error; err != nil {
+		return nil, err
+	}
+	for _, settlement := range settlements {
+		commissions[settlement.SellerTransactionID] = settlement.Commission.Mul(settlement.ExchangeRate).Round(2)
+	}
+	return commissions, nil
+}
+
+// sellerStatementFees maps payout references to the fee of the payout request
+func sellerStatementFees(c context.Context, sellerID uuid.UUID, transactions []SellerWalletTransaction) (map[string]decimal.Decimal, error) {
+	var ids []uint
+	for _, transaction := range transactions {
+		if transaction.Type != enums.TransactionPayout && transaction.Type != enums.TransactionPayoutReversal {
+			continue
+		}
+		id, err := strconv.ParseUint(strings.TrimPrefix(transaction.Reference, "payout:"), 10, 64)
+		if err == nil {
+			ids = append(ids, uint(id))
+		}
+	}
+	fees := make(map[string]decimal.Decimal, len(ids))
+	if len(ids) == 0 {
+		return fees, nil
+	}
+	var requests []PayoutRequest
+	if err := db.GetDB(c).
+		Where("seller_id = ? AND id IN ?", sellerID, ids).
+		Find(&requests).Error; err != nil {
+		return nil, err
+	}
+	for _, request := range requests {
+		fees[request.reference()] = request.Fee
+	}
+	return fees, nil
+}
+
+// Filename is the name statement downloads are offered under
+func (s *Statement) Filename(extension string) string {
+	return fmt.Sprintf("statement-%s-%s.%s", s.PeriodStart.Format("2006-01"), s.Currency, extension)
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"testing"
+	"time"
+	"user-service/enums"
+)
+
+func TestStatementClosingBalance(t *testing.T) {
+	tests := []struct {
+		name    string
+		opening string
+		lines   []string
+		want    string
+	}{
+		{"no transactions closes at the opening balance", "250.00", nil, "250.00"},
+		{"empty wallet", "0", nil, "0"},
+		{"closes at the last line balance", "100.00", []string{"150.00", "90.00"}, "90.00"},
+	}
+	for _, test := range tests {
+		start := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+		statement := newStatement(enums.Buyer, uuid.New(), "buyer", "A", "B", enums.DefaultCurrency, start, start.AddDate(0, 1, 0), decimal.RequireFromString(test.opening))
+		for _, balance := range test.lines {
+			statement.addLine(StatementLine{Amount: decimal.NewFromInt(1), Balance: decimal.RequireFromString(balance)})
+		}
+		if !statement.ClosingBalance.Equal(decimal.RequireFromString(test.want)) {
+			t.Errorf("%s: closing balance = %s, want %s", test.name, statement.ClosingBalance, test.want)
+		}
+	}
+}
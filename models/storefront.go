@@ -0,0 +1,208 @@
+package models
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+	"user-service/db"
+	"user-service/enums"
+	"user-service/errs"
+	"user-service/i18n"
+)
+
+const (
+	maxBioLength    = 1000
+	maxSocialLinks  = 10
+	maxSocialURLLen = 300
+)
+
+var (
+	ErrInvalidSlug       = errs.New(errs.ErrValidation, i18n.CodeInvalidSlug, "slug must be 3 to 40 lowercase letters, digits or dashes")
+	ErrSlugTaken         = errs.New(errs.ErrConflict, i18n.CodeSlugTaken, "slug is already taken")
+	ErrInvalidBio        = errs.New(errs.ErrValidation, i18n.CodeInvalidBio, "bio must be at most 1000 characters")
+	ErrInvalidSocialLink = errs.New(errs.ErrValidation, i18n.CodeInvalidSocialLink, "social links need a known platform and an http or https URL")
+	ErrInvalidCountry    = errs.New(errs.ErrValidation, i18n.CodeInvalidCountry, "country must be an ISO 3166-1 alpha-2 code")
+)
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,38}[a-z0-9]$`)
+
+var countryPattern = regexp.MustCompile(`^[A-Z]{2}$`)
+
+// SellerSlug is a reference table so a slug can be looked up without knowing
+// the seller, and stays unique across shards
+type SellerSlug struct {
+	Slug      string    `gorm:"primaryKey"`
+	SellerID  uuid.UUID `gorm:"type:uuid;uniqueIndex"`
+	CreatedAt time.Time
+}
+
+type SellerSocialLink struct {
+	gorm.Model
+	SellerID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Platform string
+	URL      string
+}
+
+type SocialLink struct {
+	Platform string
+	URL      string
+}
+
+// SellerProfileUpdate adds the storefront fields to a profile update, nil
+// fields are left unchanged. SocialLinks replaces every link when set
+type SellerProfileUpdate struct {
+	ProfileUpdate
+	DisplayName *string
+	Bio         *string
+	Slug        *string
+	Country     *string
+	SocialLinks *[]SocialLink
+}
+
+func (p SellerProfileUpdate) columns() (map[string]interface{}, error) {
+	columns, err := p.ProfileUpdate.columns()
+	if err != nil {
+		return nil, err
+	}
+	if p.DisplayName != nil {
+		name := strings.TrimSpace(*p.DisplayName)
+		// An empty display name goes back to the first and last name
+		if name != "" {
+			if name, err = normalizeProfileName(name); err != nil {
+				return nil, err
+			}
+		}
+		columns["display_name"] = name
+	}
+	if p.Bio != nil {
+		bio := strings.TrimSpace(*p.Bio)
+		if utf8.RuneCountInString(bio) > maxBioLength {
+			return nil, ErrInvalidBio
+		}
+		columns["bio"] = bio
+	}
+	if p.Slug != nil {
+		slug := strings.ToLower(strings.TrimSpace(*p.Slug))
+		if slug != "" && !IsValidSlug(slug) {
+			return nil, ErrInvalidSlug
+		}
+		columns["slug"] = slug
+	}
+	if p.Country != nil {
+		country := strings.ToUpper(strings.TrimSpace(*p.Country))
+		if country != "" && !countryPattern.MatchString(country) {
+			return nil, ErrInvalidCountry
+		}
+		columns["country"] = country
+	}
+	if p.SocialLinks != nil {
+		if len(*p.SocialLinks) > maxSocialLinks {
+			return nil, ErrInvalidSocialLink
+		}
+		for _, link := range *p.SocialLinks {
+			if !validSocialLink(link) {
+				return nil, ErrInvalidSocialLink
+			}
+		}
+	}
+	if len(columns) == 0 && p.SocialLinks == nil {
+		return nil, ErrEmptyProfileUpdate
+	}
+	return columns, nil
+}
+
+// IsValidSlug tells whether slug can name a storefront. Slugs never parse as
+// a UUID so the two lookups cannot collide
+func IsValidSlug(slug string) bool {
+	if !slugPattern.MatchString(slug) {
+		return false
+	}
+	_, err := uuid.Parse(slug)
+	return err != nil
+}
+
+func validSocialLink(link SocialLink) bool {
+	if !enums.IsValidSocialPlatform(link.Platform) || len(link.URL) > maxSocialURLLen {
+		return false
+	}
+	parsed, err := url.Parse(link.URL)
+	if err != nil {
+		return false
+	}
+	return (parsed.Scheme == "https" || parsed.Scheme == "http") && parsed.Host != ""
+}
+
+// claimSlug moves the seller slug from previous to slug within tx
+func claimSlug(tx *gorm.DB, sellerID uuid.UUID, previous string, slug string) error {
+	if previous == slug {
+		return nil
+	}
+	if previous != "" {
+		if err := tx.Where("slug = ? AND seller_id = ?", previous, sellerID).Delete(&SellerSlug{}).Error; err != nil {
+			return err
+		}
+	}
+	if slug == "" {
+		return nil
+	}
+	result := tx.
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&SellerSlug{Slug: slug, SellerID: sellerID})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrSlugTaken
+	}
+	return nil
+}
+
+func replaceSocialLinks(tx *gorm.DB, sellerID uuid.UUID, links []SocialLink) error {
+	if err := tx.Where("seller_id = ?", sellerID).Delete(&SellerSocialLink{}).Error; err != nil {
+		return err
+	}
+	for _, link := range links {
+		if err := tx.Create(&SellerSocialLink{
+			SellerID: sellerID,
+			Platform: link.Platform,
+			URL:      link.URL,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StorefrontName is what buyers see the seller as
+func (p SellerProfile) StorefrontName() string {
+	if p.DisplayName != "" {
+		return p.DisplayName
+	}
+	return strings.TrimSpace(p.FirstName + " " + p.LastName)
+}
+
+// RetrieveStorefront loads the public side of a seller by ID or slug, deleted
+// sellers are not found
+func (u *Seller) RetrieveStorefront(c context.Context, idOrSlug string) error {
+	id, err := uuid.Parse(idOrSlug)
+	if err != nil {
+		var slug SellerSlug
+		if err := db.GetDB(c).Where("slug = ?", strings.ToLower(idOrSlug)).First(&slug).Error; err != nil {
+			return err
+		}
+		id = slug.SellerID
+	}
+	return db.GetDB(c).
+		Where("id = ? AND deleted_at IS NULL", id).
+		Preload("SellerProfile").
+		Preload("SocialLinks", func(tx *gorm.DB) *gorm.DB {
+			return tx.Where("deleted_at IS NULL").Order("id")
+		}).
+		First(u).Error
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"testing"
+	"time"
+	"user-service/db"
+	"user-service/forms"
+)
+
+func TestDeletedSellerIsHidden(t *testing.T) {
+	requireDB(t)
+	c := context.Background()
+	seller, _ := newTestSeller(t, "0")
+	if err := db.GetDB(c).Model(&Seller{}).Where("id = ?", seller.ID).Update("deleted_at", time.Now()).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := (&Seller{}).RetrieveStorefront(c, seller.ID.String()); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("RetrieveStorefront of a deleted seller = %v, want ErrRecordNotFound", err)
+	}
+	entries, _, err := ListSellerDirectory(c, forms.SellerDirectoryQuery{JoinedFrom: seller.CreatedAt, Limit: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.ID == seller.ID {
+			t.Error("deleted seller is listed in the directory")
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"log"
+	"os"
+	"time"
+	"user-service/db"
+	"user-service/enums"
+	"user-service/errs"
+	"user-service/i18n"
+	"user-service/payment"
+)
+
+const defaultTopupIntentTTL = 30 * time.Minute
+
+var (
+	ErrUnknownTopupStatus = errs.New(errs.ErrValidation, i18n.CodeUnknownTopupStatus, "unknown top-up status")
+	// ErrPaymentAmountMismatch leaves the intent pending for someone to look at,
+	// the provider keeps retrying until then
+	ErrPaymentAmountMismatch = errs.New(errs.ErrValidation, i18n.CodePaymentAmountMismatch, "paid amount does not match the top-up")
+)
+
+// TopupIntent tracks a top-up from the moment the buyer asks for it until the
+// payment provider confirms or rejects the payment
+type TopupIntent struct {
+	gorm.Model
+	BuyerID           uuid.UUID       `gorm:"type:uuid;primaryKey"`
+	Currency          string          `gorm:"size:3;default:THB;not null"`
+	Amount            decimal.Decimal `gorm:"type:decimal(12,2);"`
+	Status            string
+	ProviderReference string `gorm:"index"`
+	CheckoutURL       string
+	ExpiresAt         time.Time `gorm:"index"`
+	TransactionID     *uint
+}
+
+func topupIntentTTL() time.Duration {
+	ttl, err := time.ParseDuration(os.Getenv("PAYMENT_INTENT_TTL"))
+	if err != nil || ttl <= 0 {
+		return defaultTopupIntentTTL
+	}
+	return ttl
+}
+
+// StartTopup checks the wallet policy and opens a payment intent with the
+// provider for the wallet in currency. The wallet is only credited when the
+// provider confirms the payment
+func (u *Buyer) StartTopup(c context.Context, currency string, amount decimal.Decimal, provider payment.Provider) (*TopupIntent, error) {
+	if !payment.IsEnabled(provider) {
+		return nil, payment.ErrTopupsDisabled
+	}
+	policy := LoadWalletPolicy()
+	localPolicy, err := policy.convertTo(c, currency)
+	if err != nil {
+		return nil, err
+	}
+	if err := localPolicy.ValidateTopup(amount); err != nil {
+		return nil, err
+	}
+	intent := TopupIntent{
+		BuyerID:   u.ID,
+		Currency:  currency,
+		Amount:    amount,
+		Status:    enums.TopupPending,
+		ExpiresAt: time.Now().Add(topupIntentTTL()),
+	}
+	err = db.GetDB(c).Transaction(func(tx *gorm.DB) error {
+		wallets, err := lockBuyerWallets(tx, u.ID)
+		if err != nil {
+			return err
+		}
+		if err := policy.checkTopupLimits(c, tx, wallets, currency, amount); err != nil {
+			return err
+		}
+		return tx.Create(&intent).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	providerIntent, err := provider.CreateIntent(c, payment.Intent{
+		ID:        intent.reference(),
+		Amount:    amount,
+		Currency:  intent.Currency,
+		ExpiresAt: intent.ExpiresAt,
+	})
+	if err != nil {
+		_ = db.GetDB(c).Model(&intent).Update("status", enums.TopupFailed).Error
+		return nil, err
+	}
+	intent.ProviderReference = providerIntent.Reference
+	intent.CheckoutURL = providerIntent.CheckoutURL
+	if err := db.GetDB(c).Model(&intent).Updates(map[string]interface{}{
+		"provider_reference": intent.ProviderReference,
+		"checkout_url":       intent.CheckoutURL,
+	}).Error; err != nil {
+		return nil, err
+	}
+	return &intent, nil
+}
+
+func (i *TopupIntent) reference() string {
+	return fmt.Sprintf("topup:%d", i.ID)
+}
+
+func (i *TopupIntent) RetrieveByProviderReference(c context.Context, reference string) error {
+	return db.GetDB(c).Where("provider_reference = ?", reference).First(i).Error
+}
+
+// ApplyPaymentEvent moves the intent to the status reported by the provider.
+// A confirmed payment is credited even when the intent already expired on our
+// side, the buyer has paid, but only when the paid amount is the one asked
+// for. Replayed events are ignored
+func (i *TopupIntent) ApplyPaymentEvent(c context.Context, event payment.Event) error {
+	status := event.Status
+	switch status {
+	case enums.TopupSucceeded, enums.TopupFailed:
+	default:
+		return ErrUnknownTopupStatus
+	}
+	return db.GetDB(c).Transaction(func(tx *gorm.DB) error {
+		wallet, err := lockBuyerWallet(tx, i.BuyerID, i.Currency)
+		if err != nil {
+			return err
+		}
+		if err := tx.
+			Where("id = ? AND buyer_id = ?", i.ID, i.BuyerID).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(i).Error; err != nil {
+			return err
+		}
+		if i.Status != enums.TopupPending && i.Status != enums.TopupExpired {
+			return nil
+		}
+		if status == enums.TopupFailed {
+			i.Status = enums.TopupFailed
+			return tx.Model(i).Update("status", i.Status).Error
+		}
+		if !event.Amount.Equal(i.Amount) || event.Currency != i.Currency {
+			log.Printf("top-up %d paid %s %s, expected %s %s", i.ID, event.Amount, event.Currency, i.Amount, i.Currency)
+			return ErrPaymentAmountMismatch
+		}
+
+		updatedBalance := wallet.Balance.Add(i.Amount)
+		if err := tx.
+			Model(&wallet).
+			Where("buyer_id = ?", i.BuyerID).
+			Update("Balance", updatedBalance).Error; err != nil {
+			return err
+		}
+		transaction, err := createBuyerTransaction(tx, wallet, enums.TransactionTopup, i.Amount, updatedBalance, i.reference())
+		if err != nil {
+			return err
+		}
+		i.Status = enums.TopupSucceeded
+		i.TransactionID = &transaction.ID
+		return tx.Model(i).Updates(map[string]interface{}{
+			"status":         i.Status,
+			"transaction_id": i.TransactionID,
+		}).Error
+	})
+}
+
+// ExpireTopupIntents gives up on intents the buyer never paid
+func ExpireTopupIntents(c context.Context) error {
+	return db.GetDB(c).
+		Model(&TopupIntent{}).
+		Where("status = ? AND expires_at < ?", enums.TopupPending, time.Now()).
+		Update("status", enums.TopupExpired).Error
+}
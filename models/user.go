@@ -2,27 +2,37 @@ package models
 
 import (
 	"context"
-	"errors"
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
-	"gorm.io/gorm/clause"
 	"html"
 	"strings"
 	"time"
 	"user-service/db"
+	"user-service/enums"
+	"user-service/errs"
 	"user-service/forms"
+	"user-service/i18n"
 )
 
+var ErrUserExists = errs.New(errs.ErrConflict, i18n.CodeUserExists, "user already exists")
+
 type Buyer struct {
 	ID           uuid.UUID `gorm:"primarykey;type:uuid;uniqueIndex:username_unique"`
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 	Username     string `gorm:"uniqueIndex:username_unique"`
 	Password     string
-	BuyerProfile BuyerProfile `gorm:"OnDelete:CASCADE"`
-	BuyerWallet  BuyerWallet
+	ReferralCode string
+	// Phone is in E.164 format and set once verified by OTP
+	Phone           string
+	PhoneVerifiedAt *time.Time
+	BuyerProfile    BuyerProfile `gorm:"OnDelete:CASCADE"`
+	// BuyerWallet is the wallet in the default currency, Wallets holds every
+	// wallet the buyer opened including that one
+	BuyerWallet BuyerWallet
+	Wallets     []BuyerWallet
 }
 
 type BuyerProfile struct {
@@ -30,12 +40,33 @@ type BuyerProfile struct {
 	FirstName string
 	LastName  string
 	BuyerID   uuid.UUID `gorm:"type:uuid;primaryKey"`
+	// Avatar is the storage key of the uploaded avatar, empty when none
+	Avatar string
 }
 
 type BuyerWallet struct {
 	gorm.Model
-	Balance decimal.Decimal `gorm:"type:decimal(12,2);"`
-	BuyerID uuid.UUID       `gorm:"type:uuid;primaryKey"`
+	Balance     decimal.Decimal `gorm:"type:decimal(12,2);"`
+	HeldBalance decimal.Decimal `gorm:"type:decimal(12,2);default:0;not null"`
+	// PromoBalance is promotional credit, spent before the cash Balance and
+	// never withdrawn
+	PromoBalance decimal.Decimal `gorm:"type:decimal(12,2);default:0;not null"`
+	BuyerID      uuid.UUID       `gorm:"type:uuid;primaryKey;uniqueIndex:buyer_wallet_currency"`
+	Currency     string          `gorm:"size:3;default:THB;not null;uniqueIndex:buyer_wallet_currency"`
+	// A frozen wallet cannot be spent from until an admin reviews it
+	Frozen       bool `gorm:"default:false;not null"`
+	FrozenReason string
+}
+
+// AvailableBalance is the part of the balance not reserved by escrow holds
+func (w BuyerWallet) AvailableBalance() decimal.Decimal {
+	return w.Balance.Sub(w.HeldBalance)
+}
+
+// SpendableBalance is the available cash plus the promo credit. Holds reserve
+// from both, so it is what a new hold or debit may take
+func (w BuyerWallet) SpendableBalance() decimal.Decimal {
+	return w.AvailableBalance().Add(w.PromoBalance)
 }
 
 func (u *Buyer) RetrieveByUserID(c context.Context, userID uuid.UUID) error {
@@ -49,7 +80,10 @@ func (u *Buyer) RetrieveByUserIDWithProfile(c context.Context, userID uuid.UUID)
 	if err := db.GetDB(c).
 		Where("id = ?", userID).
 		Preload("BuyerProfile").
-		Preload("BuyerWallet").
+		Preload("BuyerWallet", "currency = ?", enums.DefaultCurrency).
+		Preload("Wallets", func(tx *gorm.DB) *gorm.DB {
+			return tx.Order("id")
+		}).
 		First(u).
 		Error; err != nil {
 		return err
@@ -70,13 +104,15 @@ func (u *Buyer) IsUsernameExist(c context.Context, username string) (bool, error
 	return userExists, nil
 }
 
-func (u *Buyer) CreateAccount(c context.Context, registerForm forms.UserSignUp) (*Buyer, error) {
+// CreateAccount registers the buyer with a referral code of their own, and
+// records the referral when the form carries the code of another user
+func (u *Buyer) CreateAccount(c context.Context, registerForm forms.UserSignUp, signal SignupSignal) (*Buyer, error) {
 	userExists, userResultError := u.IsUsernameExist(c, registerForm.Username)
 	if userResultError != nil {
 		return &Buyer{}, userResultError
 	}
 	if userExists {
-		return &Buyer{}, errors.New("user already exists")
+		return &Buyer{}, ErrUserExists
 	}
 
 	var profile = BuyerProfile{
@@ -88,21 +124,55 @@ func (u *Buyer) CreateAccount(c context.Context, registerForm forms.UserSignUp)
 		Password:     registerForm.Password,
 		BuyerProfile: profile,
 		BuyerWallet: BuyerWallet{
-			Balance: decimal.NewFromInt(0),
+			Balance:  decimal.NewFromInt(0),
+			Currency: enums.DefaultCurrency,
 		},
 	}
 
-	if err := db.GetDB(c).Create(&user).Error; err != nil {
+	err := db.GetDB(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		code, err := createReferralCode(tx, user.ID, enums.Buyer, user.Username, signal)
+		if err != nil {
+			return err
+		}
+		user.ReferralCode = code
+		if err := tx.Model(&user).Update("ReferralCode", code).Error; err != nil {
+			return err
+		}
+		if registerForm.ReferralCode == "" {
+			return nil
+		}
+		return applyReferral(tx, registerForm.ReferralCode, user.ID, enums.Buyer, user.Username, signal)
+	})
+	if err != nil {
 		return &Buyer{}, err
 	}
 	return &user, nil
 }
 
+// EnsureReferralCode gives accounts opened before the referral program a code
+func (u *Buyer) EnsureReferralCode(c context.Context) error {
+	if u.ReferralCode != "" {
+		return nil
+	}
+	code, err := ensureReferralCode(c, u.ID, enums.Buyer, u.Username)
+	if err != nil {
+		return err
+	}
+	u.ReferralCode = code
+	return db.GetDB(c).Model(u).Update("ReferralCode", code).Error
+}
+
 func (u *Buyer) Login(c context.Context, form forms.UserSignIn) (bool, error) {
 	if err := db.GetDB(c).
 		Where("username = ?", form.Username).
 		Preload("BuyerProfile").
-		Preload("BuyerWallet").
+		Preload("BuyerWallet", "currency = ?", enums.DefaultCurrency).
+		Preload("Wallets", func(tx *gorm.DB) *gorm.DB {
+			return tx.Order("id")
+		}).
 		First(u).Error; err != nil {
 		return false, err
 	}
@@ -132,47 +202,32 @@ func (u *Buyer) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
-func (u *Buyer) AddBalance(c context.Context, input forms.AddWalletBalanceInput) (decimal.Decimal, error) {
-	updatedBalance := u.BuyerWallet.Balance
-	err := db.GetDB(c).Transaction(func(tx *gorm.DB) error {
-		tmpWallet := BuyerWallet{}
-		// do some database operations in the transaction (use 'tx' from this point, not 'db')
-		if err := tx.
-			Model(&tmpWallet).
-			Where("buyer_id = ?", u.ID).
-			Clauses(clause.Locking{Strength: "UPDATE"}).First(&tmpWallet).Error; err != nil {
-			return err
-		}
-		updatedBalance = tmpWallet.Balance.Add(input.AddBalance)
-		if err := tx.
-			Model(&tmpWallet).
-			Where("buyer_id = ?", u.ID).
-			Update("Balance", updatedBalance).Error; err != nil {
-			return err
-		}
-		return nil
-	})
-
-	if err != nil {
-		return decimal.NewFromInt(0), err
-	}
-	return updatedBalance, nil
-}
-
 type Seller struct {
-	ID            uuid.UUID `gorm:"primarykey;type:uuid;uniqueIndex:username_unique"`
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	Username      string `gorm:"uniqueIndex:username_unique"`
-	Password      string
-	SellerProfile SellerProfile `gorm:"OnDelete:CASCADE"`
-	SellerWallet  SellerWallet
+	ID           uuid.UUID `gorm:"primarykey;type:uuid;uniqueIndex:username_unique"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Username     string `gorm:"uniqueIndex:username_unique"`
+	Password     string
+	ReferralCode string
+	// Phone is in E.164 format and set once verified by OTP
+	Phone           string
+	PhoneVerifiedAt *time.Time
+	SellerProfile   SellerProfile `gorm:"OnDelete:CASCADE"`
+	// SellerWallet is the wallet in the default currency, Wallets holds every
+	// wallet the seller opened including that one
+	SellerWallet SellerWallet
+	Wallets      []SellerWallet
+	SocialLinks  []SellerSocialLink
 }
 
 type SellerWallet struct {
 	gorm.Model
 	Balance  decimal.Decimal `gorm:"type:decimal(12,2);"`
-	SellerID uuid.UUID       `gorm:"type:uuid;primaryKey"`
+	SellerID uuid.UUID       `gorm:"type:uuid;primaryKey;uniqueIndex:seller_wallet_currency"`
+	Currency string          `gorm:"size:3;default:THB;not null;uniqueIndex:seller_wallet_currency"`
+	// A frozen wallet cannot be paid out until an admin reviews it
+	Frozen       bool `gorm:"default:false;not null"`
+	FrozenReason string
 }
 
 type SellerProfile struct {
@@ -180,6 +235,22 @@ type SellerProfile struct {
 	FirstName string
 	LastName  string
 	SellerID  uuid.UUID `gorm:"type:uuid;primaryKey"`
+	// Storage keys of the uploaded images, empty when none
+	Logo   string
+	Banner string
+	// Storefront fields shown to buyers. DisplayName falls back to the
+	// first and last name, Slug is unique across sellers
+	DisplayName string
+	Bio         string
+	Slug        string
+	// Verified is the public badge, set while VerificationStatus is approved
+	Verified           bool   `gorm:"default:false;not null"`
+	VerificationStatus string `gorm:"default:unverified;not null"`
+	// Country is the ISO 3166-1 alpha-2 code the seller operates from
+	Country string `gorm:"size:2"`
+	// SearchVector is maintained by Postgres for the seller directory, it is
+	// never read or written by the service
+	SearchVector string `gorm:"type:tsvector GENERATED ALWAYS AS (setweight(to_tsvector('simple', coalesce(display_name, '') || ' ' || coalesce(first_name, '') || ' ' || coalesce(last_name, '')), 'A') || setweight(to_tsvector('simple', coalesce(bio, '')), 'B')) STORED;index:,type:gin;->:false;<-:false"`
 }
 
 func (u *Seller) RetrieveByUserID(c context.Context, userID uuid.UUID) error {
@@ -193,7 +264,13 @@ func (u *Seller) RetrieveByUserIDWithProfile(c context.Context, userID uuid.UUID
 	if err := db.GetDB(c).
 		Where("id = ?", userID).
 		Preload("SellerProfile").
-		Preload("SellerWallet").
+		Preload("SocialLinks", func(tx *gorm.DB) *gorm.DB {
+			return tx.Order("id")
+		}).
+		Preload("SellerWallet", "currency = ?", enums.DefaultCurrency).
+		Preload("Wallets", func(tx *gorm.DB) *gorm.DB {
+			return tx.Order("id")
+		}).
 		First(u).
 		Error; err != nil {
 		return err
@@ -214,13 +291,15 @@ func (u *Seller) IsUsernameExist(c context.Context, username string) (bool, erro
 	return userExists, nil
 }
 
-func (u *Seller) CreateAccount(c context.Context, registerForm forms.UserSignUp) (*Seller, error) {
+// CreateAccount registers the seller with a referral code of their own, and
+// records the referral when the form carries the code of another user
+func (u *Seller) CreateAccount(c context.Context, registerForm forms.UserSignUp, signal SignupSignal) (*Seller, error) {
 	userExists, userResultError := u.IsUsernameExist(c, registerForm.Username)
 	if userResultError != nil {
 		return &Seller{}, userResultError
 	}
 	if userExists {
-		return &Seller{}, errors.New("user already exists")
+		return &Seller{}, ErrUserExists
 	}
 
 	var profile = SellerProfile{
@@ -232,21 +311,58 @@ func (u *Seller) CreateAccount(c context.Context, registerForm forms.UserSignUp)
 		Password:      registerForm.Password,
 		SellerProfile: profile,
 		SellerWallet: SellerWallet{
-			Balance: decimal.NewFromInt(0),
+			Balance:  decimal.NewFromInt(0),
+			Currency: enums.DefaultCurrency,
 		},
 	}
 
-	if err := db.GetDB(c).Create(&user).Error; err != nil {
+	err := db.GetDB(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		code, err := createReferralCode(tx, user.ID, enums.Seller, user.Username, signal)
+		if err != nil {
+			return err
+		}
+		user.ReferralCode = code
+		if err := tx.Model(&user).Update("ReferralCode", code).Error; err != nil {
+			return err
+		}
+		if registerForm.ReferralCode == "" {
+			return nil
+		}
+		return applyReferral(tx, registerForm.ReferralCode, user.ID, enums.Seller, user.Username, signal)
+	})
+	if err != nil {
 		return &Seller{}, err
 	}
 	return &user, nil
 }
 
+// EnsureReferralCode gives accounts opened before the referral program a code
+func (u *Seller) EnsureReferralCode(c context.Context) error {
+	if u.ReferralCode != "" {
+		return nil
+	}
+	code, err := ensureReferralCode(c, u.ID, enums.Seller, u.Username)
+	if err != nil {
+		return err
+	}
+	u.ReferralCode = code
+	return db.GetDB(c).Model(u).Update("ReferralCode", code).Error
+}
+
 func (u *Seller) Login(c context.Context, form forms.UserSignIn) (bool, error) {
 	if err := db.GetDB(c).
 		Where("username = ?", form.Username).
 		Preload("SellerProfile").
-		Preload("SellerWallet").
+		Preload("SocialLinks", func(tx *gorm.DB) *gorm.DB {
+			return tx.Order("id")
+		}).
+		Preload("SellerWallet", "currency = ?", enums.DefaultCurrency).
+		Preload("Wallets", func(tx *gorm.DB) *gorm.DB {
+			return tx.Order("id")
+		}).
 		First(u).Error; err != nil {
 		return false, err
 	}
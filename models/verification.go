@@ -0,0 +1,292 @@
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"user-service/db"
+	"user-service/enums"
+	"user-service/errs"
+	"user-service/i18n"
+	"user-service/storage"
+)
+
+const defaultDocumentMaxBytes = 10 << 20
+
+var (
+	ErrVerificationPending        = errs.New(errs.ErrConflict, i18n.CodeVerificationPending, "a verification is already under review")
+	ErrAlreadyVerified            = errs.New(errs.ErrConflict, i18n.CodeAlreadyVerified, "seller is already verified")
+	ErrVerificationStatusConflict = errs.New(errs.ErrConflict, i18n.CodeVerificationStatusConflict, "verification is not pending review")
+	ErrSellerNotVerified          = errs.New(errs.ErrForbidden, i18n.CodeSellerNotVerified, "seller must be verified before requesting payouts")
+	ErrMissingDocument            = errs.New(errs.ErrValidation, i18n.CodeMissingDocument, "a business registration document is required")
+	ErrInvalidDocument            = errs.New(errs.ErrUnsupportedMedia, i18n.CodeInvalidDocument, "documents must be PDF, JPEG or PNG files")
+	ErrDocumentTooLarge           = errs.New(errs.ErrTooLarge, i18n.CodeDocumentTooLarge, "document file is too large")
+)
+
+// documentExtensions are the sniffed content types accepted as documents.
+// Documents are stored as uploaded, they are evidence and must not be altered
+var documentExtensions = map[string]string{
+	"application/pdf": "pdf",
+	"image/jpeg":      "jpg",
+	"image/png":       "png",
+}
+
+// SellerVerification is one submission of the seller business details, a
+// rejected seller submits a new one
+type SellerVerification struct {
+	gorm.Model
+	SellerID                   uuid.UUID `gorm:"type:uuid;primaryKey"`
+	LegalName                  string
+	BusinessRegistrationNumber string
+	TaxID                      string
+	Status                     string `gorm:"index"`
+	RejectionReason            string
+	ReviewedAt                 *time.Time
+	Documents                  []SellerVerificationDocument `gorm:"-"`
+}
+
+type SellerVerificationDocument struct {
+	gorm.Model
+	SellerID       uuid.UUID `gorm:"type:uuid;primaryKey"`
+	VerificationID uint      `gorm:"index"`
+	Type           string
+	Filename       string
+	ContentType    string
+	Size           int
+	// Key is the object key in the document store
+	Key string
+}
+
+// VerificationSubmission is what a seller sends to get verified
+type VerificationSubmission struct {
+	LegalName                  string
+	BusinessRegistrationNumber string
+	TaxID                      string
+	Documents                  []VerificationUpload
+}
+
+type VerificationUpload struct {
+	Type     string
+	Filename string
+	Data     []byte
+}
+
+// DocumentMaxBytes is the largest accepted document, DOCUMENT_MAX_BYTES or
+// 10 MiB
+func DocumentMaxBytes() int64 {
+	return int64(intFromEnv("DOCUMENT_MAX_BYTES", defaultDocumentMaxBytes))
+}
+
+// SubmitVerification stores the documents and puts the seller under review.
+// Sellers that are verified or already under review cannot submit again
+func (u *Seller) SubmitVerification(c context.Context, submission VerificationSubmission) (*SellerVerification, error) {
+	hasRegistration := false
+	for _, upload := range submission.Documents {
+		if upload.Type == enums.DocumentBusinessRegistration {
+			hasRegistration = true
+		}
+		if int64(len(upload.Data)) > DocumentMaxBytes() {
+			return nil, ErrDocumentTooLarge
+		}
+		if _, ok := documentExtensions[http.DetectContentType(upload.Data)]; !ok {
+			return nil, ErrInvalidDocument
+		}
+	}
+	if !hasRegistration {
+		return nil, ErrMissingDocument
+	}
+
+	verification := SellerVerification{
+		SellerID:                   u.ID,
+		LegalName:                  strings.TrimSpace(submission.LegalName),
+		BusinessRegistrationNumber: strings.TrimSpace(submission.BusinessRegistrationNumber),
+		TaxID:                      strings.TrimSpace(submission.TaxID),
+		Status:                     enums.VerificationPending,
+	}
+	for _, upload := range submission.Documents {
+		document, err := storeDocument(c, u.ID, upload)
+		if err != nil {
+			deleteDocuments(c, verification.Documents)
+			return nil, err
+		}
+		verification.Documents = append(verification.Documents, document)
+	}
+
+	err := db.GetDB(c).Transaction(func(tx *gorm.DB) error {
+		var profile SellerProfile
+		if err := tx.Where("seller_id = ?", u.ID).First(&profile).Error; err != nil {
+			return err
+		}
+		switch profile.VerificationStatus {
+		case enums.VerificationPending:
+			return ErrVerificationPending
+		case enums.VerificationApproved:
+			return ErrAlreadyVerified
+		}
+		if err := setVerificationStatus(tx, u.ID, profile.VerificationStatus, enums.VerificationPending); err != nil {
+			return err
+		}
+		if err := tx.Create(&verification).Error; err != nil {
+			return err
+		}
+		for i := range verification.Documents {
+			verification.Documents[i].VerificationID = verification.ID
+			if err := tx.Create(&verification.Documents[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		deleteDocuments(c, verification.Documents)
+		return nil, err
+	}
+	return &verification, nil
+}
+
+func storeDocument(c context.Context, sellerID uuid.UUID, upload VerificationUpload) (SellerVerificationDocument, error) {
+	contentType := http.DetectContentType(upload.Data)
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		return SellerVerificationDocument{}, err
+	}
+	document := SellerVerificationDocument{
+		SellerID:    sellerID,
+		Type:        upload.Type,
+		Filename:    upload.Filename,
+		ContentType: contentType,
+		Size:        len(upload.Data),
+		Key:         "verifications/" + sellerID.String() + "/" + hex.EncodeToString(id) + "." + documentExtensions[contentType],
+	}
+	if err := storage.GetDocumentStore().Put(c, document.Key, contentType, upload.Data); err != nil {
+		return SellerVerificationDocument{}, err
+	}
+	return document, nil
+}
+
+// deleteDocuments removes stored files of a submission that did not go
+// through, failures are only logged
+func deleteDocuments(c context.Context, documents []SellerVerificationDocument) {
+	for _, document := range documents {
+		if err := storage.GetDocumentStore().Delete(c, document.Key); err != nil {
+			log.Printf("failed to delete document %s: %v", document.Key, err)
+		}
+	}
+}
+
+// setVerificationStatus moves the seller from one status to the next and
+// keeps the public badge in line with it
+func setVerificationStatus(tx *gorm.DB, sellerID uuid.UUID, from string, to string) error {
+	result := tx.
+		Model(&SellerProfile{}).
+		Where("seller_id = ? AND verification_status = ?", sellerID, from).
+		Updates(map[string]interface{}{
+			"verification_status": to,
+			"verified":            to == enums.VerificationApproved,
+			"updated_at":          time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrVerificationStatusConflict
+	}
+	return nil
+}
+
+// RetrieveLatest loads the last submission of a seller with its documents
+func (v *SellerVerification) RetrieveLatest(c context.Context, sellerID uuid.UUID) error {
+	if err := db.GetDB(c).
+		Where("seller_id = ? AND deleted_at IS NULL", sellerID).
+		Order("id DESC").
+		First(v).Error; err != nil {
+		return err
+	}
+	return db.GetDB(c).
+		Where("seller_id = ? AND verification_id = ? AND deleted_at IS NULL", sellerID, v.ID).
+		Order("id").
+		Find(&v.Documents).Error
+}
+
+// Document loads one document of the submission and its content
+func (v *SellerVerification) Document(c context.Context, id uint) (*SellerVerificationDocument, []byte, error) {
+	var document SellerVerificationDocument
+	if err := db.GetDB(c).
+		Where("id = ? AND seller_id = ? AND verification_id = ? AND deleted_at IS NULL", id, v.SellerID, v.ID).
+		First(&document).Error; err != nil {
+		return nil, nil, err
+	}
+	data, err := storage.GetDocumentStore().Get(c, document.Key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &document, data, nil
+}
+
+// ListVerificationsByStatus lists submissions oldest first so reviewers work
+// through the queue in order
+func ListVerificationsByStatus(c context.Context, status string) ([]SellerVerification, error) {
+	var verifications []SellerVerification
+	if err := db.GetDB(c).
+		Where("status = ? AND deleted_at IS NULL", status).
+		Order("created_at").
+		Find(&verifications).Error; err != nil {
+		return nil, err
+	}
+	return verifications, nil
+}
+
+// Approve verifies the seller, which shows the badge and allows payouts
+func (v *SellerVerification) Approve(c context.Context) error {
+	return v.review(c, enums.VerificationApproved, "")
+}
+
+// Reject sends the seller back to submit again, the reason is shown to them
+func (v *SellerVerification) Reject(c context.Context, reason string) error {
+	return v.review(c, enums.VerificationRejected, reason)
+}
+
+func (v *SellerVerification) review(c context.Context, status string, reason string) error {
+	now := time.Now()
+	return db.GetDB(c).Transaction(func(tx *gorm.DB) error {
+		result := tx.
+			Model(&SellerVerification{}).
+			Where("id = ? AND seller_id = ? AND status = ?", v.ID, v.SellerID, enums.VerificationPending).
+			Updates(map[string]interface{}{
+				"status":           status,
+				"rejection_reason": reason,
+				"reviewed_at":      now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrVerificationStatusConflict
+		}
+		if err := setVerificationStatus(tx, v.SellerID, enums.VerificationPending, status); err != nil {
+			return err
+		}
+		v.Status = status
+		v.RejectionReason = reason
+		v.ReviewedAt = &now
+		return nil
+	})
+}
+
+// requireVerified fails unless the seller verification was approved
+func requireVerified(c context.Context, sellerID uuid.UUID) error {
+	var profile SellerProfile
+	if err := db.GetDB(c).Where("seller_id = ?", sellerID).First(&profile).Error; err != nil {
+		return err
+	}
+	if profile.VerificationStatus != enums.VerificationApproved {
+		return ErrSellerNotVerified
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package models
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"user-service/db"
+	"user-service/errs"
+	"user-service/i18n"
+)
+
+var (
+	ErrInsufficientFunds = errs.New(errs.ErrPaymentRequired, i18n.CodeInsufficientFunds, "insufficient funds")
+	ErrInvalidCurrency   = errs.New(errs.ErrValidation, i18n.CodeInvalidCurrency, "unsupported currency")
+	ErrWalletExists      = errs.New(errs.ErrConflict, i18n.CodeWalletExists, "a wallet in this currency already exists")
+)
+
+// Debit charges the buyer wallet for a purchase identified by orderReference,
+// promo credit is spent before cash. The wallet row is locked for the duration
+// of the transaction so concurrent debits cannot overdraw it
+func (u *Buyer) Debit(c context.Context, currency string, amount decimal.Decimal, orderReference string) (*BuyerWalletTransaction, error) {
+	if err := ValidateAmount(amount); err != nil {
+		return nil, err
+	}
+	var transaction *BuyerWalletTransaction
+	err := db.GetDB(c).Transaction(func(tx *gorm.DB) error {
+		wallet, err := lockBuyerWallet(tx, u.ID, currency)
+		if err != nil {
+			return err
+		}
+		transaction, err = spendBuyerWallet(tx, wallet, amount, orderReference, false)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+// OpenWallet creates an empty wallet in currency, a buyer has at most one
+// wallet per currency
+func (u *Buyer) OpenWallet(c context.Context, currency string) (*BuyerWallet, error) {
+	if !IsSupportedCurrency(c, currency) {
+		return nil, ErrInvalidCurrency
+	}
+	wallet := BuyerWallet{BuyerID: u.ID, Currency: currency, Balance: decimal.Zero}
+	result := db.GetDB(c).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "buyer_id"}, {Name: "currency"}},
+			DoNothing: true,
+		}).
+		Create(&wallet)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrWalletExists
+	}
+	return &wallet, nil
+}
+
+// OpenWallet creates an empty wallet in currency, a seller has at most one
+// wallet per currency
+func (u *Seller) OpenWallet(c context.Context, currency string) (*SellerWallet, error) {
+	if !IsSupportedCurrency(c, currency) {
+		return nil, ErrInvalidCurrency
+	}
+	wallet := SellerWallet{SellerID: u.ID, Currency: currency, Balance: decimal.Zero}
+	result := db.GetDB(c).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "seller_id"}, {Name: "currency"}},
+			DoNothing: true,
+		}).
+		Create(&wallet)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrWalletExists
+	}
+	return &wallet, nil
+}
+
+// lockBuyerWallet loads the buyer wallet in currency with a row lock held until
+// tx ends. When both wallets are needed lock the buyer wallet first to keep a
+// consistent lock order and avoid deadlocks
+func lockBuyerWallet(tx *gorm.DB, buyerID uuid.UUID, currency string) (BuyerWallet, error) {
+	wallet := BuyerWallet{}
+	err := tx.
+		Model(&wallet).
+		Where("buyer_id = ? AND currency = ?", buyerID, currency).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&wallet).Error
+	return wallet, err
+}
+
+// lockBuyerWallets locks every wallet of the buyer in id order, for checks
+// that span currencies. It fails with gorm.ErrRecordNotFound when the buyer
+// has no wallet
+func lockBuyerWallets(tx *gorm.DB, buyerID uuid.UUID) ([]BuyerWallet, error) {
+	var wallets []BuyerWallet
+	if err := tx.
+		Where("buyer_id = ?", buyerID).
+		Order("id").
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Find(&wallets).Error; err != nil {
+		return nil, err
+	}
+	if len(wallets) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return wallets, nil
+}
+
+func lockSellerWallet(tx *gorm.DB, sellerID uuid.UUID, currency string) (SellerWallet, error) {
+	wallet := SellerWallet{}
+	err := tx.
+		Model(&wallet).
+		Where("seller_id = ? AND currency = ?", sellerID, currency).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&wallet).Error
+	return wallet, err
+}
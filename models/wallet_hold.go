@@ -0,0 +1,193 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"log"
+	"os"
+	"time"
+	"user-service/db"
+	"user-service/enums"
+	"user-service/errs"
+	"user-service/i18n"
+)
+
+const defaultHoldTTL = time.Hour * 24 * 7
+
+var (
+	ErrHoldNotAuthorized = errs.New(errs.ErrConflict, i18n.CodeHoldNotAuthorized, "hold is not in authorized state")
+	ErrHoldExpired       = errs.New(errs.ErrConflict, i18n.CodeHoldExpired, "hold has expired")
+)
+
+// BuyerWalletHold reserves part of a buyer balance for an order until it is
+// captured to the seller, voided or expires
+type BuyerWalletHold struct {
+	gorm.Model
+	BuyerID   uuid.UUID       `gorm:"type:uuid;primaryKey"`
+	SellerID  uuid.UUID       `gorm:"type:uuid"`
+	Currency  string          `gorm:"size:3;default:THB;not null"`
+	Amount    decimal.Decimal `gorm:"type:decimal(12,2);"`
+	Fee       decimal.Decimal `gorm:"type:decimal(12,2);default:0;not null"`
+	Status    string
+	Category  string
+	Reference string
+	ExpiresAt time.Time `gorm:"index"`
+}
+
+func HoldTTL() time.Duration {
+	ttl, err := time.ParseDuration(os.Getenv("WALLET_HOLD_TTL"))
+	if err != nil || ttl <= 0 {
+		return defaultHoldTTL
+	}
+	return ttl
+}
+
+// AuthorizeHold reserves amount of the wallet in currency in its held balance.
+// Capture spends promo credit first, so unheld promo credit counts toward the
+// funds like cash does
+func (u *Buyer) AuthorizeHold(c context.Context, currency string, sellerID uuid.UUID, amount decimal.Decimal, category string, reference string, ttl time.Duration) (*BuyerWalletHold, error) {
+	if err := ValidateAmount(amount); err != nil {
+		return nil, err
+	}
+	hold := BuyerWalletHold{
+		BuyerID:   u.ID,
+		SellerID:  sellerID,
+		Currency:  currency,
+		Amount:    amount,
+		Status:    enums.HoldAuthorized,
+		Category:  category,
+		Reference: reference,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	err := db.GetDB(c).Transaction(func(tx *gorm.DB) error {
+		wallet, err := lockBuyerWallet(tx, u.ID, currency)
+		if err != nil {
+			return err
+		}
+		if wallet.Frozen {
+			return ErrWalletFrozen
+		}
+		if wallet.SpendableBalance().LessThan(amount) {
+			return ErrInsufficientFunds
+		}
+		if err := tx.
+			Model(&wallet).
+			Where("buyer_id = ?", u.ID).
+			Update("HeldBalance", wallet.HeldBalance.Add(amount)).Error; err != nil {
+			return err
+		}
+		return tx.Create(&hold).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &hold, nil
+}
+
+func (h *BuyerWalletHold) RetrieveByID(c context.Context, id uint) error {
+	return db.GetDB(c).Where("id = ?", id).First(h).Error
+}
+
+// lock reloads the hold with a row lock, the buyer wallet must already be
+// locked by the caller
+func (h *BuyerWalletHold) lock(tx *gorm.DB) error {
+	return tx.
+		Where("id = ? AND buyer_id = ?", h.ID, h.BuyerID).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(h).Error
+}
+
+// Capture settles the held amount from the buyer to the seller, minus the
+// platform commission
+func (h *BuyerWalletHold) Capture(c context.Context) error {
+	return db.GetDB(c).Transaction(func(tx *gorm.DB) error {
+		buyerWallet, err := lockBuyerWallet(tx, h.BuyerID, h.Currency)
+		if err != nil {
+			return err
+		}
+		if err := h.lock(tx); err != nil {
+			return err
+		}
+		if h.Status != enums.HoldAuthorized {
+			return ErrHoldNotAuthorized
+		}
+		if h.ExpiresAt.Before(time.Now()) {
+			return ErrHoldExpired
+		}
+
+		settlement := Settlement{
+			BuyerID:   h.BuyerID,
+			SellerID:  h.SellerID,
+			HoldID:    &h.ID,
+			Amount:    h.Amount,
+			Category:  h.Category,
+			Reference: h.Reference,
+		}
+		if err := settlement.apply(tx, buyerWallet, true); err != nil {
+			return err
+		}
+
+		h.Status = enums.HoldCaptured
+		h.Fee = settlement.Commission
+		return tx.
+			Model(h).
+			Where("buyer_id = ?", h.BuyerID).
+			Updates(map[string]interface{}{"status": h.Status, "fee": h.Fee}).Error
+	})
+}
+
+// Void releases the held amount back to the available balance
+func (h *BuyerWalletHold) Void(c context.Context) error {
+	return h.release(c, enums.HoldVoided)
+}
+
+func (h *BuyerWalletHold) release(c context.Context, status string) error {
+	return db.GetDB(c).Transaction(func(tx *gorm.DB) error {
+		buyerWallet, err := lockBuyerWallet(tx, h.BuyerID, h.Currency)
+		if err != nil {
+			return err
+		}
+		if err := h.lock(tx); err != nil {
+			return err
+		}
+		if h.Status != enums.HoldAuthorized {
+			return ErrHoldNotAuthorized
+		}
+		if err := tx.
+			Model(&buyerWallet).
+			Where("buyer_id = ?", h.BuyerID).
+			Update("HeldBalance", buyerWallet.HeldBalance.Sub(h.Amount)).Error; err != nil {
+			return err
+		}
+		h.Status = status
+		return tx.
+			Model(h).
+			Where("buyer_id = ?", h.BuyerID).
+			Update("Status", h.Status).Error
+	})
+}
+
+// ReleaseExpiredHolds returns expired holds to their buyers' available balance
+func ReleaseExpiredHolds(c context.Context) error {
+	var holds []BuyerWalletHold
+	if err := db.GetDB(c).
+		Where("status = ? AND expires_at < ?", enums.HoldAuthorized, time.Now()).
+		Find(&holds).Error; err != nil {
+		return err
+	}
+	for i := range holds {
+		err := holds[i].release(c, enums.HoldExpired)
+		// Another worker may have captured or released it in the meantime
+		if err != nil && !errors.Is(err, ErrHoldNotAuthorized) {
+			return err
+		}
+	}
+	if len(holds) > 0 {
+		log.Printf("released %d expired wallet holds", len(holds))
+	}
+	return nil
+}
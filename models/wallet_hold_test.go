@@ -0,0 +1,67 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"github.com/shopspring/decimal"
+	"testing"
+	"time"
+	"user-service/enums"
+)
+
+// Holds reserve promo credit like cash, capture spends it first
+func TestAuthorizeHoldCountsPromoCredit(t *testing.T) {
+	requireDB(t)
+	tests := []struct {
+		name   string
+		cash   string
+		promo  string
+		held   []string
+		amount string
+		want   error
+	}{
+		{"promo credit alone covers the hold", "0", "100.00", nil, "100.00", nil},
+		{"cash and promo credit together", "30.00", "70.00", nil, "100.00", nil},
+		{"more than cash and promo credit", "30.00", "70.00", nil, "100.01", ErrInsufficientFunds},
+		{"promo credit already held", "0", "100.00", []string{"60.00"}, "50.00", ErrInsufficientFunds},
+		{"what is left after an earlier hold", "0", "100.00", []string{"60.00"}, "40.00", nil},
+	}
+	c := context.Background()
+	seller, _ := newTestSeller(t, "0")
+	for _, test := range tests {
+		buyer := newTestBuyer(t, test.cash)
+		if _, err := buyer.GrantPromoCredit(c, enums.DefaultCurrency, decimal.RequireFromString(test.promo), "test", time.Now().Add(time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+		for _, held := range test.held {
+			if _, err := buyer.AuthorizeHold(c, enums.DefaultCurrency, seller.ID, decimal.RequireFromString(held), "", "order", time.Hour); err != nil {
+				t.Fatalf("%s: earlier hold: %v", test.name, err)
+			}
+		}
+		_, err := buyer.AuthorizeHold(c, enums.DefaultCurrency, seller.ID, decimal.RequireFromString(test.amount), "", "order", time.Hour)
+		if !errors.Is(err, test.want) {
+			t.Errorf("%s: AuthorizeHold = %v, want %v", test.name, err, test.want)
+		}
+	}
+}
+
+func TestCaptureSpendsPromoCreditHeld(t *testing.T) {
+	requireDB(t)
+	c := context.Background()
+	seller, _ := newTestSeller(t, "0")
+	buyer := newTestBuyer(t, "20.00")
+	if _, err := buyer.GrantPromoCredit(c, enums.DefaultCurrency, decimal.RequireFromString("80.00"), "test", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	hold, err := buyer.AuthorizeHold(c, enums.DefaultCurrency, seller.ID, decimal.RequireFromString("100.00"), "", "order", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := hold.Capture(c); err != nil {
+		t.Fatal(err)
+	}
+	wallet := testBuyerWallet(t, buyer.ID)
+	if !wallet.Balance.IsZero() || !wallet.PromoBalance.IsZero() || !wallet.HeldBalance.IsZero() {
+		t.Errorf("wallet after capture = cash %s promo %s held %s, want all zero", wallet.Balance, wallet.PromoBalance, wallet.HeldBalance)
+	}
+}
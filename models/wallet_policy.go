@@ -0,0 +1,277 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"os"
+	"time"
+	"user-service/enums"
+	"user-service/rates"
+)
+
+// maxStorableAmount is the largest value of a decimal(12,2) column
+var maxStorableAmount = decimal.RequireFromString("9999999999.99")
+
+// PolicyViolation is returned when a wallet operation breaks the wallet
+// policy. Code is stable and meant for clients, Message for humans
+type PolicyViolation struct {
+	Code    string
+	Message string
+	Limit   decimal.Decimal
+}
+
+func (e *PolicyViolation) Error() string {
+	return e.Message
+}
+
+type WalletPolicy struct {
+	MinTopup          decimal.Decimal
+	MaxTopup          decimal.Decimal
+	DailyTopupLimit   decimal.Decimal
+	MonthlyTopupLimit decimal.Decimal
+	MaxBalance        decimal.Decimal
+}
+
+// LoadWalletPolicy reads the limits, they are expressed in the default currency
+func LoadWalletPolicy() WalletPolicy {
+	return WalletPolicy{
+		MinTopup:          decimalFromEnv("WALLET_TOPUP_MIN", decimal.NewFromInt(1)),
+		MaxTopup:          decimalFromEnv("WALLET_TOPUP_MAX", decimal.NewFromInt(50000)),
+		DailyTopupLimit:   decimalFromEnv("WALLET_TOPUP_DAILY_LIMIT", decimal.NewFromInt(100000)),
+		MonthlyTopupLimit: decimalFromEnv("WALLET_TOPUP_MONTHLY_LIMIT", decimal.NewFromInt(500000)),
+		MaxBalance:        decimalFromEnv("WALLET_MAX_BALANCE", decimal.NewFromInt(1000000)),
+	}
+}
+
+// convertTo expresses the limits in another currency so a wallet in that
+// currency gets limits of the same value
+func (p WalletPolicy) convertTo(c context.Context, currency string) (WalletPolicy, error) {
+	rate, err := defaultCurrencyRate(c, currency)
+	if err != nil {
+		return p, err
+	}
+	return WalletPolicy{
+		MinTopup:          p.MinTopup.Mul(rate).Round(2),
+		MaxTopup:          p.MaxTopup.Mul(rate).Round(2),
+		DailyTopupLimit:   p.DailyTopupLimit.Mul(rate).Round(2),
+		MonthlyTopupLimit: p.MonthlyTopupLimit.Mul(rate).Round(2),
+		MaxBalance:        p.MaxBalance.Mul(rate).Round(2),
+	}, nil
+}
+
+// defaultCurrencyRate is the value of one unit of the default currency in
+// currency, used to convert amounts configured in the default currency
+func defaultCurrencyRate(c context.Context, currency string) (decimal.Decimal, error) {
+	if currency == enums.DefaultCurrency {
+		return decimal.NewFromInt(1), nil
+	}
+	quote, err := rates.GetSource().Rate(c, enums.DefaultCurrency, currency)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return quote.Rate, nil
+}
+
+func decimalFromEnv(key string, fallback decimal.Decimal) decimal.Decimal {
+	value, err := decimal.NewFromString(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// ValidateAmount checks the amount can be stored as money
+func ValidateAmount(amount decimal.Decimal) error {
+	if !amount.IsPositive() {
+		return &PolicyViolation{Code: enums.PolicyAmountNotPositive, Message: "amount must be greater than zero"}
+	}
+	if !amount.Equal(amount.Round(2)) {
+		return &PolicyViolation{Code: enums.PolicyAmountPrecision, Message: "amount must have at most 2 decimal places"}
+	}
+	if amount.GreaterThan(maxStorableAmount) {
+		return &PolicyViolation{Code: enums.PolicyAmountOverflow, Message: "amount is too large", Limit: maxStorableAmount}
+	}
+	return nil
+}
+
+// ValidateTopup checks the amount on its own, before any database access
+func (p WalletPolicy) ValidateTopup(amount decimal.Decimal) error {
+	if err := ValidateAmount(amount); err != nil {
+		return err
+	}
+	if amount.LessThan(p.MinTopup) {
+		return &PolicyViolation{
+			Code:    enums.PolicyTopupBelowMinimum,
+			Message: fmt.Sprintf("top-up must be at least %s", p.MinTopup.StringFixed(2)),
+			Limit:   p.MinTopup,
+		}
+	}
+	if amount.GreaterThan(p.MaxTopup) {
+		return &PolicyViolation{
+			Code:    enums.PolicyTopupAboveMaximum,
+			Message: fmt.Sprintf("top-up must be at most %s", p.MaxTopup.StringFixed(2)),
+			Limit:   p.MaxTopup,
+		}
+	}
+	return nil
+}
+
+// checkTopupLimits enforces the running limits across every wallet of the
+// buyer, amounts in other currencies count at their value in the default
+// currency. It must be called with the wallets locked so concurrent top-ups
+// are counted. Intents that may still be paid count as if they were,
+// otherwise opening several at once would get around the limits
+func (p WalletPolicy) checkTopupLimits(c context.Context, tx *gorm.DB, wallets []BuyerWallet, currency string, amount decimal.Decimal) error {
+	var buyerID uuid.UUID
+	hasWallet := false
+	balances := map[string]decimal.Decimal{}
+	for _, wallet := range wallets {
+		buyerID = wallet.BuyerID
+		balances[wallet.Currency] = wallet.Balance
+		hasWallet = hasWallet || wallet.Currency == currency
+	}
+	if !hasWallet {
+		return gorm.ErrRecordNotFound
+	}
+	// Violations are reported in the currency of the top-up
+	localPolicy, err := p.convertTo(c, currency)
+	if err != nil {
+		return err
+	}
+	requested, err := toDefaultCurrency(c, map[string]decimal.Decimal{currency: amount})
+	if err != nil {
+		return err
+	}
+
+	// Balances plus what pending intents will add once paid
+	holdings, err := sumOpenTopups(tx, buyerID, time.Time{}, enums.TopupPending)
+	if err != nil {
+		return err
+	}
+	for walletCurrency, balance := range balances {
+		holdings[walletCurrency] = holdings[walletCurrency].Add(balance)
+	}
+	balance, err := toDefaultCurrency(c, holdings)
+	if err != nil {
+		return err
+	}
+	if balance.Add(requested).GreaterThan(p.MaxBalance) {
+		return &PolicyViolation{
+			Code:    enums.PolicyBalanceLimitExceeded,
+			Message: fmt.Sprintf("wallet balance cannot exceed %s", localPolicy.MaxBalance.StringFixed(2)),
+			Limit:   localPolicy.MaxBalance,
+		}
+	}
+
+	now := time.Now().In(walletLocation())
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	startOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+
+	monthly, err := sumTopupsSince(c, tx, buyerID, startOfMonth)
+	if err != nil {
+		return err
+	}
+	if monthly.Add(requested).GreaterThan(p.MonthlyTopupLimit) {
+		return &PolicyViolation{
+			Code:    enums.PolicyMonthlyLimitExceeded,
+			Message: fmt.Sprintf("monthly top-up limit of %s reached", localPolicy.MonthlyTopupLimit.StringFixed(2)),
+			Limit:   localPolicy.MonthlyTopupLimit,
+		}
+	}
+	daily, err := sumTopupsSince(c, tx, buyerID, startOfDay)
+	if err != nil {
+		return err
+	}
+	if daily.Add(requested).GreaterThan(p.DailyTopupLimit) {
+		return &PolicyViolation{
+			Code:    enums.PolicyDailyLimitExceeded,
+			Message: fmt.Sprintf("daily top-up limit of %s reached", localPolicy.DailyTopupLimit.StringFixed(2)),
+			Limit:   localPolicy.DailyTopupLimit,
+		}
+	}
+	return nil
+}
+
+// sumTopupsSince adds up the credited top-ups and the intents still
+// outstanding of every wallet of the buyer, in the default currency
+func sumTopupsSince(c context.Context, tx *gorm.DB, buyerID uuid.UUID, since time.Time) (decimal.Decimal, error) {
+	var rows []currencyTotal
+	if err := tx.
+		Model(&BuyerWalletTransaction{}).
+		Select("currency, SUM(amount) AS total").
+		Where("buyer_id = ? AND type = ? AND created_at >= ?", buyerID, enums.TransactionTopup, since).
+		Group("currency").
+		Scan(&rows).Error; err != nil {
+		return decimal.Zero, err
+	}
+	// Expired intents count too, a late payment is still credited
+	totals, err := sumOpenTopups(tx, buyerID, since, enums.TopupPending, enums.TopupExpired)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	for _, row := range rows {
+		totals[row.Currency] = totals[row.Currency].Add(row.Total)
+	}
+	return toDefaultCurrency(c, totals)
+}
+
+// sumOpenTopups adds up by currency the intents opened since that have one
+// of statuses
+func sumOpenTopups(tx *gorm.DB, buyerID uuid.UUID, since time.Time, statuses ...string) (map[string]decimal.Decimal, error) {
+	var rows []currencyTotal
+	if err := tx.
+		Model(&TopupIntent{}).
+		Select("currency, SUM(amount) AS total").
+		Where("buyer_id = ? AND status IN ? AND created_at >= ?", buyerID, statuses, since).
+		Group("currency").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	totals := map[string]decimal.Decimal{}
+	for _, row := range rows {
+		totals[row.Currency] = row.Total
+	}
+	return totals, nil
+}
+
+type currencyTotal struct {
+	Currency string
+	Total    decimal.Decimal
+}
+
+// toDefaultCurrency adds up amounts given by currency at their value in the
+// default currency
+func toDefaultCurrency(c context.Context, amounts map[string]decimal.Decimal) (decimal.Decimal, error) {
+	total := decimal.Zero
+	for currency, amount := range amounts {
+		rate, err := defaultCurrencyRate(c, currency)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		total = total.Add(amount.DivRound(rate, 2))
+	}
+	return total, nil
+}
+
+// IsSupportedCurrency reports whether a wallet may hold currency, it has to
+// be a known code with an exchange rate to the default currency
+func IsSupportedCurrency(c context.Context, currency string) bool {
+	if !enums.IsValidCurrency(currency) {
+		return false
+	}
+	_, err := defaultCurrencyRate(c, currency)
+	return err == nil
+}
+
+// walletLocation is the timezone daily and monthly limits reset in, the same
+// one the database connection uses
+func walletLocation() *time.Location {
+	location, err := time.LoadLocation("Asia/Bangkok")
+	if err != nil {
+		return time.FixedZone("Asia/Bangkok", 7*60*60)
+	}
+	return location
+}
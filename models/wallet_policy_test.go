@@ -0,0 +1,92 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"github.com/shopspring/decimal"
+	"testing"
+	"user-service/enums"
+)
+
+func TestValidateAmount(t *testing.T) {
+	tests := []struct {
+		amount string
+		want   string
+	}{
+		{"0.01", ""},
+		{"9999999999.99", ""},
+		{"0", enums.PolicyAmountNotPositive},
+		{"-5", enums.PolicyAmountNotPositive},
+		{"0.005", enums.PolicyAmountPrecision},
+		{"10.125", enums.PolicyAmountPrecision},
+		{"10000000000", enums.PolicyAmountOverflow},
+	}
+	for _, test := range tests {
+		err := ValidateAmount(decimal.RequireFromString(test.amount))
+		var violation *PolicyViolation
+		switch {
+		case test.want == "" && err != nil:
+			t.Errorf("ValidateAmount(%s) = %v, want nil", test.amount, err)
+		case test.want != "" && (!errors.As(err, &violation) || violation.Code != test.want):
+			t.Errorf("ValidateAmount(%s) = %v, want %s", test.amount, err, test.want)
+		}
+	}
+}
+
+func TestIsSupportedCurrency(t *testing.T) {
+	tests := []struct {
+		currency string
+		want     bool
+	}{
+		{enums.DefaultCurrency, true},
+		{"USD", true},
+		// A valid ISO code without an exchange rate could never be topped up
+		{"AED", false},
+		{"XXX", false},
+		{"", false},
+	}
+	for _, test := range tests {
+		if got := IsSupportedCurrency(context.Background(), test.currency); got != test.want {
+			t.Errorf("IsSupportedCurrency(%q) = %v, want %v", test.currency, got, test.want)
+		}
+	}
+}
+
+func TestToDefaultCurrency(t *testing.T) {
+	total, err := toDefaultCurrency(context.Background(), map[string]decimal.Decimal{
+		enums.DefaultCurrency: decimal.RequireFromString("100.00"),
+		"USD":                 decimal.RequireFromString("2.83"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := decimal.RequireFromString("200.00"); !total.Equal(want) {
+		t.Errorf("got %s, want %s", total, want)
+	}
+	if _, err := toDefaultCurrency(context.Background(), map[string]decimal.Decimal{"AED": decimal.NewFromInt(1)}); err == nil {
+		t.Error("converted a currency without a rate")
+	}
+}
+
+func TestTopupLimitsSpanWallets(t *testing.T) {
+	requireDB(t)
+	t.Setenv("WALLET_TOPUP_DAILY_LIMIT", "1000")
+	c := context.Background()
+	buyer := newTestBuyer(t, "0")
+	if _, err := buyer.OpenWallet(c, "USD"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := buyer.StartTopup(c, enums.DefaultCurrency, decimal.NewFromInt(900), stubProvider{}); err != nil {
+		t.Fatalf("first top-up: %v", err)
+	}
+	// 5 USD is about 177 THB, the pending THB intent already used 900
+	_, err := buyer.StartTopup(c, "USD", decimal.NewFromInt(5), stubProvider{})
+	var violation *PolicyViolation
+	if !errors.As(err, &violation) || violation.Code != enums.PolicyDailyLimitExceeded {
+		t.Fatalf("top-up in a second wallet: got %v, want %s", err, enums.PolicyDailyLimitExceeded)
+	}
+	if _, err := buyer.StartTopup(c, "USD", decimal.NewFromInt(2), stubProvider{}); err != nil {
+		t.Fatalf("top-up within the limit: %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"testing"
+	"time"
+	"user-service/db"
+	"user-service/enums"
+)
+
+// Every entry point that moves money checks the amount before touching the
+// database, the decimal(12,2) columns would round it differently from the
+// ledger otherwise
+func TestMoneyEntryPointsValidateAmount(t *testing.T) {
+	c := context.Background()
+	buyer := Buyer{ID: uuid.New()}
+	seller := Seller{ID: uuid.New()}
+	entryPoints := []struct {
+		name string
+		call func(amount decimal.Decimal) error
+	}{
+		{"debit", func(amount decimal.Decimal) error {
+			_, err := buyer.Debit(c, enums.DefaultCurrency, amount, "order")
+			return err
+		}},
+		{"authorize hold", func(amount decimal.Decimal) error {
+			_, err := buyer.AuthorizeHold(c, enums.DefaultCurrency, seller.ID, amount, "", "order", time.Hour)
+			return err
+		}},
+		{"settle", func(amount decimal.Decimal) error {
+			_, err := buyer.Settle(c, enums.DefaultCurrency, seller.ID, amount, "", "order")
+			return err
+		}},
+		{"request payout", func(amount decimal.Decimal) error {
+			_, err := seller.RequestPayout(c, enums.DefaultCurrency, 1, amount)
+			return err
+		}},
+		{"refund", func(amount decimal.Decimal) error {
+			_, err := buyer.Refund(c, 1, amount, "refund")
+			return err
+		}},
+	}
+	amounts := []struct {
+		amount string
+		want   string
+	}{
+		{"0.005", enums.PolicyAmountPrecision},
+		{"100.001", enums.PolicyAmountPrecision},
+		{"-1", enums.PolicyAmountNotPositive},
+		{"10000000000.00", enums.PolicyAmountOverflow},
+	}
+	for _, entryPoint := range entryPoints {
+		for _, test := range amounts {
+			err := entryPoint.call(decimal.RequireFromString(test.amount))
+			var violation *PolicyViolation
+			if !errors.As(err, &violation) || violation.Code != test.want {
+				t.Errorf("%s of %s: got %v, want %s", entryPoint.name, test.amount, err, test.want)
+			}
+		}
+	}
+}
+
+func TestDebit(t *testing.T) {
+	requireDB(t)
+	tests := []struct {
+		name      string
+		cash      string
+		promo     string
+		frozen    bool
+		amount    string
+		want      error
+		wantCash  string
+		wantPromo string
+	}{
+		{"cash only", "100.00", "0", false, "40.00", nil, "60.00", "0"},
+		{"promo credit before cash", "100.00", "30.00", false, "40.00", nil, "90.00", "0"},
+		{"promo credit covers it all", "100.00", "50.00", false, "40.00", nil, "100.00", "10.00"},
+		{"the whole balance", "10.00", "30.00", false, "40.00", nil, "0", "0"},
+		{"more than cash and promo credit", "10.00", "30.00", false, "40.01", ErrInsufficientFunds, "10.00", "30.00"},
+		{"frozen wallet", "100.00", "0", true, "1.00", ErrWalletFrozen, "100.00", "0"},
+	}
+	c := context.Background()
+	for _, test := range tests {
+		buyer := newTestBuyer(t, test.cash)
+		if promo := decimal.RequireFromString(test.promo); promo.IsPositive() {
+			if _, err := buyer.GrantPromoCredit(c, enums.DefaultCurrency, promo, "test", time.Now().Add(time.Hour)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if test.frozen {
+			if err := freezeWallet(db.GetDB(c), enums.Buyer, buyer.ID, enums.DefaultCurrency, "test"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		transaction, err := buyer.Debit(c, enums.DefaultCurrency, decimal.RequireFromString(test.amount), "order")
+		if !errors.Is(err, test.want) {
+			t.Errorf("%s: Debit = %v, want %v", test.name, err, test.want)
+		}
+		wallet := testBuyerWallet(t, buyer.ID)
+		if !wallet.Balance.Equal(decimal.RequireFromString(test.wantCash)) || !wallet.PromoBalance.Equal(decimal.RequireFromString(test.wantPromo)) {
+			t.Errorf("%s: wallet = cash %s promo %s, want %s %s", test.name, wallet.Balance, wallet.PromoBalance, test.wantCash, test.wantPromo)
+		}
+		if err == nil && !transaction.BalanceAfter.Equal(wallet.Balance) {
+			t.Errorf("%s: ledger balance after %s, wallet %s", test.name, transaction.BalanceAfter, wallet.Balance)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package models
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"strconv"
+	"strings"
+	"time"
+	"user-service/db"
+	"user-service/errs"
+	"user-service/forms"
+	"user-service/i18n"
+)
+
+const (
+	defaultTransactionPageSize = 20
+	maxTransactionPageSize     = 100
+)
+
+var ErrInvalidCursor = errs.New(errs.ErrBadRequest, i18n.CodeInvalidCursor, "invalid cursor")
+
+type BuyerWalletTransaction struct {
+	gorm.Model
+	BuyerID  uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Currency string    `gorm:"size:3;default:THB;not null"`
+	Type     string
+	// Amount is the change of cash and promo credit together, PromoAmount the
+	// part of it that moved promo credit. BalanceAfter is the cash balance
+	Amount       decimal.Decimal `gorm:"type:decimal(12,2);"`
+	PromoAmount  decimal.Decimal `gorm:"type:decimal(12,2);default:0;not null"`
+	BalanceAfter decimal.Decimal `gorm:"type:decimal(12,2);"`
+	Reference    string
+	// OriginalTransactionID links a refund to the transaction it reverses
+	OriginalTransactionID *uint `gorm:"index"`
+}
+
+// CashAmount is the change of the cash balance
+func (t BuyerWalletTransaction) CashAmount() decimal.Decimal {
+	return t.Amount.Sub(t.PromoAmount)
+}
+
+type SellerWalletTransaction struct {
+	gorm.Model
+	SellerID     uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Currency     string    `gorm:"size:3;default:THB;not null"`
+	Type         string
+	Amount       decimal.Decimal `gorm:"type:decimal(12,2);"`
+	BalanceAfter decimal.Decimal `gorm:"type:decimal(12,2);"`
+	Reference    string
+	// OriginalTransactionID links a refund to the transaction it reverses
+	OriginalTransactionID *uint `gorm:"index"`
+}
+
+// createBuyerTransaction appends a ledger entry, it must be called within the
+// same database transaction that updates the wallet balance
+func createBuyerTransaction(tx *gorm.DB, wallet BuyerWallet, transactionType string, amount decimal.Decimal, balanceAfter decimal.Decimal, reference string) (*BuyerWalletTransaction, error) {
+	transaction := BuyerWalletTransaction{
+		BuyerID:      wallet.BuyerID,
+		Currency:     wallet.Currency,
+		Type:         transactionType,
+		Amount:       amount,
+		BalanceAfter: balanceAfter,
+		Reference:    reference,
+	}
+	if err := tx.Create(&transaction).Error; err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+// createSellerTransaction appends a ledger entry, it must be called within the
+// same database transaction that updates the wallet balance
+func createSellerTransaction(tx *gorm.DB, wallet SellerWallet, transactionType string, amount decimal.Decimal, balanceAfter decimal.Decimal, reference string) (*SellerWalletTransaction, error) {
+	transaction := SellerWalletTransaction{
+		SellerID:     wallet.SellerID,
+		Currency:     wallet.Currency,
+		Type:         transactionType,
+		Amount:       amount,
+		BalanceAfter: balanceAfter,
+		Reference:    reference,
+	}
+	if err := tx.Create(&transaction).Error; err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+func (u *Buyer) ListWalletTransactions(c context.Context, query forms.WalletTransactionQuery) ([]BuyerWalletTransaction, string, error) {
+	limit := transactionPageSize(query.Limit)
+	tx, err := filterWalletTransactions(db.GetDB(c).Where("buyer_id = ?", u.ID), query)
+	if err != nil {
+		return nil, "", err
+	}
+	var transactions []BuyerWalletTransaction
+	if err := tx.Limit(limit + 1).Find(&transactions).Error; err != nil {
+		return nil, "", err
+	}
+
+	nextCursor := ""
+	if len(transactions) > limit {
+		transactions = transactions[:limit]
+		last := transactions[limit-1]
+		nextCursor = encodeTransactionCursor(last.CreatedAt, last.ID)
+	}
+	return transactions, nextCursor, nil
+}
+
+func (u *Seller) ListWalletTransactions(c context.Context, query forms.WalletTransactionQuery) ([]SellerWalletTransaction, string, error) {
+	limit := transactionPageSize(query.Limit)
+	tx, err := filterWalletTransactions(db.GetDB(c).Where("seller_id = ?", u.ID), query)
+	if err != nil {
+		return nil, "", err
+	}
+	var transactions []SellerWalletTransaction
+	if err := tx.Limit(limit + 1).Find(&transactions).Error; err != nil {
+		return nil, "", err
+	}
+
+	nextCursor := ""
+	if len(transactions) > limit {
+		transactions = transactions[:limit]
+		last := transactions[limit-1]
+		nextCursor = encodeTransactionCursor(last.CreatedAt, last.ID)
+	}
+	return transactions, nextCursor, nil
+}
+
+func transactionPageSize(limit int) int {
+	if limit <= 0 {
+		return defaultTransactionPageSize
+	}
+	if limit > maxTransactionPageSize {
+		return maxTransactionPageSize
+	}
+	return limit
+}
+
+// filterWalletTransactions applies the query filters and the newest-first
+// ordering shared by buyer and seller transaction history
+func filterWalletTransactions(tx *gorm.DB, query forms.WalletTransactionQuery) (*gorm.DB, error) {
+	if query.Type != "" {
+		tx = tx.Where("type = ?", query.Type)
+	}
+	if query.Currency != "" {
+		tx = tx.Where("currency = ?", query.Currency)
+	}
+	if !query.From.IsZero() {
+		tx = tx.Where("created_at >= ?", query.From)
+	}
+	if !query.To.IsZero() {
+		// "to" is a date, so include the whole day
+		tx = tx.Where("created_at < ?", query.To.AddDate(0, 0, 1))
+	}
+	if query.Cursor != "" {
+		createdAt, id, err := decodeTransactionCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		tx = tx.Where("(created_at, id) < (?, ?)", createdAt, id)
+	}
+	return tx.Order("created_at DESC").Order("id DESC"), nil
+}
+
+func encodeTransactionCursor(createdAt time.Time, id uint) string {
+	raw := fmt.Sprintf("%d:%d", createdAt.UnixNano(), id)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeTransactionCursor(cursor string) (time.Time, uint, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	parts := strings.SplitN(string(raw), ":", 2)
+	if len(parts) != 2 {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	id, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	return time.Unix(0, nanos), uint(id), nil
+}
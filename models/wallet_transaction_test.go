@@ -0,0 +1,122 @@
+package models
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"github.com/shopspring/decimal"
+	"testing"
+	"time"
+	"user-service/db"
+	"user-service/enums"
+	"user-service/forms"
+)
+
+func TestTransactionCursor(t *testing.T) {
+	createdAt := time.Date(2024, 3, 1, 10, 30, 0, 123456789, time.UTC)
+	gotTime, gotID, err := decodeTransactionCursor(encodeTransactionCursor(createdAt, 42))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !gotTime.Equal(createdAt) || gotID != 42 {
+		t.Errorf("cursor round trip = %s %d, want %s 42", gotTime, gotID, createdAt)
+	}
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"not base64", "not a cursor!"},
+		{"no separator", base64.RawURLEncoding.EncodeToString([]byte("1234"))},
+		{"time not a number", base64.RawURLEncoding.EncodeToString([]byte("x:1"))},
+		{"id not a number", base64.RawURLEncoding.EncodeToString([]byte("1:x"))},
+		{"negative id", base64.RawURLEncoding.EncodeToString([]byte("1:-1"))},
+	}
+	for _, test := range tests {
+		if _, _, err := decodeTransactionCursor(test.cursor); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s: decode = %v, want ErrInvalidCursor", test.name, err)
+		}
+	}
+}
+
+func TestTransactionPageSize(t *testing.T) {
+	tests := []struct {
+		limit int
+		want  int
+	}{
+		{0, defaultTransactionPageSize},
+		{-1, defaultTransactionPageSize},
+		{1, 1},
+		{maxTransactionPageSize, maxTransactionPageSize},
+		{maxTransactionPageSize + 1, maxTransactionPageSize},
+	}
+	for _, test := range tests {
+		if got := transactionPageSize(test.limit); got != test.want {
+			t.Errorf("transactionPageSize(%d) = %d, want %d", test.limit, got, test.want)
+		}
+	}
+}
+
+// Entries posted in the same instant are ordered by id, paging through them
+// returns every entry exactly once
+func TestListWalletTransactionsPages(t *testing.T) {
+	requireDB(t)
+	c := context.Background()
+	buyer := newTestBuyer(t, "0")
+	createdAt := time.Now().Truncate(time.Microsecond)
+	var posted []uint
+	for i := 0; i < 5; i++ {
+		transaction := BuyerWalletTransaction{
+			BuyerID:  buyer.ID,
+			Currency: enums.DefaultCurrency,
+			Type:     enums.TransactionTopup,
+			Amount:   decimal.NewFromInt(1),
+		}
+		transaction.CreatedAt = createdAt
+		if err := db.GetDB(c).Create(&transaction).Error; err != nil {
+			t.Fatal(err)
+		}
+		posted = append(posted, transaction.ID)
+	}
+
+	tests := []struct {
+		name  string
+		limit int
+		pages int
+	}{
+		{"one page", 10, 1},
+		{"exact pages", 5, 1},
+		{"several pages", 2, 3},
+		{"one per page", 1, 5},
+	}
+	for _, test := range tests {
+		var seen []uint
+		cursor := ""
+		pages := 0
+		for {
+			transactions, next, err := buyer.ListWalletTransactions(c, forms.WalletTransactionQuery{Cursor: cursor, Limit: test.limit})
+			if err != nil {
+				t.Fatalf("%s: %v", test.name, err)
+			}
+			pages++
+			for _, transaction := range transactions {
+				seen = append(seen, transaction.ID)
+			}
+			if next == "" {
+				break
+			}
+			cursor = next
+		}
+		if pages != test.pages {
+			t.Errorf("%s: %d pages, want %d", test.name, pages, test.pages)
+		}
+		if len(seen) != len(posted) {
+			t.Fatalf("%s: listed %v, want %d entries", test.name, seen, len(posted))
+		}
+		for i, id := range seen {
+			if want := posted[len(posted)-1-i]; id != want {
+				t.Errorf("%s: entry %d = %d, want %d", test.name, i, id, want)
+			}
+		}
+	}
+}
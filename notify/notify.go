@@ -0,0 +1,54 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+)
+
+// ErrNoAddress means the recipient cannot be reached on a channel, like a
+// user without a verified phone on SMS
+var ErrNoAddress = errors.New("recipient has no address on this channel")
+
+// Recipient is who a message goes to, with what the channels need to reach
+// them
+type Recipient struct {
+	UserID uuid.UUID
+	Group  string
+	Phone  string
+	Locale string
+}
+
+// Message is a notification about Event, Text holds its wording by locale and
+// must have English
+type Message struct {
+	Event string
+	Text  map[string]string
+}
+
+// Localized picks the wording for locale, English when there is none
+func (m Message) Localized(locale string) string {
+	if text, ok := m.Text[locale]; ok {
+		return text
+	}
+	return m.Text["en"]
+}
+
+// Channel delivers messages one way, like SMS or email
+type Channel interface {
+	Name() string
+	Send(c context.Context, recipient Recipient, message Message) error
+}
+
+var channels = map[string]Channel{}
+
+// Register makes a channel available, channels users enable but that are not
+// registered are skipped
+func Register(channel Channel) {
+	channels[channel.Name()] = channel
+}
+
+func GetChannel(name string) (Channel, bool) {
+	channel, ok := channels[name]
+	return channel, ok
+}
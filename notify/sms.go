@@ -0,0 +1,23 @@
+package notify
+
+import (
+	"context"
+	"user-service/enums"
+	"user-service/sms"
+)
+
+// SMSChannel texts notifications to the verified phone of the recipient
+type SMSChannel struct {
+	Gateway sms.Gateway
+}
+
+func (s *SMSChannel) Name() string {
+	return enums.ChannelSMS
+}
+
+func (s *SMSChannel) Send(c context.Context, recipient Recipient, message Message) error {
+	if recipient.Phone == "" {
+		return ErrNoAddress
+	}
+	return s.Gateway.Send(c, recipient.Phone, message.Localized(recipient.Locale))
+}
@@ -0,0 +1,109 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"log"
+	"net/http"
+	"os"
+	"time"
+	"user-service/enums"
+)
+
+const fakeSignatureHeader = "X-Fake-Signature"
+
+type fakeWebhookPayload struct {
+	Reference string          `json:"reference"`
+	Status    string          `json:"status"`
+	Amount    decimal.Decimal `json:"amount"`
+	Currency  string          `json:"currency"`
+}
+
+// FakeProvider accepts every intent without talking to anyone. Webhooks are
+// sent by SendWebhook, or automatically after AutoConfirm when it is set, so the
+// whole top-up flow can be exercised offline
+type FakeProvider struct {
+	Secret      []byte
+	WebhookURL  string
+	AutoConfirm time.Duration
+	Client      *http.Client
+}
+
+func NewFakeProviderFromEnv() *FakeProvider {
+	webhookURL := os.Getenv("PAYMENT_WEBHOOK_URL")
+	if webhookURL == "" {
+		webhookURL = fmt.Sprintf("http://localhost:%s/api/user/payments/webhook", os.Getenv("PORT"))
+	}
+	autoConfirm, _ := time.ParseDuration(os.Getenv("FAKE_PAYMENT_AUTO_CONFIRM"))
+	return &FakeProvider{
+		Secret:      webhookSecretFromEnv(),
+		WebhookURL:  webhookURL,
+		AutoConfirm: autoConfirm,
+		Client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *FakeProvider) CreateIntent(c context.Context, intent Intent) (ProviderIntent, error) {
+	reference := "fake_" + uuid.New().String()
+	if p.AutoConfirm > 0 {
+		go func() {
+			time.Sleep(p.AutoConfirm)
+			if err := p.SendWebhook(context.Background(), reference, enums.TopupSucceeded, intent.Amount, intent.Currency); err != nil {
+				log.Printf("fake payment webhook for %s failed: %v", reference, err)
+			}
+		}()
+	}
+	return ProviderIntent{
+		Reference:   reference,
+		CheckoutURL: "https://fake-payments.local/checkout/" + reference,
+	}, nil
+}
+
+func (p *FakeProvider) VerifyWebhook(header http.Header, payload []byte) (Event, error) {
+	signature, err := hex.DecodeString(header.Get(fakeSignatureHeader))
+	if err != nil || !hmac.Equal(signature, p.sign(payload)) {
+		return Event{}, ErrInvalidSignature
+	}
+	var body fakeWebhookPayload
+	if err := json.Unmarshal(payload, &body); err != nil {
+		return Event{}, err
+	}
+	return Event{Reference: body.Reference, Status: body.Status, Amount: body.Amount, Currency: body.Currency}, nil
+}
+
+// SendWebhook signs and posts a payment notification for amount in currency
+// to WebhookURL the way a real provider would
+func (p *FakeProvider) SendWebhook(c context.Context, reference string, status string, amount decimal.Decimal, currency string) error {
+	payload, err := json.Marshal(fakeWebhookPayload{Reference: reference, Status: status, Amount: amount, Currency: currency})
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequestWithContext(c, http.MethodPost, p.WebhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(fakeSignatureHeader, hex.EncodeToString(p.sign(payload)))
+	response, err := p.Client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("webhook responded with status %d", response.StatusCode)
+	}
+	return nil
+}
+
+func (p *FakeProvider) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, p.Secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
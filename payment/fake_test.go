@@ -0,0 +1,55 @@
+package payment
+
+import (
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestFakeProviderVerifyWebhook(t *testing.T) {
+	provider := &FakeProvider{Secret: []byte("webhook-secret")}
+	payload := []byte(`{"reference":"fake_1","status":"succeeded","amount":"100.00","currency":"THB"}`)
+	signed := hex.EncodeToString(provider.sign(payload))
+	tests := []struct {
+		name      string
+		signature string
+		payload   []byte
+		want      error
+	}{
+		{"signed", signed, payload, nil},
+		{"unsigned", "", payload, ErrInvalidSignature},
+		{"signature not hex", "not hex", payload, ErrInvalidSignature},
+		{"signed with another secret", hex.EncodeToString((&FakeProvider{Secret: []byte("other")}).sign(payload)), payload, ErrInvalidSignature},
+		{"tampered payload", signed, []byte(strings.Replace(string(payload), "100.00", "900.00", 1)), ErrInvalidSignature},
+	}
+	for _, test := range tests {
+		header := http.Header{}
+		if test.signature != "" {
+			header.Set(fakeSignatureHeader, test.signature)
+		}
+		event, err := provider.VerifyWebhook(header, test.payload)
+		if !errors.Is(err, test.want) {
+			t.Errorf("%s: VerifyWebhook = %v, want %v", test.name, err, test.want)
+			continue
+		}
+		if err == nil && (event.Reference != "fake_1" || event.Amount.String() != "100") {
+			t.Errorf("%s: event = %+v", test.name, event)
+		}
+	}
+}
+
+func TestDisabledProvider(t *testing.T) {
+	t.Setenv("PAYMENT_PROVIDER", "")
+	provider := Init()
+	if IsEnabled(provider) {
+		t.Fatal("provider without PAYMENT_PROVIDER is enabled")
+	}
+	if _, err := provider.VerifyWebhook(http.Header{}, nil); !errors.Is(err, ErrTopupsDisabled) {
+		t.Errorf("VerifyWebhook = %v, want ErrTopupsDisabled", err)
+	}
+	if !IsEnabled(&FakeProvider{}) {
+		t.Error("fake provider is not enabled")
+	}
+}
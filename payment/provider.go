@@ -0,0 +1,98 @@
+package payment
+
+import (
+	"context"
+	"github.com/shopspring/decimal"
+	"log"
+	"net/http"
+	"os"
+	"time"
+	"user-service/errs"
+	"user-service/i18n"
+)
+
+var (
+	ErrInvalidSignature = errs.New(errs.ErrUnauthorized, i18n.CodeInvalidSignature, "invalid webhook signature")
+	ErrTopupsDisabled   = errs.New(errs.ErrUnavailable, i18n.CodeTopupsDisabled, "no payment provider is configured")
+)
+
+type Intent struct {
+	ID        string
+	Amount    decimal.Decimal
+	Currency  string
+	ExpiresAt time.Time
+}
+
+type ProviderIntent struct {
+	Reference   string
+	CheckoutURL string
+}
+
+// Event is a verified payment notification, Status is one of enums.Topup*.
+// Amount and Currency are what the buyer actually paid
+type Event struct {
+	Reference string
+	Status    string
+	Amount    decimal.Decimal
+	Currency  string
+}
+
+// Provider collects money from buyers. Top-ups are only credited once the
+// provider confirms the payment through a signed webhook
+type Provider interface {
+	CreateIntent(c context.Context, intent Intent) (ProviderIntent, error)
+	VerifyWebhook(header http.Header, payload []byte) (Event, error)
+}
+
+var provider Provider
+
+// Init picks the provider named by PAYMENT_PROVIDER, only the fake provider
+// exists so far. Without one top-ups are disabled, the rest of the service
+// runs. The fake provider also needs PAYMENT_FAKE_ENABLED=true, since it lets
+// anyone mark a top-up as paid
+func Init() Provider {
+	switch os.Getenv("PAYMENT_PROVIDER") {
+	case "":
+		log.Print("PAYMENT_PROVIDER is not set, top-ups are disabled")
+		provider = disabledProvider{}
+	case "fake":
+		if os.Getenv("PAYMENT_FAKE_ENABLED") != "true" {
+			log.Fatal("the fake payment provider is only for development, set PAYMENT_FAKE_ENABLED=true to use it")
+		}
+		provider = NewFakeProviderFromEnv()
+	default:
+		log.Fatalf("unknown payment provider %q", os.Getenv("PAYMENT_PROVIDER"))
+	}
+	return provider
+}
+
+// webhookSecretFromEnv reads the key webhooks are signed with. An empty key
+// would let anyone forge a signature, so the service refuses to start
+func webhookSecretFromEnv() []byte {
+	secret := os.Getenv("PAYMENT_WEBHOOK_SECRET")
+	if secret == "" {
+		log.Fatal("PAYMENT_WEBHOOK_SECRET is not set")
+	}
+	return []byte(secret)
+}
+
+// disabledProvider refuses top-ups and webhooks when no provider is configured
+type disabledProvider struct{}
+
+func (disabledProvider) CreateIntent(c context.Context, intent Intent) (ProviderIntent, error) {
+	return ProviderIntent{}, ErrTopupsDisabled
+}
+
+func (disabledProvider) VerifyWebhook(header http.Header, payload []byte) (Event, error) {
+	return Event{}, ErrTopupsDisabled
+}
+
+// IsEnabled reports whether p can take payments
+func IsEnabled(p Provider) bool {
+	_, disabled := p.(disabledProvider)
+	return p != nil && !disabled
+}
+
+func GetProvider() Provider {
+	return provider
+}
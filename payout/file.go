@@ -0,0 +1,223 @@
+package payout
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"user-service/enums"
+)
+
+var ErrMixedCurrencies = errors.New("payout batch mixes currencies")
+
+// FileDropProvider writes each batch to a file in Dir instead of calling a
+// bank. Every item is reported as paid, it stands in for a real provider in
+// local testing
+type FileDropProvider struct {
+	Dir    string
+	Format string
+	Debtor Debtor
+}
+
+func (p *FileDropProvider) Submit(c context.Context, batch Batch) (results []Result, err error) {
+	if err := os.MkdirAll(p.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	extension := "csv"
+	if p.Format == enums.PayoutFormatPain001 {
+		extension = "xml"
+	}
+	path := filepath.Join(p.Dir, fmt.Sprintf("payout-batch-%d.%s", batch.ID, extension))
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	// A failed close may mean the file never reached the disk, the bank
+	// would not get the batch
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil && err == nil {
+			results, err = nil, closeErr
+		}
+	}()
+
+	if p.Format == enums.PayoutFormatPain001 {
+		err = WritePain001(file, batch, p.Debtor)
+	} else {
+		err = WriteCSV(file, batch)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	results = make([]Result, 0, len(batch.Items))
+	for _, item := range batch.Items {
+		results = append(results, Result{RequestID: item.RequestID, Paid: true})
+	}
+	return results, nil
+}
+
+func WriteCSV(w io.Writer, batch Batch) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{
+		"request_id", "seller_id", "account_name", "account_number", "bank_code", "amount", "currency", "reference",
+	}); err != nil {
+		return err
+	}
+	for _, item := range batch.Items {
+		if err := writer.Write([]string{
+			strconv.FormatUint(uint64(item.RequestID), 10),
+			item.SellerID.String(),
+			item.AccountName,
+			item.AccountNumber,
+			item.BankCode,
+			item.Amount.StringFixed(2),
+			item.Currency,
+			item.Reference,
+		}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// pain.001.001.03 customer credit transfer initiation, only the elements
+// required by most banks are produced
+
+type painDocument struct {
+	XMLName  xml.Name     `xml:"urn:iso:std:iso:20022:tech:xsd:pain.001.001.03 Document"`
+	Initiate painInitiate `xml:"CstmrCdtTrfInitn"`
+}
+
+type painInitiate struct {
+	GroupHeader painGroupHeader `xml:"GrpHdr"`
+	Payment     painPayment     `xml:"PmtInf"`
+}
+
+type painGroupHeader struct {
+	MessageID        string    `xml:"MsgId"`
+	CreationDateTime string    `xml:"CreDtTm"`
+	NumberOfTxs      int       `xml:"NbOfTxs"`
+	ControlSum       string    `xml:"CtrlSum"`
+	InitiatingParty  painParty `xml:"InitgPty"`
+}
+
+type painParty struct {
+	Name string `xml:"Nm"`
+}
+
+type painAccount struct {
+	ID painAccountID `xml:"Id"`
+}
+
+type painAccountID struct {
+	IBAN  string       `xml:"IBAN,omitempty"`
+	Other *painOtherID `xml:"Othr,omitempty"`
+}
+
+type painOtherID struct {
+	ID string `xml:"Id"`
+}
+
+type painAgent struct {
+	BIC string `xml:"FinInstnId>BIC"`
+}
+
+type painPayment struct {
+	PaymentInfoID          string            `xml:"PmtInfId"`
+	PaymentMethod          string            `xml:"PmtMtd"`
+	NumberOfTxs            int               `xml:"NbOfTxs"`
+	ControlSum             string            `xml:"CtrlSum"`
+	RequestedExecutionDate string            `xml:"ReqdExctnDt"`
+	Debtor                 painParty         `xml:"Dbtr"`
+	DebtorAccount          painAccount       `xml:"DbtrAcct"`
+	DebtorAgent            painAgent         `xml:"DbtrAgt"`
+	Transactions           []painTransaction `xml:"CdtTrfTxInf"`
+}
+
+type painAmount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type painTransaction struct {
+	EndToEndID      string      `xml:"PmtId>EndToEndId"`
+	Amount          painAmount  `xml:"Amt>InstdAmt"`
+	CreditorAgent   painAgent   `xml:"CdtrAgt"`
+	Creditor        painParty   `xml:"Cdtr"`
+	CreditorAccount painAccount `xml:"CdtrAcct"`
+	Remittance      string      `xml:"RmtInf>Ustrd"`
+}
+
+func WritePain001(w io.Writer, batch Batch, debtor Debtor) error {
+	for _, item := range batch.Items {
+		if item.Currency != batch.Currency {
+			return ErrMixedCurrencies
+		}
+	}
+	messageID := fmt.Sprintf("PAYOUT-%d", batch.ID)
+	total := batch.Total().StringFixed(2)
+	payment := painPayment{
+		PaymentInfoID:          messageID,
+		PaymentMethod:          "TRF",
+		NumberOfTxs:            len(batch.Items),
+		ControlSum:             total,
+		RequestedExecutionDate: batch.CreatedAt.Format("2006-01-02"),
+		Debtor:                 painParty{Name: debtor.Name},
+		DebtorAccount:          painAccount{ID: painAccountID{IBAN: debtor.IBAN}},
+		DebtorAgent:            painAgent{BIC: debtor.BIC},
+	}
+	for _, item := range batch.Items {
+		account := painAccount{ID: painAccountID{Other: &painOtherID{ID: item.AccountNumber}}}
+		if isIBAN(item.AccountNumber) {
+			account = painAccount{ID: painAccountID{IBAN: item.AccountNumber}}
+		}
+		payment.Transactions = append(payment.Transactions, painTransaction{
+			EndToEndID:      fmt.Sprintf("PAYOUT-%d-%d", batch.ID, item.RequestID),
+			Amount:          painAmount{Currency: item.Currency, Value: item.Amount.StringFixed(2)},
+			CreditorAgent:   painAgent{BIC: item.BankCode},
+			Creditor:        painParty{Name: item.AccountName},
+			CreditorAccount: account,
+			Remittance:      item.Reference,
+		})
+	}
+	document := painDocument{
+		Initiate: painInitiate{
+			GroupHeader: painGroupHeader{
+				MessageID:        messageID,
+				CreationDateTime: batch.CreatedAt.Format("2006-01-02T15:04:05"),
+				NumberOfTxs:      len(batch.Items),
+				ControlSum:       total,
+				InitiatingParty:  painParty{Name: debtor.Name},
+			},
+			Payment: payment,
+		},
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	return encoder.Encode(document)
+}
+
+// isIBAN does a shallow check, the bank validates the checksum
+func isIBAN(account string) bool {
+	if len(account) < 15 || len(account) > 34 {
+		return false
+	}
+	for i, r := range account {
+		isLetter := r >= 'A' && r <= 'Z'
+		isDigit := r >= '0' && r <= '9'
+		if i < 2 && !isLetter || i >= 2 && i < 4 && !isDigit || !isLetter && !isDigit {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,46 @@
+package payout
+
+import (
+	"bytes"
+	"errors"
+	"github.com/shopspring/decimal"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWritePain001ControlSum(t *testing.T) {
+	tests := []struct {
+		name       string
+		currencies []string
+		amounts    []string
+		want       string
+		wantErr    error
+	}{
+		{"one item", []string{"THB"}, []string{"100.00"}, "<CtrlSum>100.00</CtrlSum>", nil},
+		{"several items", []string{"THB", "THB"}, []string{"100.50", "0.75"}, "<CtrlSum>101.25</CtrlSum>", nil},
+		{"mixed currencies", []string{"THB", "USD"}, []string{"100.00", "3.00"}, "", ErrMixedCurrencies},
+	}
+	for _, test := range tests {
+		batch := Batch{ID: 1, CreatedAt: time.Now(), Currency: test.currencies[0]}
+		for i, amount := range test.amounts {
+			batch.Items = append(batch.Items, Item{
+				RequestID:     uint(i + 1),
+				AccountName:   "Test Seller",
+				AccountNumber: "1234567890",
+				BankCode:      "KASITHBK",
+				Amount:        decimal.RequireFromString(amount),
+				Currency:      test.currencies[i],
+			})
+		}
+		var file bytes.Buffer
+		err := WritePain001(&file, batch, Debtor{Name: "Platform"})
+		if !errors.Is(err, test.wantErr) {
+			t.Errorf("%s: WritePain001 = %v, want %v", test.name, err, test.wantErr)
+			continue
+		}
+		if err == nil && strings.Count(file.String(), test.want) != 2 {
+			t.Errorf("%s: want %s in the group header and the payment\n%s", test.name, test.want, file.String())
+		}
+	}
+}
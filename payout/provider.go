@@ -0,0 +1,77 @@
+package payout
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"os"
+	"time"
+	"user-service/enums"
+)
+
+type Item struct {
+	RequestID     uint
+	SellerID      uuid.UUID
+	AccountName   string
+	AccountNumber string
+	BankCode      string
+	Amount        decimal.Decimal
+	Currency      string
+	Reference     string
+}
+
+// Batch is a set of payouts sent together. Every item is in Currency, amounts
+// of different currencies cannot be added up into one control sum
+type Batch struct {
+	ID        uint
+	CreatedAt time.Time
+	Currency  string
+	Items     []Item
+}
+
+func (b Batch) Total() decimal.Decimal {
+	total := decimal.Zero
+	for _, item := range b.Items {
+		total = total.Add(item.Amount)
+	}
+	return total
+}
+
+type Result struct {
+	RequestID     uint
+	Paid          bool
+	FailureReason string
+}
+
+// Provider sends a batch of payouts to a bank or payment processor and reports
+// the outcome of every item
+type Provider interface {
+	Submit(c context.Context, batch Batch) ([]Result, error)
+}
+
+// Debtor is the platform account payouts are sent from
+type Debtor struct {
+	Name string
+	IBAN string
+	BIC  string
+}
+
+func NewProviderFromEnv() Provider {
+	format := os.Getenv("PAYOUT_FILE_FORMAT")
+	if format == "" {
+		format = enums.PayoutFormatCSV
+	}
+	dir := os.Getenv("PAYOUT_FILE_DIR")
+	if dir == "" {
+		dir = "temp/payouts"
+	}
+	return &FileDropProvider{
+		Dir:    dir,
+		Format: format,
+		Debtor: Debtor{
+			Name: os.Getenv("PAYOUT_DEBTOR_NAME"),
+			IBAN: os.Getenv("PAYOUT_DEBTOR_IBAN"),
+			BIC:  os.Getenv("PAYOUT_DEBTOR_BIC"),
+		},
+	}
+}
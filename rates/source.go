@@ -0,0 +1,47 @@
+package rates
+
+import (
+	"context"
+	"github.com/shopspring/decimal"
+	"log"
+	"os"
+	"time"
+	"user-service/errs"
+	"user-service/i18n"
+)
+
+var ErrRateNotFound = errs.New(errs.ErrValidation, i18n.CodeRateNotFound, "exchange rate not found")
+
+// Quote is how many units of To one unit of From buys, as published at AsOf
+type Quote struct {
+	From string
+	To   string
+	Rate decimal.Decimal
+	AsOf time.Time
+}
+
+// Source provides exchange rates for cross-currency settlement
+type Source interface {
+	Rate(c context.Context, from string, to string) (Quote, error)
+}
+
+var source Source
+
+// Init loads the rate source, only the static file source exists so far
+func Init() Source {
+	path := os.Getenv("EXCHANGE_RATES_FILE")
+	if path == "" {
+		path = "config/exchange_rates.json"
+	}
+	staticSource, err := NewStaticFileSource(path)
+	if err != nil {
+		log.Printf("could not load exchange rates from %s: %v", path, err)
+		staticSource = &StaticFileSource{}
+	}
+	source = staticSource
+	return source
+}
+
+func GetSource() Source {
+	return source
+}
@@ -0,0 +1,68 @@
+package rates
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/shopspring/decimal"
+	"os"
+	"time"
+)
+
+type staticFile struct {
+	Base  string                     `json:"base"`
+	AsOf  time.Time                  `json:"as_of"`
+	Rates map[string]decimal.Decimal `json:"rates"`
+}
+
+// StaticFileSource serves rates from a JSON file holding the value of one unit
+// of the base currency in every other currency, e.g.
+//
+//	{"base": "THB", "as_of": "2022-07-01T00:00:00+07:00", "rates": {"USD": "0.0283"}}
+//
+// Cross rates between two non-base currencies go through the base
+type StaticFileSource struct {
+	Base  string
+	AsOf  time.Time
+	Rates map[string]decimal.Decimal
+}
+
+func NewStaticFileSource(path string) (*StaticFileSource, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file staticFile
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, err
+	}
+	return &StaticFileSource{Base: file.Base, AsOf: file.AsOf, Rates: file.Rates}, nil
+}
+
+func (s *StaticFileSource) Rate(c context.Context, from string, to string) (Quote, error) {
+	quote := Quote{From: from, To: to, AsOf: s.AsOf}
+	if from == to {
+		quote.Rate = decimal.NewFromInt(1)
+		return quote, nil
+	}
+	fromRate, ok := s.baseRate(from)
+	if !ok {
+		return Quote{}, ErrRateNotFound
+	}
+	toRate, ok := s.baseRate(to)
+	if !ok {
+		return Quote{}, ErrRateNotFound
+	}
+	quote.Rate = toRate.DivRound(fromRate, 8)
+	return quote, nil
+}
+
+func (s *StaticFileSource) baseRate(currency string) (decimal.Decimal, bool) {
+	if currency == s.Base {
+		return decimal.NewFromInt(1), true
+	}
+	rate, ok := s.Rates[currency]
+	if !ok || !rate.IsPositive() {
+		return decimal.Zero, false
+	}
+	return rate, true
+}
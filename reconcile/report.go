@@ -0,0 +1,84 @@
+package reconcile
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"user-service/models"
+)
+
+// WriteReport saves the report as JSON and CSV in dir and returns both paths
+func WriteReport(dir string, report models.ReconciliationReport) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	name := fmt.Sprintf("reconciliation-%s", report.StartedAt.Format("20060102-150405"))
+	jsonPath := filepath.Join(dir, name+".json")
+	csvPath := filepath.Join(dir, name+".csv")
+	if err := writeJSON(jsonPath, report); err != nil {
+		return nil, err
+	}
+	if err := writeCSV(csvPath, report); err != nil {
+		return nil, err
+	}
+	return []string{jsonPath, csvPath}, nil
+}
+
+func writeJSON(path string, report models.ReconciliationReport) error {
+	content, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0o644)
+}
+
+// writeCSV lists wallet discrepancies then the books check of every currency,
+// the kind column tells the two apart
+func writeCSV(path string, report models.ReconciliationReport) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	if err := writer.Write([]string{
+		"kind", "group", "user_id", "currency", "field", "stored", "expected", "difference", "frozen",
+	}); err != nil {
+		return err
+	}
+	for _, discrepancy := range report.Discrepancies {
+		if err := writer.Write([]string{
+			"wallet",
+			discrepancy.Group,
+			discrepancy.UserID.String(),
+			discrepancy.Currency,
+			discrepancy.Field,
+			discrepancy.Stored.StringFixed(2),
+			discrepancy.Expected.StringFixed(2),
+			discrepancy.Difference.StringFixed(2),
+			fmt.Sprint(discrepancy.Frozen),
+		}); err != nil {
+			return err
+		}
+	}
+	for _, books := range report.Books {
+		if err := writer.Write([]string{
+			"books",
+			"",
+			"",
+			books.Currency,
+			"total",
+			books.StoredTotal.StringFixed(2),
+			books.ExpectedTotal.StringFixed(2),
+			books.Difference.StringFixed(2),
+			"",
+		}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,45 @@
+package reconcile
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"user-service/models"
+)
+
+// ReportDir is where reports are written, RECONCILIATION_REPORT_DIR or
+// temp/reconciliation
+func ReportDir() string {
+	dir := os.Getenv("RECONCILIATION_REPORT_DIR")
+	if dir == "" {
+		return "temp/reconciliation"
+	}
+	return dir
+}
+
+// FreezeFromEnv tells whether RECONCILIATION_FREEZE asks to freeze wallets
+// that disagree with their ledger
+func FreezeFromEnv() bool {
+	freeze, err := strconv.ParseBool(os.Getenv("RECONCILIATION_FREEZE"))
+	return err == nil && freeze
+}
+
+// Run reconciles every wallet and writes the report files
+func Run(c context.Context, dir string, freeze bool) (*models.ReconciliationReport, error) {
+	report, err := models.ReconcileWallets(c, freeze)
+	if err != nil {
+		return nil, err
+	}
+	paths, err := WriteReport(dir, *report)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf(
+		"reconciled %d wallets, %d discrepancies, report written to %v",
+		report.WalletsChecked,
+		len(report.Discrepancies),
+		paths,
+	)
+	return report, nil
+}
@@ -1,13 +1,17 @@
 package service
 
 import (
-	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/google/uuid"
 	"time"
+	"user-service/errs"
+	"user-service/i18n"
 )
 
+// ErrInvalidToken is returned for tokens that parse but cannot be trusted
+var ErrInvalidToken = errs.New(errs.ErrUnauthorized, i18n.CodeInvalidToken, "invalid token")
+
 type TokenService struct {
 	SecretKey         []byte
 	ISS               string
@@ -21,6 +25,10 @@ type TokenUserInput struct {
 	RoleGroupName string
 	Firstname     string
 	Lastname      string
+	// MFAVerified marks tokens issued after a second factor was checked
+	MFAVerified bool
+	// Locale is the preferred language of the user
+	Locale string
 }
 
 func (tg *TokenService) GenerateAccessToken(user *TokenUserInput) (string, error) {
@@ -37,12 +45,16 @@ func (tg *TokenService) GenerateAccessToken(user *TokenUserInput) (string, error
 	claims["group"] = user.RoleGroupName
 	claims["firstname"] = user.Firstname
 	claims["lastname"] = user.Lastname
+	if user.Locale != "" {
+		claims["locale"] = user.Locale
+	}
 	claims["exp"] = time.Now().Add(tg.AccessExpireTime).Unix()
+	if user.MFAVerified {
+		claims["mfa"] = true
+	}
 
 	// Generate encoded token and send it as response.
 	// The signing string should be secret (a generated UUID works too)
-	fmt.Println("generating token")
-	fmt.Println(string(tg.SecretKey))
 	t, err := token.SignedString(tg.SecretKey)
 	if err != nil {
 		return "", err
@@ -73,7 +85,45 @@ func (tg *TokenService) GetUserIDFromToken(accessToken string) (uuid.UUID, error
 		}
 		return userID, nil
 	}
-	return uuid.Nil, errors.New("invalid token")
+	return uuid.Nil, ErrInvalidToken
+}
+
+// IsMFAVerified reports whether a valid access token carries the "mfa" claim
+func (tg *TokenService) IsMFAVerified(accessToken string) bool {
+	token, err := jwt.Parse(accessToken, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
+		}
+		return tg.SecretKey, nil
+	})
+	if err != nil || !token.Valid {
+		return false
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return false
+	}
+	mfa, _ := claims["mfa"].(bool)
+	return mfa
+}
+
+// GetLocaleFromToken returns the "locale" claim of a valid access token
+func (tg *TokenService) GetLocaleFromToken(accessToken string) (string, bool) {
+	token, err := jwt.Parse(accessToken, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
+		}
+		return tg.SecretKey, nil
+	})
+	if err != nil || !token.Valid {
+		return "", false
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return "", false
+	}
+	locale, ok := claims["locale"].(string)
+	return locale, ok && locale != ""
 }
 
 func (tg *TokenService) GenerateRefreshToken(user *TokenUserInput) (string, error) {
@@ -111,5 +161,53 @@ func (tg *TokenService) ValidateRefreshAccessToken(refreshToken string) (jwt.Map
 		// run through your business logic to verify if the user can log in
 		return claims, nil
 	}
-	return nil, errors.New("invalid token")
+	return nil, ErrInvalidToken
+}
+
+type ServiceClaims struct {
+	Name        string
+	Permissions []string
+}
+
+func (sc ServiceClaims) HasPermission(permission string) bool {
+	for _, p := range sc.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateServiceToken parses a token issued to an internal service client.
+// The token carries the client name in "sub" and its grants in "permissions"
+func (tg *TokenService) ValidateServiceToken(accessToken string) (ServiceClaims, error) {
+	token, err := jwt.Parse(accessToken, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
+		}
+		return tg.SecretKey, nil
+	})
+	if err != nil {
+		return ServiceClaims{}, err
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return ServiceClaims{}, ErrInvalidToken
+	}
+	if tg.ISS != "" && !claims.VerifyIssuer(tg.ISS, true) {
+		return ServiceClaims{}, fmt.Errorf("%w issuer", ErrInvalidToken)
+	}
+	name, _ := claims["sub"].(string)
+	if name == "" {
+		return ServiceClaims{}, fmt.Errorf("%w subject", ErrInvalidToken)
+	}
+	serviceClaims := ServiceClaims{Name: name}
+	if permissions, ok := claims["permissions"].([]interface{}); ok {
+		for _, p := range permissions {
+			if permission, ok := p.(string); ok {
+				serviceClaims.Permissions = append(serviceClaims.Permissions, permission)
+			}
+		}
+	}
+	return serviceClaims, nil
 }
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"github.com/google/uuid"
+	"testing"
+	"time"
+)
+
+func TestMFAClaim(t *testing.T) {
+	tokens := &TokenService{
+		SecretKey:        []byte("0123456789abcdef0123456789abcdef"),
+		ISS:              "test",
+		AccessExpireTime: time.Minute,
+	}
+	other := &TokenService{
+		SecretKey:        []byte("fedcba9876543210fedcba9876543210"),
+		ISS:              "test",
+		AccessExpireTime: time.Minute,
+	}
+	tests := []struct {
+		name        string
+		mfaVerified bool
+		validator   *TokenService
+		want        bool
+	}{
+		{"password login", false, tokens, false},
+		{"after second factor", true, tokens, true},
+		{"signed with another key", true, other, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			token, err := tokens.GenerateAccessToken(&TokenUserInput{
+				Username:    "user",
+				UserID:      uuid.New(),
+				MFAVerified: test.mfaVerified,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := test.validator.IsMFAVerified(token); got != test.want {
+				t.Errorf("IsMFAVerified() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package sms
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileGateway appends every message to the file at Path instead of sending
+// it, one tab separated line per message. It stands in for a real gateway in
+// local testing
+type FileGateway struct {
+	Path string
+	mu   sync.Mutex
+}
+
+func (g *FileGateway) Send(c context.Context, phone string, message string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(g.Path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(g.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	// Keep one message per line
+	message = strings.ReplaceAll(message, "\n", " ")
+	_, err = fmt.Fprintf(file, "%s\t%s\t%s\n", time.Now().Format(time.RFC3339), phone, message)
+	return err
+}
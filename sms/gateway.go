@@ -0,0 +1,37 @@
+package sms
+
+import (
+	"context"
+	"log"
+	"os"
+)
+
+// Gateway delivers text messages to E.164 phone numbers
+type Gateway interface {
+	Send(c context.Context, phone string, message string) error
+}
+
+var gateway Gateway
+
+// Init picks the gateway named by SMS_GATEWAY, only the file gateway exists
+// so far. It writes one time passwords in plain text, so it has to be chosen
+// explicitly and the service refuses to start without a gateway
+func Init() Gateway {
+	switch os.Getenv("SMS_GATEWAY") {
+	case "":
+		log.Fatal("SMS_GATEWAY is not set, set SMS_GATEWAY=file to write messages to a file in development")
+	case "file":
+		path := os.Getenv("SMS_FILE_PATH")
+		if path == "" {
+			path = "temp/sms/messages.log"
+		}
+		gateway = &FileGateway{Path: path}
+	default:
+		log.Fatalf("unknown SMS gateway %q", os.Getenv("SMS_GATEWAY"))
+	}
+	return gateway
+}
+
+func GetGateway() Gateway {
+	return gateway
+}
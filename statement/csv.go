@@ -0,0 +1,64 @@
+package statement
+
+import (
+	"encoding/csv"
+	"io"
+	"user-service/enums"
+	"user-service/models"
+)
+
+// WriteCSV writes the statement as a summary block followed by one row per
+// transaction
+func WriteCSV(w io.Writer, statement models.Statement) error {
+	writer := csv.NewWriter(w)
+	rows := [][]string{
+		{"Account", statement.AccountName},
+		{"Username", statement.Username},
+		{"Period", period(statement)},
+		{"Currency", statement.Currency},
+		{"Opening balance", money(statement.OpeningBalance)},
+		{"Total credits", money(statement.TotalCredits)},
+		{"Total debits", money(statement.TotalDebits)},
+	}
+	if statement.Group == enums.Seller {
+		rows = append(rows,
+			[]string{"Total fees", money(statement.TotalFees)},
+			[]string{"Total commission", money(statement.TotalCommission)},
+		)
+	}
+	rows = append(rows, []string{"Closing balance", money(statement.ClosingBalance)}, []string{})
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+
+	header := []string{"date", "transaction_id", "type", "reference", "amount"}
+	if statement.Group == enums.Seller {
+		header = append(header, "fee", "commission")
+	} else {
+		header = append(header, "promo_amount")
+	}
+	header = append(header, "balance")
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, line := range statement.Lines {
+		row := []string{
+			line.Date.UTC().Format("2006-01-02 15:04:05"),
+			uintString(line.ID),
+			line.Type,
+			line.Reference,
+			money(line.Amount),
+		}
+		if statement.Group == enums.Seller {
+			row = append(row, money(line.Fee), money(line.Commission))
+		} else {
+			row = append(row, money(line.PromoAmount))
+		}
+		row = append(row, money(line.Balance))
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
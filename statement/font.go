@@ -0,0 +1,239 @@
+package statement
+
+import (
+	"bytes"
+	"compress/zlib"
+	"embed"
+	"errors"
+	"fmt"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+	"io/fs"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf16"
+)
+
+// Text outside Latin-1, Thai names and references, is drawn with a TrueType
+// font embedded in the PDF. The font files are built into the binary from the
+// fonts directory, they are vendored there with their license
+//
+//go:embed fonts
+var fontFiles embed.FS
+
+const (
+	regularFontFile = "fonts/regular.ttf"
+	boldFontFile    = "fonts/bold.ttf"
+)
+
+var (
+	loadFontsOnce sync.Once
+	regularFont   *trueTypeFont
+	boldFont      *trueTypeFont
+)
+
+type trueTypeFont struct {
+	name string
+	data []byte
+	font *sfnt.Font
+	// ppem makes sfnt report metrics in font units, scale turns font units
+	// into the thousandths of an em PDF widths are given in
+	ppem  fixed.Int26_6
+	scale float64
+}
+
+// unicodeFonts returns the embedded regular and bold fonts, bold falls back to
+// regular. Both are nil when the binary was built without them
+func unicodeFonts() (*trueTypeFont, *trueTypeFont) {
+	loadFontsOnce.Do(func() {
+		var err error
+		if regularFont, err = loadTrueTypeFont(regularFontFile); err != nil {
+			log.Printf("statement: %s, text outside Latin-1 is replaced with ?", err)
+			return
+		}
+		if boldFont, err = loadTrueTypeFont(boldFontFile); err != nil {
+			boldFont = regularFont
+		}
+	})
+	return regularFont, boldFont
+}
+
+func loadTrueTypeFont(path string) (*trueTypeFont, error) {
+	data, err := fontFiles.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("no font embedded at %s", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := sfnt.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	unitsPerEm := parsed.UnitsPerEm()
+	name, err := parsed.Name(nil, sfnt.NameIDPostScript)
+	if err != nil || name == "" {
+		name = "EmbeddedFont"
+	}
+	return &trueTypeFont{
+		name:  pdfName(name),
+		data:  data,
+		font:  parsed,
+		ppem:  fixed.Int26_6(unitsPerEm) << 6,
+		scale: 1000 / float64(unitsPerEm),
+	}, nil
+}
+
+// pdfName keeps the characters a PDF name may hold without escaping
+func pdfName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '-' {
+			return r
+		}
+		return -1
+	}, name)
+}
+
+func (f *trueTypeFont) glyph(r rune) sfnt.GlyphIndex {
+	glyph, err := f.font.GlyphIndex(nil, r)
+	if err != nil {
+		return 0
+	}
+	return glyph
+}
+
+// width is the advance of glyph in thousandths of an em
+func (f *trueTypeFont) width(glyph sfnt.GlyphIndex) int {
+	advance, err := f.font.GlyphAdvance(nil, glyph, f.ppem, font.HintingNone)
+	if err != nil {
+		return 0
+	}
+	return f.units(advance)
+}
+
+func (f *trueTypeFont) units(value fixed.Int26_6) int {
+	return int(float64(value) / 64 * f.scale)
+}
+
+// fontUsage is an embedded font as used by one document, only the glyphs
+// drawn get a width and a ToUnicode entry
+type fontUsage struct {
+	resource string
+	font     *trueTypeFont
+	glyphs   map[sfnt.GlyphIndex]rune
+}
+
+// encode turns s into the hex string of its glyph ids for the Identity-H
+// encoding
+func (u *fontUsage) encode(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		glyph := u.font.glyph(r)
+		if _, ok := u.glyphs[glyph]; !ok {
+			u.glyphs[glyph] = r
+		}
+		fmt.Fprintf(&b, "%04X", uint16(glyph))
+	}
+	return b.String()
+}
+
+func (u *fontUsage) sortedGlyphs() []sfnt.GlyphIndex {
+	glyphs := make([]sfnt.GlyphIndex, 0, len(u.glyphs))
+	for glyph := range u.glyphs {
+		glyphs = append(glyphs, glyph)
+	}
+	sort.Slice(glyphs, func(i, j int) bool { return glyphs[i] < glyphs[j] })
+	return glyphs
+}
+
+// objects returns the bodies of the Type0 font, its CID font, descriptor,
+// font file and ToUnicode map, numbered from first
+func (u *fontUsage) objects(first int) ([]string, error) {
+	f := u.font
+	metrics, err := f.font.Metrics(nil, f.ppem, font.HintingNone)
+	if err != nil {
+		return nil, err
+	}
+	bounds, err := f.font.Bounds(nil, f.ppem, font.HintingNone)
+	if err != nil {
+		return nil, err
+	}
+
+	var widths strings.Builder
+	for _, glyph := range u.sortedGlyphs() {
+		fmt.Fprintf(&widths, "%d [%d] ", glyph, f.width(glyph))
+	}
+
+	var compressed bytes.Buffer
+	writer := zlib.NewWriter(&compressed)
+	if _, err := writer.Write(f.data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	// sfnt bounds grow downwards, PDF ones upwards
+	return []string{
+		fmt.Sprintf(
+			"<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+			f.name, first+1, first+4,
+		),
+		fmt.Sprintf(
+			"<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %d 0 R /DW 1000 /W [%s] /CIDToGIDMap /Identity >>",
+			f.name, first+2, strings.TrimSpace(widths.String()),
+		),
+		fmt.Sprintf(
+			"<< /Type /FontDescriptor /FontName /%s /Flags 32 /FontBBox [%d %d %d %d] /ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+			f.name, f.units(bounds.Min.X), -f.units(bounds.Max.Y), f.units(bounds.Max.X), -f.units(bounds.Min.Y),
+			f.units(metrics.Ascent), -f.units(metrics.Descent), f.units(metrics.CapHeight), first+3,
+		),
+		fmt.Sprintf(
+			"<< /Length %d /Length1 %d /Filter /FlateDecode >>\nstream\n%s\nendstream",
+			compressed.Len(), len(f.data), compressed.String(),
+		),
+		u.toUnicode(),
+	}, nil
+}
+
+// toUnicode maps glyph ids back to text so the statement can be searched and
+// copied from
+func (u *fontUsage) toUnicode() string {
+	var cmap strings.Builder
+	cmap.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n")
+	cmap.WriteString("/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n")
+	cmap.WriteString("/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n")
+	cmap.WriteString("1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	glyphs := u.sortedGlyphs()
+	// A bfchar block holds at most 100 entries
+	for start := 0; start < len(glyphs); start += 100 {
+		end := start + 100
+		if end > len(glyphs) {
+			end = len(glyphs)
+		}
+		fmt.Fprintf(&cmap, "%d beginbfchar\n", end-start)
+		for _, glyph := range glyphs[start:end] {
+			fmt.Fprintf(&cmap, "<%04X> <", uint16(glyph))
+			for _, unit := range utf16.Encode([]rune{u.glyphs[glyph]}) {
+				fmt.Fprintf(&cmap, "%04X", unit)
+			}
+			cmap.WriteString(">\n")
+		}
+		cmap.WriteString("endbfchar\n")
+	}
+	cmap.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", cmap.Len(), cmap.String())
+}
+
+// isLatin1 reports whether the standard fonts can draw s
+func isLatin1(s string) bool {
+	for _, r := range s {
+		if r > 0xff {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,20 @@
+package statement
+
+import (
+	"github.com/shopspring/decimal"
+	"strconv"
+	"user-service/models"
+)
+
+func money(amount decimal.Decimal) string {
+	return amount.StringFixed(2)
+}
+
+func uintString(value uint) string {
+	return strconv.FormatUint(uint64(value), 10)
+}
+
+// period is the inclusive date range the statement covers
+func period(statement models.Statement) string {
+	return statement.PeriodStart.Format("2006-01-02") + " - " + statement.PeriodEnd.AddDate(0, 0, -1).Format("2006-01-02")
+}
@@ -0,0 +1,178 @@
+package statement
+
+import (
+	"bytes"
+	"fmt"
+	"golang.org/x/image/font/sfnt"
+	"io"
+	"strings"
+)
+
+// A minimal PDF 1.4 writer, just enough for text and rules on A4 pages. Latin-1
+// text uses the standard 14 fonts and is WinAnsi encoded. Anything else is
+// drawn with the embedded TrueType font, see font.go
+
+const (
+	pageWidth  = 595.0
+	pageHeight = 842.0
+
+	fontRegular  = "F1"
+	fontBold     = "F2"
+	fontMono     = "F3"
+	fontMonoBold = "F4"
+
+	// Every Courier glyph is 600/1000 em wide, which lets monospaced text be
+	// right aligned without font metrics
+	monoAdvance = 0.6
+)
+
+var pdfFonts = []struct {
+	name     string
+	baseFont string
+}{
+	{fontRegular, "Helvetica"},
+	{fontBold, "Helvetica-Bold"},
+	{fontMono, "Courier"},
+	{fontMonoBold, "Courier-Bold"},
+}
+
+type pdfDocument struct {
+	pages []*bytes.Buffer
+	// page is the index drawing goes to
+	page int
+	// fonts are the embedded fonts drawn with so far
+	fonts []*fontUsage
+}
+
+func (d *pdfDocument) newPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.page = len(d.pages) - 1
+}
+
+func (d *pdfDocument) current() *bytes.Buffer {
+	return d.pages[d.page]
+}
+
+// text draws s with its baseline starting at x, y
+func (d *pdfDocument) text(font string, size float64, x float64, y float64, s string) {
+	if usage := d.unicodeFont(font, s); usage != nil {
+		fmt.Fprintf(d.current(), "BT /%s %.1f Tf %.2f %.2f Td <%s> Tj ET\n", usage.resource, size, x, y, usage.encode(s))
+		return
+	}
+	fmt.Fprintf(d.current(), "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, pdfString(s))
+}
+
+// unicodeFont picks the embedded font for text the standard fonts cannot draw,
+// nil means s stays with font
+func (d *pdfDocument) unicodeFont(font string, s string) *fontUsage {
+	if isLatin1(s) {
+		return nil
+	}
+	regular, bold := unicodeFonts()
+	embedded := regular
+	if font == fontBold || font == fontMonoBold {
+		embedded = bold
+	}
+	if embedded == nil {
+		return nil
+	}
+	for _, usage := range d.fonts {
+		if usage.font == embedded {
+			return usage
+		}
+	}
+	usage := &fontUsage{
+		resource: fmt.Sprintf("U%d", len(d.fonts)+1),
+		font:     embedded,
+		glyphs:   map[sfnt.GlyphIndex]rune{},
+	}
+	d.fonts = append(d.fonts, usage)
+	return usage
+}
+
+// monoRight draws s in a Courier font so that it ends at x
+func (d *pdfDocument) monoRight(font string, size float64, x float64, y float64, s string) {
+	width := float64(len([]rune(s))) * size * monoAdvance
+	d.text(font, size, x-width, y, s)
+}
+
+func (d *pdfDocument) rule(x1 float64, x2 float64, y float64) {
+	fmt.Fprintf(d.current(), "0.5 w %.2f %.2f m %.2f %.2f l S\n", x1, y, x2, y)
+}
+
+// WriteTo serialises the document with its cross-reference table
+func (d *pdfDocument) WriteTo(w io.Writer) (int64, error) {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	// Object numbers: 1 catalog, 2 page tree, then the standard fonts, five
+	// objects for every embedded font, then a page and its content stream for
+	// every page
+	firstEmbedded := 3 + len(pdfFonts)
+	firstPage := firstEmbedded + len(d.fonts)*5
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+i*2)
+	}
+	fonts := make([]string, 0, len(pdfFonts)+len(d.fonts))
+	for i, font := range pdfFonts {
+		fonts = append(fonts, fmt.Sprintf("/%s %d 0 R", font.name, 3+i))
+	}
+	embedded := make([]string, 0, len(d.fonts)*5)
+	for i, usage := range d.fonts {
+		fonts = append(fonts, fmt.Sprintf("/%s %d 0 R", usage.resource, firstEmbedded+i*5))
+		objects, err := usage.objects(firstEmbedded + i*5)
+		if err != nil {
+			return 0, err
+		}
+		embedded = append(embedded, objects...)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	for _, font := range pdfFonts {
+		object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", font.baseFont))
+	}
+	for _, body := range embedded {
+		object(body)
+	}
+	for i, page := range d.pages {
+		object(fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << %s >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, strings.Join(fonts, " "), firstPage+i*2+1,
+		))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.WriteTo(w)
+}
+
+// pdfString encodes s for a literal string in WinAnsiEncoding
+func pdfString(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,134 @@
+package statement
+
+import (
+	"io"
+	"time"
+	"user-service/enums"
+	"user-service/models"
+)
+
+const (
+	margin        = 40.0
+	rowHeight     = 12.0
+	tableFontSize = 8.0
+	maxReference  = 20
+)
+
+type column struct {
+	title string
+	x     float64
+	right bool
+	value func(line models.StatementLine) string
+}
+
+func columns(group string) []column {
+	columns := []column{
+		{"Date", margin, false, func(line models.StatementLine) string {
+			return line.Date.UTC().Format("2006-01-02")
+		}},
+		{"Type", 100, false, func(line models.StatementLine) string { return line.Type }},
+		{"Reference", 185, false, func(line models.StatementLine) string {
+			reference := []rune(line.Reference)
+			if len(reference) > maxReference {
+				return string(reference[:maxReference-1]) + "~"
+			}
+			return line.Reference
+		}},
+		{"Amount", 360, true, func(line models.StatementLine) string { return money(line.Amount) }},
+	}
+	if group == enums.Seller {
+		columns = append(columns,
+			column{"Fee", 420, true, func(line models.StatementLine) string { return money(line.Fee) }},
+			column{"Commission", 487, true, func(line models.StatementLine) string { return money(line.Commission) }},
+		)
+	} else {
+		columns = append(columns,
+			column{"Promo", 460, true, func(line models.StatementLine) string { return money(line.PromoAmount) }},
+		)
+	}
+	return append(columns,
+		column{"Balance", pageWidth - margin, true, func(line models.StatementLine) string { return money(line.Balance) }},
+	)
+}
+
+// WritePDF renders the statement as an A4 PDF, the transaction table flows
+// over as many pages as needed
+func WritePDF(w io.Writer, statement models.Statement) error {
+	document := &pdfDocument{}
+	document.newPage()
+	y := pageHeight - margin - 16
+
+	document.text(fontBold, 16, margin, y, "Wallet statement")
+	y -= 26
+	details := [][2]string{
+		{"Account", statement.AccountName + " (" + statement.Username + ")"},
+		{"Period", period(statement)},
+		{"Currency", statement.Currency},
+		{"Generated", time.Now().UTC().Format("2006-01-02 15:04 MST")},
+	}
+	for _, detail := range details {
+		document.text(fontBold, 10, margin, y, detail[0])
+		document.text(fontRegular, 10, margin+80, y, detail[1])
+		y -= 14
+	}
+	y -= 10
+
+	summary := [][2]string{
+		{"Opening balance", money(statement.OpeningBalance)},
+		{"Total credits", money(statement.TotalCredits)},
+		{"Total debits", money(statement.TotalDebits)},
+	}
+	if statement.Group == enums.Seller {
+		summary = append(summary,
+			[2]string{"Total fees", money(statement.TotalFees)},
+			[2]string{"Total commission", money(statement.TotalCommission)},
+		)
+	}
+	summary = append(summary, [2]string{"Closing balance", money(statement.ClosingBalance)})
+	for _, row := range summary {
+		document.text(fontRegular, 10, margin, y, row[0])
+		document.monoRight(fontMono, 10, margin+220, y, row[1])
+		y -= 14
+	}
+	y -= 16
+
+	columns := columns(statement.Group)
+	tableHeader := func() {
+		for _, column := range columns {
+			if column.right {
+				document.monoRight(fontMonoBold, tableFontSize, column.x, y, column.title)
+			} else {
+				document.text(fontMonoBold, tableFontSize, column.x, y, column.title)
+			}
+		}
+		document.rule(margin, pageWidth-margin, y-4)
+		y -= rowHeight + 4
+	}
+	tableHeader()
+	if len(statement.Lines) == 0 {
+		document.text(fontRegular, tableFontSize, margin, y, "No transactions in this period")
+	}
+	for _, line := range statement.Lines {
+		if y < margin+rowHeight {
+			document.newPage()
+			y = pageHeight - margin - rowHeight
+			tableHeader()
+		}
+		for _, column := range columns {
+			if column.right {
+				document.monoRight(fontMono, tableFontSize, column.x, y, column.value(line))
+			} else {
+				document.text(fontMono, tableFontSize, column.x, y, column.value(line))
+			}
+		}
+		y -= rowHeight
+	}
+
+	for i := range document.pages {
+		document.page = i
+		footer := "Page " + uintString(uint(i+1)) + " of " + uintString(uint(len(document.pages)))
+		document.monoRight(fontMono, tableFontSize, pageWidth-margin, margin/2, footer)
+	}
+	_, err := document.WriteTo(w)
+	return err
+}
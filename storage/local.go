@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStore writes files below Dir, the service serves them at PublicURL
+type LocalStore struct {
+	Dir       string
+	PublicURL string
+}
+
+// path maps a key inside Dir, keys cannot climb out of it
+func (s *LocalStore) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if clean == "/" {
+		return "", errors.New("empty storage key")
+	}
+	return filepath.Join(s.Dir, filepath.FromSlash(strings.TrimPrefix(clean, "/"))), nil
+}
+
+func (s *LocalStore) Put(c context.Context, key string, contentType string, data []byte) error {
+	file, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+	// Write to a temporary file first so readers never see half a file
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+func (s *LocalStore) Get(c context.Context, key string) ([]byte, error) {
+	file, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+func (s *LocalStore) Delete(c context.Context, key string) error {
+	file, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStore) URL(key string) string {
+	return strings.TrimSuffix(s.PublicURL, "/") + "/" + strings.TrimPrefix(key, "/")
+}
@@ -0,0 +1,174 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+type S3Config struct {
+	// Endpoint of the S3 compatible service, e.g. https://s3.ap-southeast-1.amazonaws.com
+	// or a MinIO server. Buckets are addressed path style
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// PublicURL is the base URL objects are downloaded from, defaults to
+	// the bucket URL
+	PublicURL string
+}
+
+// S3Store talks to any S3 compatible object storage, requests are signed with
+// AWS Signature Version 4
+type S3Store struct {
+	config S3Config
+	client *http.Client
+}
+
+func NewS3Store(config S3Config) *S3Store {
+	config.Endpoint = strings.TrimSuffix(config.Endpoint, "/")
+	if config.PublicURL == "" {
+		config.PublicURL = config.Endpoint + "/" + config.Bucket
+	}
+	return &S3Store{config: config, client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+func (s *S3Store) Put(c context.Context, key string, contentType string, data []byte) error {
+	response, err := s.do(c, http.MethodPut, key, contentType, data)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	return checkS3Response(response)
+}
+
+func (s *S3Store) Get(c context.Context, key string) ([]byte, error) {
+	response, err := s.do(c, http.MethodGet, key, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if err := checkS3Response(response); err != nil {
+		return nil, err
+	}
+	return io.ReadAll(response.Body)
+}
+
+func (s *S3Store) Delete(c context.Context, key string) error {
+	response, err := s.do(c, http.MethodDelete, key, "", nil)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	return checkS3Response(response)
+}
+
+func (s *S3Store) URL(key string) string {
+	return strings.TrimSuffix(s.config.PublicURL, "/") + "/" + escapeS3Path(key)
+}
+
+func (s *S3Store) do(c context.Context, method string, key string, contentType string, data []byte) (*http.Response, error) {
+	canonicalURI := "/" + escapeS3Path(s.config.Bucket) + "/" + escapeS3Path(key)
+	request, err := http.NewRequestWithContext(c, method, s.config.Endpoint+canonicalURI, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+	s.sign(request, canonicalURI, data, time.Now().UTC())
+	return s.client.Do(request)
+}
+
+// sign adds the SigV4 headers, every header set so far is signed
+func (s *S3Store) sign(request *http.Request, canonicalURI string, payload []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(payload)
+	request.Header.Set("X-Amz-Date", amzDate)
+	request.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": request.URL.Host}
+	for name, values := range request.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		canonicalURI,
+		"",
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + s.config.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+s.config.SecretKey), date)
+	key = hmacSHA256(key, s.config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	request.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.config.AccessKey, scope, signedHeaders, signature,
+	))
+}
+
+func checkS3Response(response *http.Response) error {
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+	return fmt.Errorf("s3 %s: %s", response.Status, strings.TrimSpace(string(body)))
+}
+
+// escapeS3Path URI encodes every path segment the way SigV4 expects, only
+// unreserved characters are left as they are
+func escapeS3Path(key string) string {
+	var b strings.Builder
+	for _, c := range []byte(strings.TrimPrefix(key, "/")) {
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"context"
+	"log"
+	"os"
+	"user-service/errs"
+	"user-service/i18n"
+)
+
+var ErrNotFound = errs.New(errs.ErrNotFound, i18n.CodeNotFound, "object not found")
+
+// Store keeps uploaded files under slash separated keys
+type Store interface {
+	Put(c context.Context, key string, contentType string, data []byte) error
+	Get(c context.Context, key string) ([]byte, error)
+	Delete(c context.Context, key string) error
+	// URL is where clients download the object from
+	URL(key string) string
+}
+
+var (
+	imageStore    Store
+	documentStore Store
+)
+
+// NewFromEnv builds the store named by <prefix>_STORAGE, local by default.
+// The local store keeps files in <prefix>_LOCAL_DIR, the S3 store reads
+// <prefix>_S3_ENDPOINT, _REGION, _BUCKET, _ACCESS_KEY, _SECRET_KEY and
+// optionally _PUBLIC_URL. <prefix>_PUBLIC_URL is the base URL of local files
+func NewFromEnv(prefix string, defaultDir string, defaultPublicURL string) Store {
+	switch os.Getenv(prefix + "_STORAGE") {
+	case "", "local":
+		return &LocalStore{
+			Dir:       envOr(prefix+"_LOCAL_DIR", defaultDir),
+			PublicURL: envOr(prefix+"_PUBLIC_URL", defaultPublicURL),
+		}
+	case "s3":
+		return NewS3Store(S3Config{
+			Endpoint:  os.Getenv(prefix + "_S3_ENDPOINT"),
+			Region:    envOr(prefix+"_S3_REGION", "us-east-1"),
+			Bucket:    os.Getenv(prefix + "_S3_BUCKET"),
+			AccessKey: os.Getenv(prefix + "_S3_ACCESS_KEY"),
+			SecretKey: os.Getenv(prefix + "_S3_SECRET_KEY"),
+			PublicURL: os.Getenv(prefix + "_S3_PUBLIC_URL"),
+		})
+	default:
+		log.Fatalf("unknown %s storage %q", prefix, os.Getenv(prefix+"_STORAGE"))
+	}
+	return nil
+}
+
+// Init sets up the store of profile images, configured with IMAGE_* variables
+func Init() Store {
+	imageStore = NewFromEnv("IMAGE", "temp/images", "/api/user/images")
+	return imageStore
+}
+
+func GetImageStore() Store {
+	return imageStore
+}
+
+// InitDocuments sets up the store of verification documents, configured with
+// DOCUMENT_* variables. Documents are never served publicly, they are only
+// read back through the review endpoints
+func InitDocuments() Store {
+	documentStore = NewFromEnv("DOCUMENT", "temp/documents", "")
+	return documentStore
+}
+
+func GetDocumentStore() Store {
+	return documentStore
+}
+
+func envOr(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}